@@ -10,6 +10,8 @@ import (
 	reqContext "context"
 	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
@@ -17,15 +19,76 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/retry"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 )
 
 // opts allows the user to specify more advanced options
 type requestOptions struct {
-	Targets       []fab.Peer // targets
-	TargetFilter  fab.TargetFilter
-	Retry         retry.Opts
-	Timeouts      map[core.TimeoutType]time.Duration //timeout options for channel client operations
-	ParentContext reqContext.Context                 //parent grpc context for channel client operations (query, execute, invokehandler)
+	Targets                  []fab.Peer // targets
+	TargetFilter             fab.TargetFilter
+	Retry                    retry.Opts
+	Timeouts                 map[core.TimeoutType]time.Duration //timeout options for channel client operations
+	ParentContext            reqContext.Context                 //parent grpc context for channel client operations (query, execute, invokehandler)
+	PayloadQuorum            int                                //minimum number of endorsers that must agree on the payload (0 means all)
+	PreferredLabels          map[string]string                  //peer metadata labels preferred when selecting endorsers
+	MinEndorsingOrgs         int                                //minimum number of distinct orgs (MSP IDs) that must endorse
+	RequiredOrgs             []string                           //MSP IDs of the orgs that must endorse
+	CommitQuorum             int                                //number of event sources that must report the commit (0 means the first one)
+	Transactor               fab.Transactor                     //overrides the client transactor for the request
+	ChaincodeEventFilter     string                             //if set, the chaincode event matching the filter is returned when the transaction commits
+	EndorserConcurrency      int                                //maximum number of endorsers that are sent the proposal concurrently (0 means no limit)
+	NormalizeArgs            bool                               //canonicalize JSON args and transient values before creating the proposal
+	NonceGenerator           invoke.NonceGenerator              //generates the nonce of the transaction header (random nonce if nil)
+	OrdererComparator        fab.OrdererComparator              //order in which orderers are tried (random order if nil)
+	ProposalObserver         invoke.ProposalObserver            //invoked with the serialized proposal before it is sent
+	CompareWriteSets         bool                               //compare the RW set writes of the endorsements instead of the response payloads
+	MinBlockHeight           uint64                             //minimum ledger height of the selected endorsers (0 means any height)
+	BlockHeightWait          time.Duration                      //maximum time to wait for endorsers to reach MinBlockHeight
+	ProposalSigner           fab.ProposalSigner                 //signs the proposal and the transaction (the signing manager of the client context is used if nil)
+	TransientMapOverrides    map[string]map[string][]byte       //transient maps sent to specific endorsers, keyed by peer URL or MSP ID
+	AcceptedValidationCodes  []pb.TxValidationCode              //non-VALID validation codes that are treated as success
+	CompressProposal         bool                               //gzip-compress the proposal sent to the endorsers
+	MandatoryPeers           []fab.Peer                         //peers that are always sent the proposal and must endorse it
+	CompareCCVersions        bool                               //fail if the endorsers ran different chaincode versions
+	ProposalMetadata         map[string]string                  //gRPC metadata headers sent with the proposal to the endorsers
+	ProposalCallOptions      []grpc.CallOption                  //gRPC call options used to send the proposal to the endorsers
+	EndorsementSatisfier     invoke.EndorsementSatisfier        //stop sending the proposal once the matching endorsements satisfy the policy
+	CorrelationIDKey         string                             //transient map key of a generated correlation ID that the chaincode must echo
+	Collections              []string                           //private data collections written by the chaincode, used to select the endorsers
+	SkipPayloadComparison    bool                               //only check the status of the endorsements without comparing them
+	CommitObserver           invoke.CommitObserver              //invoked with the outcome once the transaction is committed as VALID
+	CRLProvider              invoke.CRLProvider                 //provides the CRLs against which the endorser identities are checked
+	ShadowTargets            []fab.Peer                         //shadow (canary) endorsers whose endorsement is compared but never committed
+	RetryBudget              int                                //maximum number of retries of the invoke (unlimited if 0)
+	RequireNonEmptyPayload   bool                               //fail if a successful endorsement carries an empty payload
+	Logger                   invoke.Logger                      //receives the handler log messages of the invoke instead of the fabsdk/client logger
+	ReportReadConflicts      bool                               //report the keys that the endorsers read at different versions
+	SubmitApprover           invoke.SubmitApprover              //invoked before the transaction is submitted; an error vetoes the submission
+	RetryableValidationCodes []pb.TxValidationCode              //validation codes for which the whole execute is retried
+	ProcessorDecorator       invoke.ProcessorDecorator          //wraps the proposal processor of each target before the proposal is sent
+	HealthChecker            invoke.HealthChecker               //checks the health of the targets before the proposal is sent
+	HealthCheckTimeout       time.Duration                      //timeout of each target health check (DefaultHealthCheckTimeout if 0)
+	ReserveTargets           []fab.Peer                         //healthy peers that replace the targets failing the health check
+	SortResponses            bool                               //sort the endorsement responses by endorser MSP ID and URL
+	ResponseEncoding         string                             //encoding of the response payload requested from the chaincode through the transient map
+	FallbackChaincodeID      string                             //chaincode that is endorsed instead if the endorsement of the request chaincode fails
+	FallbackCondition        invoke.FallbackCondition           //errors of the request chaincode that trigger the fallback (IsChaincodeNotFound if nil)
+	AcceptedStatuses         []int32                            //endorsement response statuses that are accepted as success in addition to 200
+	RequireStatusAgreement   bool                               //fail if the endorsers returned different response statuses
+	CollectionOwnerMSPID     string                             //org owning the collections whose peers are preferred when selecting endorsers
+	RedirectInterpreter      invoke.RedirectInterpreter         //recognizes a redirect hint in an endorser response, which is followed once
+	MaxEndorsementAge        time.Duration                      //fail the endorsements of a proposal created longer ago than this
+	OrgEndorsementTimeouts   map[string]time.Duration           //endorsement timeout of the targets of each org (MSP ID)
+	TraceSelection           bool                               //record the endorser selection attempts in the response
+	CanonicalWriteSets       bool                               //sort the compared write sets by namespace and key
+	EndorsementContext       reqContext.Context                 //deadline, cancellation and values applied to the endorsement calls only
+	ConfirmationPeer         fab.Peer                           //trusted peer that must confirm the endorsed result before it's committed
+	PayloadNormalizer        invoke.PayloadNormalizer           //normalizes the response payloads before they're compared
+	RequestID                string                             //user-defined request ID included in the handler log messages
+	CheckConfigSequence      bool                               //abort if the channel config sequence changes between selection and commit
+	EventFailoverWindow      time.Duration                      //fail over to an alternate event service if no TxStatus event arrives within the window
+	CheckTransactionSize     bool                               //reject a transaction larger than MaxTransactionBytes before it's submitted
+	MaxTransactionBytes      int                                //maximum estimated size of the transaction envelope (0 for the AbsoluteMaxBytes of the channel)
 }
 
 // RequestOption func for each Opts argument
@@ -46,6 +109,22 @@ type Response struct {
 	TxValidationCode pb.TxValidationCode
 	Proposal         *fab.TransactionProposal
 	Responses        []*fab.TransactionProposalResponse
+	Dissenters       []*fab.TransactionProposalResponse
+	ChaincodeEvent   *fab.CCEvent
+	Endorsements     []*invoke.EndorsementResult
+	StaleEndorsers   []string
+	DivergentShadows []string
+	ReadConflicts    []invoke.ReadVersionConflict
+	CommitLatency    invoke.CommitLatency
+	ResponseEncoding string
+	SelectionTrace   *invoke.SelectionTrace
+}
+
+// BatchResponse contains the response of a request submitted with ExecuteBatch
+// or the error if the request failed
+type BatchResponse struct {
+	Response Response
+	Error    error
 }
 
 //WithTargets encapsulates ProposalProcessors to Option
@@ -117,3 +196,631 @@ func WithParentContext(parentContext reqContext.Context) RequestOption {
 		return nil
 	}
 }
+
+// WithPayloadQuorum allows endorsement validation to succeed when at least n endorsers
+// returned the same payload. The most common payload is selected and the endorsers that
+// returned a different payload are recorded as dissenters on the response.
+func WithPayloadQuorum(n int) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if n < 0 {
+			return errors.New("payload quorum must not be negative")
+		}
+		o.PayloadQuorum = n
+		return nil
+	}
+}
+
+// WithPreferredLabels specifies peer metadata labels (for example, "region") that are preferred
+// when endorsers are selected. Selection first considers only the peers whose metadata matches
+// all of the given labels and falls back to all peers if no matching endorsers are found.
+// The metadata of a peer are the labels of the peer's config (the "labels" of the peer in the
+// network config); note that the config loader lower-cases the label keys.
+func WithPreferredLabels(labels map[string]string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.PreferredLabels = labels
+		return nil
+	}
+}
+
+// WithCollectionAffinity prefers the peers of the org (MSP ID) that owns the private data collections of the request
+// when endorsers are selected, for better cache locality and less cross-org data movement. Selection first considers
+// only the peers of the owning org, which succeeds if they alone satisfy the endorsement policy, and otherwise falls
+// back to all peers so that the policy is still satisfied.
+func WithCollectionAffinity(ownerMSPID string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.CollectionOwnerMSPID = ownerMSPID
+		return nil
+	}
+}
+
+// WithMinEndorsingOrgs causes endorsement validation to fail unless the endorsements
+// were received from at least n distinct orgs (MSP IDs).
+func WithMinEndorsingOrgs(n int) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if n < 0 {
+			return errors.New("minimum endorsing orgs must not be negative")
+		}
+		o.MinEndorsingOrgs = n
+		return nil
+	}
+}
+
+// WithRequiredOrgs causes endorsement validation to fail unless each of the given
+// orgs (MSP IDs) returned at least one endorsement.
+func WithRequiredOrgs(mspIDs []string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.RequiredOrgs = mspIDs
+		return nil
+	}
+}
+
+// WithCommitQuorum specifies the number of event sources that must report the
+// transaction status before Execute considers the transaction committed. Additional
+// event sources are provided to the client using WithAdditionalEventServices.
+func WithCommitQuorum(n int) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if n < 0 {
+			return errors.New("commit quorum must not be negative")
+		}
+		o.CommitQuorum = n
+		return nil
+	}
+}
+
+// WithTransactor overrides the transactor that is used to create and send the
+// transaction proposal and the transaction for the request (for example, a batching
+// transactor). The channel transactor is used if none is supplied.
+func WithTransactor(transactor fab.Transactor) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.Transactor = transactor
+		return nil
+	}
+}
+
+// WithChaincodeEvent causes Execute to also wait for the chaincode event that is set by the
+// transaction and whose name matches the given filter (a regular expression). The event is
+// returned in the response once the transaction commits as valid. Execute fails if the
+// event is not received before the request times out.
+func WithChaincodeEvent(eventFilter string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ChaincodeEventFilter = eventFilter
+		return nil
+	}
+}
+
+// WithEndorserConcurrency limits the number of endorsers that are sent the transaction
+// proposal concurrently. This prevents the client from opening a large number of
+// connections at once when the request is sent to a wide set of endorsers.
+func WithEndorserConcurrency(n int) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if n < 0 {
+			return errors.New("endorser concurrency must not be negative")
+		}
+		o.EndorserConcurrency = n
+		return nil
+	}
+}
+
+// WithNormalizedArgs canonicalizes the args and transient map values that are
+// JSON objects or arrays (sorted keys, no insignificant whitespace) before the
+// proposal is created, so that logically identical requests are serialized identically.
+func WithNormalizedArgs() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.NormalizeArgs = true
+		return nil
+	}
+}
+
+// WithNonce specifies the nonce that is used in the transaction header, which
+// determines the transaction ID. Note that a retry of the request uses the same nonce.
+func WithNonce(nonce []byte) RequestOption {
+	return WithNonceGenerator(func() ([]byte, error) {
+		return nonce, nil
+	})
+}
+
+// WithNonceGenerator specifies a generator for the nonce that is used in the
+// transaction header. By default a random nonce is generated.
+func WithNonceGenerator(generator invoke.NonceGenerator) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.NonceGenerator = generator
+		return nil
+	}
+}
+
+// WithOrdererComparator specifies the order in which the orderers are tried when the transaction
+// is sent (the comparator returns true if orderer o1 is preferred over orderer o2). The next orderer
+// is tried if the preferred orderer is unreachable. By default the orderers are tried in random order.
+func WithOrdererComparator(comparator fab.OrdererComparator) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.OrdererComparator = comparator
+		return nil
+	}
+}
+
+// WithPreferredOrderer causes the transaction to be sent to the orderer with the given URL (for
+// example, the nearest orderer). The other orderers are tried if the preferred orderer is unreachable.
+func WithPreferredOrderer(url string) RequestOption {
+	return WithOrdererComparator(func(o1, o2 fab.Orderer) bool {
+		return o1.URL() == url && o2.URL() != url
+	})
+}
+
+// WithProposalObserver specifies an observer that is invoked with the serialized transaction
+// proposal (the exact content that is signed) before the proposal is sent to the endorsers,
+// for example to archive the signed content for audits. The proposal is only serialized for
+// the observer if this option is specified.
+func WithProposalObserver(observer invoke.ProposalObserver) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ProposalObserver = observer
+		return nil
+	}
+}
+
+// WithWriteSetComparison causes the endorsements to be validated by comparing the writes in the
+// RW sets of the proposal responses instead of the response payloads. Endorsements match if their
+// writes agree, even if the payloads returned by the chaincode differ (for example, if the payload
+// contains nondeterministic content). Note that an invoke can still only be committed if the
+// endorsed proposal response payloads are identical (including the payloads and the reads); Execute
+// fails with status EndorsementMismatch if they differ. The comparison is meant for the validation
+// of queries and for selecting the matching endorsements with WithPayloadQuorum.
+func WithWriteSetComparison() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.CompareWriteSets = true
+		return nil
+	}
+}
+
+// WithCanonicalWriteSetComparison is like WithWriteSetComparison but the writes are sorted by namespace and key
+// before they're compared, so that endorsements whose write sets contain the same writes in a different order (for
+// example, from a chaincode iterating over a map) match. Note that an invoke can still only be committed if the
+// endorsed proposal response payloads are identical; the canonical comparison avoids spurious mismatches in the
+// validation of queries and when selecting the matching endorsements with WithPayloadQuorum.
+func WithCanonicalWriteSetComparison() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.CompareWriteSets = true
+		o.CanonicalWriteSets = true
+		return nil
+	}
+}
+
+// WithMinBlockHeight causes only endorsers whose ledger height is at least the given height to be
+// selected, for example to read the state written by a previously committed transaction. If no such
+// endorsers are available then selection is retried until maxWait expires, giving lagging peers a
+// chance to catch up. The ledger height of a peer that implements fab.PeerState is the height it reports;
+// the ledger height of any other peer is queried from the peer (with the GetChainInfo function of qscc) on
+// each selection attempt. Peers whose ledger height can't be determined are not selected.
+// The option does not apply to the targets specified by WithTargets.
+func WithMinBlockHeight(height uint64, maxWait time.Duration) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if maxWait < 0 {
+			return errors.New("block height wait must not be negative")
+		}
+		o.MinBlockHeight = height
+		o.BlockHeightWait = maxWait
+		return nil
+	}
+}
+
+// WithProposalSigner specifies the function that signs the transaction proposal and, for Execute,
+// the transaction envelope sent to the orderer, for example to produce the signatures with an
+// external HSM. The signer is invoked with the serialized proposal or envelope payload and returns
+// the signature. The transaction header (including the creator) is still created from the client
+// context, so the signer must sign with the key of the client's identity.
+func WithProposalSigner(signer fab.ProposalSigner) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ProposalSigner = signer
+		return nil
+	}
+}
+
+// WithEndorserTransientMap specifies the transient map that is sent to the endorsers matching the
+// given key (a peer URL or an MSP ID) instead of the transient map of the request, for example to
+// send private data only to the org that it is intended for. An override keyed by the URL of a peer
+// takes precedence over an override keyed by the MSP ID of the peer.
+func WithEndorserTransientMap(key string, transientMap map[string][]byte) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if o.TransientMapOverrides == nil {
+			o.TransientMapOverrides = make(map[string]map[string][]byte)
+		}
+		o.TransientMapOverrides[key] = transientMap
+		return nil
+	}
+}
+
+// WithAcceptedValidationCodes specifies validation codes other than VALID that are treated as success
+// when the transaction is committed (for example, DUPLICATE_TXID when resubmitting a transaction
+// idempotently). Execute returns as soon as an accepted code is received, without an error, and the
+// code is returned in Response.TxValidationCode for the caller to interpret.
+func WithAcceptedValidationCodes(codes ...pb.TxValidationCode) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.AcceptedValidationCodes = append(o.AcceptedValidationCodes, codes...)
+		return nil
+	}
+}
+
+// WithRetryableValidationCodes specifies validation codes for which the whole execute is retried (with a new
+// transaction ID) instead of failing, for example when the committing peers report a transient failure. The
+// retries are subject to the retry options specified with WithRetry and to the retry budget of the invoke.
+func WithRetryableValidationCodes(codes ...pb.TxValidationCode) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.RetryableValidationCodes = append(o.RetryableValidationCodes, codes...)
+		return nil
+	}
+}
+
+// WithProcessorDecorator specifies a decorator that is applied to the proposal processor of each target before
+// the proposal is sent, for example to add instrumentation or to use a specialized transport. By default the
+// proposal is sent to the target peer.
+func WithProcessorDecorator(decorator invoke.ProcessorDecorator) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ProcessorDecorator = decorator
+		return nil
+	}
+}
+
+// WithHealthCheck enables a health check of the targets (for example, invoke.DialHealthCheck) before the proposal
+// is sent. The targets are checked concurrently, each with the given timeout (invoke.DefaultHealthCheckTimeout if
+// not positive). A target that fails the check is replaced by the first healthy reserve peer that isn't already a
+// target, or removed if there is none. The invoke fails with a NoPeersFound status if none of the targets is healthy.
+func WithHealthCheck(checker invoke.HealthChecker, timeout time.Duration, reserve ...fab.Peer) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.HealthChecker = checker
+		o.HealthCheckTimeout = timeout
+		o.ReserveTargets = reserve
+		return nil
+	}
+}
+
+// WithSortedResponses sorts Response.Responses by the MSP ID and then the URL of the endorser, instead of the
+// order in which the endorsements were received, so that the responses can be processed deterministically.
+func WithSortedResponses() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.SortResponses = true
+		return nil
+	}
+}
+
+// WithResponseEncoding requests the given encoding of the response payload (for example, invoke.EncodingJSON) from
+// the chaincode by setting invoke.ResponseEncodingKey in the transient map. The encoding that the chaincode reports
+// in the message of its response is returned in Response.ResponseEncoding so that the payload can be decoded.
+func WithResponseEncoding(encoding string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ResponseEncoding = encoding
+		return nil
+	}
+}
+
+// WithFallbackChaincode specifies a fallback chaincode for blue/green deployments, in which the old and new chaincode
+// run in parallel under different names. If the endorsement of the request chaincode fails with an error satisfying
+// the given condition (invoke.IsChaincodeNotFound if nil) then a proposal for the fallback chaincode is sent to the
+// endorsers selected for the fallback chaincode (or to the same targets, if specified), and the rest of the invoke
+// uses the endorsements of the fallback chaincode. A retried invoke starts again with the request chaincode.
+func WithFallbackChaincode(chaincodeID string, condition invoke.FallbackCondition) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.FallbackChaincodeID = chaincodeID
+		o.FallbackCondition = condition
+		return nil
+	}
+}
+
+// WithAcceptedStatuses accepts the given endorsement response statuses as success in addition to 200, for chaincodes
+// that return a status such as 201. If strict is set then all of the endorsers must return the identical status,
+// otherwise the invoke fails with a StatusDisagreement status. Note that a transaction can only be assembled from
+// responses with status 200, so the accepted statuses are mainly useful for queries.
+func WithAcceptedStatuses(strict bool, statuses ...int32) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.AcceptedStatuses = statuses
+		o.RequireStatusAgreement = strict
+		return nil
+	}
+}
+
+// WithRedirectInterpreter specifies an interpreter (for example, invoke.RedirectOnMessage) that recognizes a redirect
+// or not-leader hint in the response of an endorser, for peers deployed behind a load balancer. The proposal is then
+// sent once to the indicated peer, which must be known to the discovery service, and its response is used instead.
+func WithRedirectInterpreter(interpreter invoke.RedirectInterpreter) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.RedirectInterpreter = interpreter
+		return nil
+	}
+}
+
+// WithMaxEndorsementAge fails the endorsement validation with a StaleEndorsement status if the proposal that was
+// endorsed (according to the timestamp in its header) was created longer ago than maxAge. This guards against
+// replayed endorsements, for example when the proposal is signed offline and submitted later. The age is checked
+// when the endorsements are validated and again before the transaction is submitted, including by
+// SubmitEndorsedTransaction and SubmitPending.
+func WithMaxEndorsementAge(maxAge time.Duration) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.MaxEndorsementAge = maxAge
+		return nil
+	}
+}
+
+// WithOrgEndorsementTimeouts sets the endorsement timeout of the targets of each org, keyed by MSP ID. A target
+// that doesn't respond within the timeout of its org is abandoned (and counts as a failed endorsement), so a slow
+// org can be given more time while fast orgs are held to tight deadlines. The timeouts can't extend the overall
+// execute timeout of the request; targets of orgs without an entry are only bound by that timeout.
+func WithOrgEndorsementTimeouts(timeouts map[string]time.Duration) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.OrgEndorsementTimeouts = make(map[string]time.Duration, len(timeouts))
+		for mspID, timeout := range timeouts {
+			o.OrgEndorsementTimeouts[mspID] = timeout
+		}
+		return nil
+	}
+}
+
+// WithSelectionTrace records how the endorsers were selected in Response.SelectionTrace: each selection attempt
+// (collection owner, preferred labels, all peers) with the selected endorsers and the discovered peers excluded by
+// its filters, and the attempt whose endorsers were used. It is intended for debugging endorsement routing; nothing
+// is recorded (or looked up from discovery) unless the option is set.
+func WithSelectionTrace() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.TraceSelection = true
+		return nil
+	}
+}
+
+// WithEndorsementContext applies the deadline, cancellation and values of the given context to the calls that
+// send the proposal to the endorsers, in addition to those of the request (see WithParentContext). It's typically a
+// child of the parent context with a tighter deadline, so that the endorsement is abandoned earlier while the
+// commit is still bound by the deadline of the parent context.
+func WithEndorsementContext(endorsementContext reqContext.Context) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.EndorsementContext = endorsementContext
+		return nil
+	}
+}
+
+// WithConfirmationPeer causes Execute to send a separate confirmation query for the request to the given trusted
+// peer once the proposal has been endorsed, and to abort the invoke before committing if the confirmation fails or
+// its result disagrees with the endorsed result (with a ConfirmationMismatch status whose details are the endorsers
+// that disagree with the confirmation). The results are compared in the same way as the endorsements (see
+// WithWriteSetComparison). The peer should be independent of the endorsers.
+func WithConfirmationPeer(peer fab.Peer) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ConfirmationPeer = peer
+		return nil
+	}
+}
+
+// WithPayloadNormalizer normalizes the response payloads with the given function (for example,
+// invoke.IgnoreJSONFields) before they're compared across the endorsers, so that payloads that only differ in
+// non-semantic content such as a per-peer nonce match. The unnormalized payload is returned in Response.Payload.
+// Note that an invoke can still only be committed if the endorsed proposal response payloads are identical.
+func WithPayloadNormalizer(normalizer invoke.PayloadNormalizer) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.PayloadNormalizer = normalizer
+		return nil
+	}
+}
+
+// WithProposalCompression enables gzip compression of the transaction proposal sent to the endorsers.
+// It is useful when the chaincode arguments are large and CPU is cheaper than bandwidth. The compressor is set
+// per call, so the proposal is sent on the same (cached) connections to the endorsers as other proposals.
+func WithProposalCompression() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.CompressProposal = true
+		return nil
+	}
+}
+
+// WithMandatoryPeers specifies peers (for example, a regulator's peer) that are always sent the
+// proposal, in addition to the targets (or the endorsers chosen by the selection service if no
+// targets are specified). The request fails unless each of the mandatory peers returns a valid endorsement.
+func WithMandatoryPeers(peers ...fab.Peer) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.MandatoryPeers = append(o.MandatoryPeers, peers...)
+		return nil
+	}
+}
+
+// WithChaincodeVersionValidation checks that all of the endorsers ran the same version of the chaincode
+// (if the endorsers include the chaincode version in their responses). If they didn't, the request fails with
+// a ChaincodeVersionMismatch status instead of a generic endorsement mismatch.
+func WithChaincodeVersionValidation() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.CompareCCVersions = true
+		return nil
+	}
+}
+
+// WithProposalMetadata adds a gRPC metadata header (for example, x-tenant-id) to the calls that send
+// the proposal to the endorsers, so that it reaches the interceptors of a proxy or of the endorsers
+func WithProposalMetadata(key, value string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if o.ProposalMetadata == nil {
+			o.ProposalMetadata = make(map[string]string)
+		}
+		o.ProposalMetadata[key] = value
+		return nil
+	}
+}
+
+// WithProposalCallOptions adds gRPC call options to the calls that send the proposal to the endorsers
+func WithProposalCallOptions(callOptions ...grpc.CallOption) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ProposalCallOptions = append(o.ProposalCallOptions, callOptions...)
+		return nil
+	}
+}
+
+// WithEarlySatisfaction cancels the outstanding proposal calls as soon as the matching endorsements received so
+// far satisfy the endorsement policy (for example, invoke.OrgsSatisfier), which reduces latency and load when more
+// endorsers are selected than the policy requires. Only the endorsements that satisfied the policy are returned.
+func WithEarlySatisfaction(satisfier invoke.EndorsementSatisfier) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.EndorsementSatisfier = satisfier
+		return nil
+	}
+}
+
+// WithEventFailover waits for the TxStatus event on the event service of the channel only and, each time the given
+// window elapses without the event, fails over to the next of the event services added with
+// WithAdditionalEventServices (for example, connected to other event peers) and continues waiting. This prevents
+// a single stalled event peer from causing a false commit failure, while the commit is still decided by the event
+// service of the channel when it's healthy. The TxStatus event is registered on all of the event services before
+// the transaction is submitted, so the event of an alternate event service that delivered the block of the
+// transaction before the failover is held and delivered by the failover.
+func WithEventFailover(window time.Duration) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.EventFailoverWindow = window
+		return nil
+	}
+}
+
+// WithTransactionSizeLimit rejects the transaction with status TransactionTooLarge, without submitting it, if the
+// estimated size of its envelope exceeds maxBytes. If maxBytes is 0 then the AbsoluteMaxBytes of the batch size in
+// the channel config is used, which is the size above which the orderer rejects the transaction.
+func WithTransactionSizeLimit(maxBytes int) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if maxBytes < 0 {
+			return errors.Errorf("invalid transaction size limit %d", maxBytes)
+		}
+		o.CheckTransactionSize = true
+		o.MaxTransactionBytes = maxBytes
+		return nil
+	}
+}
+
+// WithConfigSequenceCheck records the sequence of the channel config when the endorsers are selected and checks it
+// again before the transaction is submitted. If the channel config changed in the meantime (for example, the
+// endorsement policy was updated) then the transaction isn't submitted and the invoke fails with a
+// ConfigSequenceChanged status. The channel config is cached by the channel service, so a config update is only
+// detected once the cached config is refreshed.
+func WithConfigSequenceCheck() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.CheckConfigSequence = true
+		return nil
+	}
+}
+
+// WithRequestID attaches a user-defined request ID to the request. The request ID is included as a field
+// in every log message of the handlers, so that the SDK log messages of an invoke can be correlated with
+// the application log messages by the application's ID rather than only by the transaction ID.
+func WithRequestID(id string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.RequestID = id
+		return nil
+	}
+}
+
+// WithCorrelationID injects a generated correlation ID into the transient map under the given key. The chaincode is
+// expected to echo the correlation ID in its response; the endorsers that don't are returned in Response.StaleEndorsers
+// since they may be running stale chaincode.
+func WithCorrelationID(key string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.CorrelationIDKey = key
+		return nil
+	}
+}
+
+// WithCollections specifies the private data collections that the chaincode writes to, so that the selection
+// service selects the peers satisfying the chaincode policy among the peers of the orgs that are members of the
+// collections. The request fails if the selection service can't honour the collections (for example, static
+// selection). It's ignored if Targets are specified.
+func WithCollections(names ...string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.Collections = append(o.Collections, names...)
+		return nil
+	}
+}
+
+// WithSkipPayloadComparison skips the comparison of the endorsements (payloads or write sets) across endorsers
+// and only checks that they were successful. It's intended for high-frequency queries whose result is not
+// compared (for example, queries sent to a single target). The comparison is always skipped for a single endorsement.
+func WithSkipPayloadComparison() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.SkipPayloadComparison = true
+		return nil
+	}
+}
+
+// WithCommitObserver specifies an observer that is invoked once the transaction is committed as VALID, with the
+// transaction ID, the number of the block and the keys written by the transaction. It isn't invoked if the
+// transaction is invalid (even if its validation code is accepted with WithAcceptedValidationCodes).
+func WithCommitObserver(observer invoke.CommitObserver) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.CommitObserver = observer
+		return nil
+	}
+}
+
+// WithCRLProvider specifies a provider of certificate revocation lists. The invoke is aborted with status
+// EndorserRevoked if the identity of any endorser is revoked by one of the CRLs.
+func WithCRLProvider(provider invoke.CRLProvider) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.CRLProvider = provider
+		return nil
+	}
+}
+
+// WithShadowTargets specifies shadow endorsers (for example, canary peers running a new version of the chaincode) to
+// which a separate proposal is sent concurrently with the primary one. The shadow endorsements are never committed; the
+// shadow endorsers that fail or whose endorsement differs from the primary endorsement are returned in
+// Response.DivergentShadows. The shadow endorsements are only waited for briefly (one second) once the request has
+// been handled, and the shadow endorsers that haven't responded by then are also returned as divergent.
+func WithShadowTargets(targets ...fab.Peer) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ShadowTargets = targets
+		return nil
+	}
+}
+
+// WithRetryBudget limits the total number of retries of the invoke, across the handler chain and any handlers
+// that retry, to the given number. The invoke fails with status RetryBudgetExhausted once the budget is
+// exhausted. Without a budget the retries are only limited by the retry options of each handler.
+func WithRetryBudget(retries int) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.RetryBudget = retries
+		return nil
+	}
+}
+
+// WithRequireNonEmptyPayload fails the request with status EmptyPayload if a successful endorsement carries an
+// empty payload (for example, because an empty payload from a query indicates a chaincode bug)
+func WithRequireNonEmptyPayload() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.RequireNonEmptyPayload = true
+		return nil
+	}
+}
+
+// WithLogger specifies a logger which receives the handler log messages of this invoke (at all levels) instead of
+// the fabsdk/client logger
+func WithLogger(logger invoke.Logger) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.Logger = logger
+		return nil
+	}
+}
+
+// WithLogLevel emits the handler log messages of this invoke at or above the given level, independently of the level
+// of the fabsdk/client module (for example, to debug a single problematic transaction in production)
+func WithLogLevel(level logging.Level) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.Logger = invoke.NewLevelLogger(level)
+		return nil
+	}
+}
+
+// WithReadConflictReport reports the keys that the endorsers read at different versions (based on different block
+// heights) in Response.ReadConflicts, which predicts a likely MVCC conflict at commit time. The request doesn't fail.
+func WithReadConflictReport() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ReportReadConflicts = true
+		return nil
+	}
+}
+
+// WithSubmitApprover specifies a callback that is invoked with the validated endorsement response before the
+// transaction is submitted to the ordering service. If the callback returns an error then the transaction isn't
+// submitted and Execute fails with status VetoedBeforeSubmit.
+func WithSubmitApprover(approver invoke.SubmitApprover) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.SubmitApprover = approver
+		return nil
+	}
+}
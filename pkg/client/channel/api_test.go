@@ -99,3 +99,15 @@ func TestTimeoutOptions(t *testing.T) {
 	assert.True(t, opts.Timeouts[core.Query] == 45*time.Second, "timeout value by type didn't match with one supplied")
 
 }
+
+func TestWithPreferredOrderer(t *testing.T) {
+	opts := requestOptions{}
+	err := WithPreferredOrderer("orderer2.example.com")(nil, &opts)
+	assert.Nil(t, err)
+
+	orderer1 := fcmocks.NewMockOrderer("orderer1.example.com", nil)
+	orderer2 := fcmocks.NewMockOrderer("orderer2.example.com", nil)
+	assert.True(t, opts.OrdererComparator(orderer2, orderer1))
+	assert.False(t, opts.OrdererComparator(orderer1, orderer2))
+	assert.False(t, opts.OrdererComparator(orderer2, orderer2))
+}
@@ -9,33 +9,59 @@ package channel
 
 import (
 	reqContext "context"
+	"math/rand"
+	"sync"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery/greylist"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery/penaltybox"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
 	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/multi"
 	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
 	"github.com/pkg/errors"
 )
 
 var logger = logging.NewLogger("fabsdk/client")
 
+// defaultBatchWorkers is the number of requests of a batch that are executed concurrently by default
+const defaultBatchWorkers = 10
+
 // Client enables access to a channel on a Fabric network.
 //
 // A channel client instance provides a handler to interact with peers on specified channel.
 // An application that requires interaction with multiple channels should create a separate
 // instance of the channel client for each channel. Channel client supports non-admin functions only.
 type Client struct {
-	context      context.Channel
-	membership   fab.ChannelMembership
-	eventService fab.EventService
-	greylist     *greylist.Filter
+	context                 context.Channel
+	membership              fab.ChannelMembership
+	eventService            fab.EventService
+	greylist                *greylist.Filter
+	transformer             invoke.RequestTransformer
+	additionalEventServices []fab.EventService
+	penaltyBox              *penaltybox.Filter
+	selectionBreaker        *invoke.SelectionCircuitBreaker
+	latencyTracker          *invoke.LatencyTracker
+	eventServiceResolver    invoke.EventServiceResolver
+	selectionCache          invoke.SelectionCache
+	selectionRandom         *rand.Rand
+	endorserCommManager     fab.CommManager
+	concurrencyLimiter      *invoke.ConcurrencyLimiter
+	tracer                  invoke.Tracer
+	errorRateTracker        *invoke.ErrorRateTracker
+	batchCommitListener     *invoke.BatchCommitListener
+	chaincodeRateLimiter    *invoke.ChaincodeRateLimiter
+	txStatusFanout          *invoke.TxStatusFanout
+	batchWorkers            int
 }
 
 // ClientOption describes a functional parameter for the New constructor
@@ -66,19 +92,202 @@ func New(channelProvider context.ChannelProvider, opts ...ClientOption) (*Client
 	}
 
 	channelClient := Client{
-		membership:   membership,
-		eventService: eventService,
-		greylist:     greylistProvider,
-		context:      channelContext,
+		membership:     membership,
+		eventService:   eventService,
+		greylist:       greylistProvider,
+		context:        channelContext,
+		txStatusFanout: invoke.NewTxStatusFanout(),
 	}
 
 	for _, param := range opts {
-		param(&channelClient)
+		err := param(&channelClient)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to create Client")
+		}
 	}
 
 	return &channelClient, nil
 }
 
+// WithRequestTransformer sets a transformer that is invoked with the chaincode invoke request
+// of every query and execute just before the transaction proposal is created. It allows
+// cross-cutting changes to Fcn, Args and TransientMap (for example, injecting a tenant argument).
+func WithRequestTransformer(transformer invoke.RequestTransformer) ClientOption {
+	return func(client *Client) error {
+		client.transformer = transformer
+		return nil
+	}
+}
+
+// WithAdditionalEventServices adds event services (for example, connected to other peers) on which
+// Execute also registers for the transaction status. The transaction is considered committed as
+// soon as the first event source (or the number given by WithCommitQuorum) reports the status, so that
+// a single lagging event source doesn't cause a spurious timeout.
+func WithAdditionalEventServices(eventServices ...fab.EventService) ClientOption {
+	return func(client *Client) error {
+		client.additionalEventServices = append(client.additionalEventServices, eventServices...)
+		return nil
+	}
+}
+
+// WithPenaltyBox installs a penalty box filter. A peer that can't be reached (a connection failure or a
+// gRPC transport error) is put in the penalty box and is not selected as an endorser until the TTL of the
+// penalty box expires. The same penalty box may be shared by multiple clients.
+func WithPenaltyBox(filter *penaltybox.Filter) ClientOption {
+	return func(client *Client) error {
+		client.penaltyBox = filter
+		return nil
+	}
+}
+
+// WithSelectionCircuitBreaker installs a circuit breaker around the selection service. After the given
+// number of consecutive selection failures, endorser selection fails fast (or uses the given fallback targets)
+// until the cool-down period expires, at which point the selection service is probed again.
+func WithSelectionCircuitBreaker(threshold int, coolDown time.Duration, fallbackTargets ...fab.Peer) ClientOption {
+	return func(client *Client) error {
+		if threshold <= 0 {
+			return errors.New("circuit breaker threshold must be greater than zero")
+		}
+		if coolDown <= 0 {
+			return errors.New("circuit breaker cool-down must be greater than zero")
+		}
+		client.selectionBreaker = invoke.NewSelectionCircuitBreaker(threshold, coolDown, fallbackTargets...)
+		return nil
+	}
+}
+
+// WithAdaptiveEndorsementTimeout enables tracking of the endorsement latencies of the peers. Once enough
+// latencies have been tracked for a peer, an endorsement by the peer is abandoned if it takes longer than
+// the p99 latency of the peer multiplied by factor (invoke.DefaultLatencyFactor if not positive), but never
+// less than minTimeout. The request timeout still applies to peers without enough tracked latencies.
+func WithAdaptiveEndorsementTimeout(factor float64, minTimeout time.Duration) ClientOption {
+	return func(client *Client) error {
+		client.latencyTracker = invoke.NewLatencyTracker(factor, minTimeout)
+		return nil
+	}
+}
+
+// WithEventServiceResolver sets the resolver that returns the event service on which Execute registers
+// for the transaction status, based on the channel of the transaction. It allows applications that commit
+// transactions on several channels to share one set of event services (for example, invoke.ChannelEventServices).
+func WithEventServiceResolver(resolver invoke.EventServiceResolver) ClientOption {
+	return func(client *Client) error {
+		client.eventServiceResolver = resolver
+		return nil
+	}
+}
+
+// WithSelectionCache caches the endorsers selected for each chaincode so that the selection service isn't
+// called on every invoke. Cached endorsers are not used once they expire, once the discovery service reports
+// a different set of peers, or if any of them is filtered out (for example, because it's greylisted).
+func WithSelectionCache(cache invoke.SelectionCache) ClientOption {
+	return func(client *Client) error {
+		client.selectionCache = cache
+		return nil
+	}
+}
+
+// WithSelectionSeed seeds the source of randomness used by the selection service to choose between equivalent
+// sets of endorsers, so that a client session selects the same endorsers across runs given identical inputs
+// (for example, for reproducible load tests). By default the selection is not deterministic.
+func WithSelectionSeed(seed int64) ClientOption {
+	return func(client *Client) error {
+		client.selectionRandom = invoke.NewSeededRandom(seed)
+		return nil
+	}
+}
+
+// WithConnectionPool specifies the connection manager used to connect to the endorsers, typically a pool created
+// with comm.NewConnectionPool that keeps warm connections (up to a maximum per peer) to frequently contacted endorsers
+// and evicts idle ones. The caller is responsible for closing the pool. By default the connection manager of the SDK
+// is used.
+func WithConnectionPool(pool fab.CommManager) ClientOption {
+	return func(client *Client) error {
+		client.endorserCommManager = pool
+		return nil
+	}
+}
+
+// WithConcurrencyLimit bounds the number of invokes of the client that are in the endorsement phase at the same
+// time to maxConcurrent, so that a burst of invokes doesn't overwhelm the endorsers. Once the limit is reached, an
+// invoke waits for another invoke to complete its endorsement (or for the request to time out) or, if failFast is set,
+// fails immediately with a ConcurrencyLimitExceeded status.
+func WithConcurrencyLimit(maxConcurrent int, failFast bool) ClientOption {
+	return func(client *Client) error {
+		client.concurrencyLimiter = invoke.NewConcurrencyLimiter(maxConcurrent, failFast)
+		return nil
+	}
+}
+
+// WithBatchWorkers sets the maximum number of requests of a batch submitted with ExecuteBatch that are executed
+// at the same time (10 by default).
+func WithBatchWorkers(workers int) ClientOption {
+	return func(client *Client) error {
+		if workers <= 0 {
+			return errors.Errorf("invalid number of batch workers: %d", workers)
+		}
+		client.batchWorkers = workers
+		return nil
+	}
+}
+
+// WithChaincodeRateLimit limits the invokes of the given chaincode to perSecond invokes per second on average, with
+// bursts of up to burst invokes, independently of the concurrency limit of the client. Once the limit is reached, an
+// invoke of the chaincode waits before it's endorsed (or fails with a Timeout status if the request times out first).
+// The option may be given once for each chaincode to limit; the invokes of the other chaincodes aren't throttled.
+func WithChaincodeRateLimit(chaincodeID string, perSecond float64, burst int) ClientOption {
+	return func(client *Client) error {
+		if client.chaincodeRateLimiter == nil {
+			client.chaincodeRateLimiter = invoke.NewChaincodeRateLimiter()
+		}
+		client.chaincodeRateLimiter.SetLimit(chaincodeID, perSecond, burst)
+		return nil
+	}
+}
+
+// WithCommitLatencyRecording decorates the event service of the client with an invoke.RecordingEventService,
+// which records how long the TxStatus event of each transaction took to arrive. The latency is reported in
+// Response.CommitLatency so that committer lag can be tracked independently of the endorsement latency.
+func WithCommitLatencyRecording() ClientOption {
+	return func(client *Client) error {
+		client.eventService = invoke.NewRecordingEventService(client.eventService)
+		return nil
+	}
+}
+
+// WithTracer sets the tracer with which the built-in handlers (endorser selection, endorsement, validation and
+// commit) start spans, for example backed by OpenTelemetry. The spans are children of the span carried by the
+// parent context of the request (see WithParentContext), so an invoke shows as a span tree.
+func WithTracer(tracer invoke.Tracer) ClientOption {
+	return func(client *Client) error {
+		client.tracer = tracer
+		return nil
+	}
+}
+
+// WithErrorRateWeighting tracks the error rate of each peer over the given sliding window (invoke.DefaultErrorRateWindow
+// if not positive) and biases the selection of endorsers away from the peers with high error rates. Unlike the
+// penalty box, a peer that errored isn't excluded; it's selected less often until its errors age out of the window.
+// The weights only apply to a selection service that supports them (see selectopts.WithPeerWeight) and not to the
+// endorsers returned by the selection cache.
+func WithErrorRateWeighting(window time.Duration) ClientOption {
+	return func(client *Client) error {
+		client.errorRateTracker = invoke.NewErrorRateTracker(window)
+		return nil
+	}
+}
+
+// WithBatchCommit waits for the TxStatus events of the executed transactions through the given listener, which
+// registers a single filtered block event listener per event service instead of a TxStatus registration per
+// transaction. This reduces the registration churn when many transactions are submitted concurrently. The listener
+// may be shared by several clients; it should be closed once the clients are no longer used.
+func WithBatchCommit(listener *invoke.BatchCommitListener) ClientOption {
+	return func(client *Client) error {
+		client.batchCommitListener = listener
+		return nil
+	}
+}
+
 // Query chaincode using request and optional options provided
 func (cc *Client) Query(request Request, options ...RequestOption) (Response, error) {
 	return cc.InvokeHandler(invoke.NewQueryHandler(), request, cc.addDefaultTimeout(cc.context, core.Query, options...)...)
@@ -89,6 +298,212 @@ func (cc *Client) Execute(request Request, options ...RequestOption) (Response,
 	return cc.InvokeHandler(invoke.NewExecuteHandler(), request, cc.addDefaultTimeout(cc.context, core.Execute, options...)...)
 }
 
+// QueryAggregate sends the query to all of the targets (the selected endorsers unless targets are specified
+// with WithTargets or WithTargetURLs) without requiring their responses to match, and returns the payload returned
+// by the largest number of targets along with the ratio of targets that agree on it. This may be used to query
+// several peers for redundancy while detecting a compromised or faulty peer.
+func (cc *Client) QueryAggregate(request Request, options ...RequestOption) (invoke.AggregateResult, error) {
+	response, err := cc.InvokeHandler(invoke.NewCollectEndorsementsHandler(), request, cc.addDefaultTimeout(cc.context, core.Query, options...)...)
+	if err != nil {
+		return invoke.AggregateResult{}, err
+	}
+	return invoke.AggregatePayloads(response.Endorsements)
+}
+
+// ExecuteBatch prepares and executes the given independent transactions concurrently using the
+// optional options provided, which apply to all of the requests. At most the number of requests set with
+// WithBatchWorkers (10 by default) are executed at the same time. A response is returned for each request
+// (in the same order as the requests) containing either the result or the error of the transaction.
+func (cc *Client) ExecuteBatch(requests []Request, options ...RequestOption) []BatchResponse {
+	options = cc.addDefaultTimeout(cc.context, core.Execute, options...)
+
+	workers := cc.batchWorkers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+
+	indexes := make(chan int, len(requests))
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+
+	responses := make([]BatchResponse, len(requests))
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				response, err := cc.InvokeHandler(invoke.NewExecuteHandler(), requests[i], options...)
+				responses[i] = BatchResponse{Response: response, Error: err}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return responses
+}
+
+// BuildProposal creates the transaction proposal for the given request using the optional options provided, in
+// the same way as Query and Execute, without sending it to the endorsers. This allows the proposal to be signed
+// offline, for example; the endorsements collected for it may be submitted with SubmitEndorsedTransaction.
+func (cc *Client) BuildProposal(request Request, options ...RequestOption) (*fab.TransactionProposal, error) {
+	txnOpts, err := cc.prepareOptsFromOptions(cc.context, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	reqCtx, cancel := cc.createReqContext(&txnOpts)
+	defer cancel()
+
+	requestContext, clientContext, err := cc.prepareHandlerContexts(reqCtx, request, txnOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoke.BuildChaincodeProposal(requestContext, clientContext)
+}
+
+// Warmup selects the endorsers for the given chaincode in the same way as Query and Execute, without sending a
+// proposal. This populates discovery and selection (and the selection cache set with WithSelectionCache) so that
+// the first invoke of the chaincode doesn't incur their latency. Options such as WithCollections are applied to the
+// selection in the same way as for an invoke.
+func (cc *Client) Warmup(chaincodeID string, options ...RequestOption) error {
+	if chaincodeID == "" {
+		return errors.New("ChaincodeID is required")
+	}
+
+	txnOpts, err := cc.prepareOptsFromOptions(cc.context, options...)
+	if err != nil {
+		return err
+	}
+
+	reqCtx, cancel := cc.createReqContext(&txnOpts)
+	defer cancel()
+
+	requestContext, clientContext, err := cc.newHandlerContexts(reqCtx, Request{ChaincodeID: chaincodeID}, txnOpts)
+	if err != nil {
+		return err
+	}
+
+	endorsers, err := invoke.SelectEndorsers(requestContext, clientContext)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to get endorsing peers")
+	}
+	logger.Debugf("Warmed up selection of %d endorsers for chaincode [%s]", len(endorsers), chaincodeID)
+	return nil
+}
+
+// NewPendingTransaction builds the proposal for the given request (see BuildProposal) and returns an empty
+// collection of its endorsements. The endorsements may then be accumulated over time with EndorsePending (for
+// example, from different orgs for a governance transaction) and the transaction submitted with SubmitPending.
+func (cc *Client) NewPendingTransaction(request Request, options ...RequestOption) (*invoke.PendingEndorsements, error) {
+	proposal, err := cc.BuildProposal(request, options...)
+	if err != nil {
+		return nil, err
+	}
+	return invoke.NewPendingEndorsements(proposal), nil
+}
+
+// EndorsePending sends the proposal of the pending transaction to the targets (the selected endorsers unless
+// targets are specified with WithTargets or WithTargetURLs) and adds their successful endorsements to the pending
+// endorsements. The response contains the result of each target and all of the accumulated endorsements.
+func (cc *Client) EndorsePending(pending *invoke.PendingEndorsements, options ...RequestOption) (Response, error) {
+	if pending == nil || pending.Proposal == nil || pending.Proposal.Proposal == nil {
+		return Response{}, errors.New("pending proposal is required")
+	}
+
+	request, err := requestFromProposal(pending.Proposal)
+	if err != nil {
+		return Response{}, err
+	}
+
+	handler := invoke.NewProposalProcessorHandler(invoke.NewPendingEndorsementHandler(pending))
+	return cc.InvokeHandler(handler, request, cc.addDefaultTimeout(cc.context, core.Execute, options...)...)
+}
+
+// SubmitPending submits the transaction of the pending endorsements (see SubmitEndorsedTransaction) provided that
+// the accumulated endorsements satisfy the given endorsement policy (for example, invoke.OrgsSatisfier). The policy
+// isn't checked if the satisfier is nil.
+func (cc *Client) SubmitPending(pending *invoke.PendingEndorsements, satisfier invoke.EndorsementSatisfier, options ...RequestOption) (Response, error) {
+	if pending == nil {
+		return Response{}, errors.New("pending proposal is required")
+	}
+	if satisfier != nil && !pending.Satisfies(satisfier) {
+		return Response{}, errors.Errorf("the %d pending endorsement(s) do not satisfy the endorsement policy", len(pending.Responses))
+	}
+	return cc.SubmitEndorsedTransaction(pending.Proposal, pending.Responses, options...)
+}
+
+// SubmitEndorsedTransaction creates a transaction from the given proposal and the endorsements
+// that were collected for it (for example, by another node), sends the transaction to the orderer and
+// waits for it to be committed. The proposal is not re-endorsed and the endorsements are not validated
+// by the client, except that the transaction isn't submitted if the proposal is older than the
+// WithMaxEndorsementAge option (if specified). The retry option is ignored since the transaction ID of the
+// proposal cannot be reused.
+func (cc *Client) SubmitEndorsedTransaction(proposal *fab.TransactionProposal, responses []*fab.TransactionProposalResponse, options ...RequestOption) (Response, error) {
+	if proposal == nil || proposal.Proposal == nil {
+		return Response{}, errors.New("proposal is required")
+	}
+	if len(responses) == 0 {
+		return Response{}, errors.New("at least one endorsement is required")
+	}
+
+	request, err := requestFromProposal(proposal)
+	if err != nil {
+		return Response{}, err
+	}
+
+	//Read execute tx options
+	txnOpts, err := cc.prepareOptsFromOptions(cc.context, cc.addDefaultTimeout(cc.context, core.Execute, options...)...)
+	if err != nil {
+		return Response{}, err
+	}
+
+	reqCtx, cancel := cc.createReqContext(&txnOpts)
+	defer cancel()
+
+	//Prepare context objects for handler
+	requestContext, clientContext, err := cc.prepareHandlerContexts(reqCtx, request, txnOpts)
+	if err != nil {
+		return Response{}, err
+	}
+	requestContext.RetryHandler = retry.New(retry.Opts{})
+	requestContext.Response = invoke.Response{
+		Payload:       responses[0].ProposalResponse.GetResponse().GetPayload(),
+		TransactionID: proposal.TxnID,
+		Proposal:      proposal,
+		Responses:     responses,
+	}
+
+	return cc.handle(reqCtx, invoke.NewCommitHandler(), requestContext, clientContext, txnOpts)
+}
+
+// EndorserCertificateChains returns the certificate chain (from the certificate of the endorser to the root certificate
+// of its MSP) of the endorser of each of the given responses (for example, Response.Responses), resolved with the MSPs
+// of the channel config. This saves downstream verification from having to resolve the intermediate certificates.
+func (cc *Client) EndorserCertificateChains(responses []*fab.TransactionProposalResponse) ([]invoke.EndorserCertificateChain, error) {
+	chConfig, err := cc.context.ChannelService().ChannelConfig()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to retrieve channel config")
+	}
+	return invoke.EndorserCertificateChains(chConfig.MSPs(), responses)
+}
+
+// TransactionEnvelope returns the marshaled common.Envelope of the transaction that is assembled from the proposal
+// and the endorsements of the given response (for example, the response of a query), signed by the client. The
+// envelope is in the standard Fabric format so the transaction can be submitted by any Fabric tool or SDK.
+func (cc *Client) TransactionEnvelope(response Response) ([]byte, error) {
+	if len(response.Responses) == 0 {
+		return nil, errors.New("at least one endorsement is required")
+	}
+	return txn.CreateEnvelope(cc.context, fab.TransactionRequest{Proposal: response.Proposal, ProposalResponses: response.Responses})
+}
+
 //InvokeHandler invokes handler using request and options provided
 func (cc *Client) InvokeHandler(handler invoke.Handler, request Request, options ...RequestOption) (Response, error) {
 	//Read execute tx options
@@ -106,12 +521,19 @@ func (cc *Client) InvokeHandler(handler invoke.Handler, request Request, options
 		return Response{}, err
 	}
 
-	complete := make(chan bool)
+	return cc.handle(reqCtx, handler, requestContext, clientContext, txnOpts)
+}
+
+//handle performs the action through the handler, retrying as required, until it completes or the request times out
+func (cc *Client) handle(reqCtx reqContext.Context, handler invoke.Handler, requestContext *invoke.RequestContext, clientContext *invoke.ClientContext, txnOpts requestOptions) (Response, error) {
+	// Buffered so that the handler goroutine doesn't block (and leak) if the request times out
+	complete := make(chan bool, 1)
 
 	go func() {
 	handleInvoke:
 		//Perform action through handler
 		handler.Handle(requestContext, clientContext)
+		cc.penalize(requestContext.Error)
 		if cc.resolveRetry(requestContext, txnOpts) {
 			goto handleInvoke
 		}
@@ -127,14 +549,22 @@ func (cc *Client) InvokeHandler(handler invoke.Handler, request Request, options
 }
 
 func (cc *Client) resolveRetry(ctx *invoke.RequestContext, o requestOptions) bool {
-	errs, ok := ctx.Error.(multi.Errors)
+	errs, ok := errors.Cause(ctx.Error).(multi.Errors)
 	if !ok {
 		errs = append(errs, ctx.Error)
 	}
 	for _, e := range errs {
 		if ctx.RetryHandler.Required(e) {
+			if err := ctx.RetryBudget.Consume(e); err != nil {
+				logger.Warnf("Not retrying on error %s: %s", e, err)
+				ctx.Error = err
+				return false
+			}
 			logger.Infof("Retrying on error %s", e)
 			cc.greylist.Greylist(e)
+			if url := failedEndorser(e); url != "" {
+				ctx.FailedEndorsers = append(ctx.FailedEndorsers, url)
+			}
 
 			// Reset context parameters
 			ctx.Opts.Targets = o.Targets
@@ -147,6 +577,81 @@ func (cc *Client) resolveRetry(ctx *invoke.RequestContext, o requestOptions) boo
 	return false
 }
 
+// requestFromProposal returns the chaincode invoke request of the given transaction proposal
+func requestFromProposal(proposal *fab.TransactionProposal) (Request, error) {
+	ccProposalPayload, err := protos_utils.GetChaincodeProposalPayload(proposal.Proposal.Payload)
+	if err != nil {
+		return Request{}, errors.WithMessage(err, "failed to extract chaincode proposal payload")
+	}
+	cis := &pb.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(ccProposalPayload.Input, cis); err != nil {
+		return Request{}, errors.Wrap(err, "unmarshal of chaincode invocation spec failed")
+	}
+	spec := cis.GetChaincodeSpec()
+	if spec.GetChaincodeId() == nil || spec.GetInput() == nil || len(spec.GetInput().Args) == 0 {
+		return Request{}, errors.New("proposal does not contain a chaincode invocation")
+	}
+
+	return Request{
+		ChaincodeID: spec.ChaincodeId.Name,
+		Fcn:         string(spec.Input.Args[0]),
+		Args:        spec.Input.Args[1:],
+	}, nil
+}
+
+// penalize puts the endorsers that caused the given error in the penalty box (if installed)
+func (cc *Client) penalize(err error) {
+	if cc.penaltyBox == nil || err == nil {
+		return
+	}
+	errs, ok := errors.Cause(err).(multi.Errors)
+	if !ok {
+		errs = append(errs, err)
+	}
+	for _, e := range errs {
+		if url := unreachableEndorser(e); url != "" {
+			cc.penaltyBox.Penalize(url)
+		}
+	}
+}
+
+// failedEndorser returns the URL of the endorser that caused the given error
+// or an empty string if the error is not associated with a specific endorser
+func failedEndorser(err error) string {
+	s, ok := status.FromError(err)
+	if !ok || (s.Group != status.EndorserClientStatus && s.Group != status.EndorserServerStatus) {
+		return ""
+	}
+	if len(s.Details) == 0 {
+		return ""
+	}
+	url, ok := s.Details[0].(string)
+	if !ok {
+		return ""
+	}
+	return url
+}
+
+// unreachableEndorser returns the URL of the endorser that couldn't be reached (a connection failure or a gRPC
+// transport error) according to the given error, or an empty string if the error is not a connection or transport
+// failure of a specific endorser. Other errors (for example, a chaincode error or an endorsement mismatch) don't
+// indicate that the endorser is unhealthy.
+func unreachableEndorser(err error) string {
+	s, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+	if s.Group != status.GRPCTransportStatus && (s.Group != status.EndorserClientStatus || s.Code != status.ConnectionFailed.ToInt32()) {
+		return ""
+	}
+	for _, detail := range s.Details {
+		if url, ok := detail.(string); ok {
+			return url
+		}
+	}
+	return ""
+}
+
 //createReqContext creates req context for invoke handler
 func (cc *Client) createReqContext(txnOpts *requestOptions) (reqContext.Context, reqContext.CancelFunc) {
 
@@ -158,6 +663,9 @@ func (cc *Client) createReqContext(txnOpts *requestOptions) (reqContext.Context,
 	if txnOpts.Timeouts[core.Execute] == 0 {
 		txnOpts.Timeouts[core.Execute] = cc.context.Config().TimeoutOrDefault(core.Execute)
 	}
+	if txnOpts.Timeouts[core.EventReg] == 0 {
+		txnOpts.Timeouts[core.EventReg] = cc.context.Config().TimeoutOrDefault(core.EventReg)
+	}
 
 	reqCtx, cancel := contextImpl.NewRequest(cc.context, contextImpl.WithTimeout(txnOpts.Timeouts[core.Execute]),
 		contextImpl.WithParent(txnOpts.ParentContext))
@@ -174,19 +682,23 @@ func (cc *Client) prepareHandlerContexts(reqCtx reqContext.Context, request Requ
 		return nil, nil, errors.New("ChaincodeID and Fcn are required")
 	}
 
-	chConfig, err := cc.context.ChannelService().ChannelConfig()
-	if err != nil {
-		return nil, nil, errors.WithMessage(err, "failed to retrieve channel config")
-	}
-	transactor, err := cc.context.InfraProvider().CreateChannelTransactor(reqCtx, chConfig)
+	return cc.newHandlerContexts(reqCtx, request, o)
+}
+
+//newHandlerContexts creates the context objects for handlers without validating the request
+func (cc *Client) newHandlerContexts(reqCtx reqContext.Context, request Request, o requestOptions) (*invoke.RequestContext, *invoke.ClientContext, error) {
+	transactor, err := cc.channelTransactor(reqCtx)
 	if err != nil {
-		return nil, nil, errors.WithMessage(err, "failed to create transactor")
+		return nil, nil, err
 	}
 
 	peerFilter := func(peer fab.Peer) bool {
 		if !cc.greylist.Accept(peer) {
 			return false
 		}
+		if cc.penaltyBox != nil && !cc.penaltyBox.Accept(peer) {
+			return false
+		}
 		if o.TargetFilter != nil && !o.TargetFilter.Accept(peer) {
 			return false
 		}
@@ -194,25 +706,119 @@ func (cc *Client) prepareHandlerContexts(reqCtx reqContext.Context, request Requ
 	}
 
 	clientContext := &invoke.ClientContext{
-		Selection:    cc.context.SelectionService(),
-		Discovery:    cc.context.DiscoveryService(),
-		Membership:   cc.membership,
-		Transactor:   transactor,
-		EventService: cc.eventService,
+		Selection:               cc.context.SelectionService(),
+		Discovery:               cc.context.DiscoveryService(),
+		Membership:              cc.membership,
+		Transactor:              transactor,
+		EventService:            cc.eventService,
+		RequestTransformer:      cc.transformer,
+		AdditionalEventServices: cc.additionalEventServices,
+		SelectionCircuitBreaker: cc.selectionBreaker,
+		LatencyTracker:          cc.latencyTracker,
+		EventServiceResolver:    cc.eventServiceResolver,
+		SelectionCache:          cc.selectionCache,
+		SelectionRandom:         cc.selectionRandom,
+		EndorserCommManager:     cc.endorserCommManager,
+		ConcurrencyLimiter:      cc.concurrencyLimiter,
+		Tracer:                  cc.tracer,
+		ErrorRateTracker:        cc.errorRateTracker,
+		BatchCommitListener:     cc.batchCommitListener,
+		ConfigSequence:          cc.configSequence,
+		MaxTransactionBytes:     cc.maxTransactionBytes,
+		ChaincodeRateLimiter:    cc.chaincodeRateLimiter,
+		TxStatusFanout:          cc.txStatusFanout,
+		CommitTransactor: func() (fab.Transactor, error) {
+			return cc.channelTransactor(reqCtx)
+		},
 	}
 
 	requestContext := &invoke.RequestContext{
 		Request:         invoke.Request(request),
 		Opts:            invoke.Opts(o),
 		Response:        invoke.Response{},
-		RetryHandler:    retry.New(o.Retry),
+		RetryHandler:    retry.New(retryOpts(o)),
 		Ctx:             reqCtx,
 		SelectionFilter: peerFilter,
+		RetryBudget:     retryBudget(o),
 	}
 
 	return requestContext, clientContext, nil
 }
 
+//channelTransactor creates a transactor for the orderers in the channel config. The channel config is cached and
+//refreshed periodically (see core.ChannelConfigRefresh), so the orderers added or removed by a config update are
+//picked up without restarting the client.
+func (cc *Client) channelTransactor(reqCtx reqContext.Context) (fab.Transactor, error) {
+	chConfig, err := cc.context.ChannelService().ChannelConfig()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to retrieve channel config")
+	}
+	transactor, err := cc.context.InfraProvider().CreateChannelTransactor(reqCtx, chConfig)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create transactor")
+	}
+	return transactor, nil
+}
+
+//configSequence returns the sequence of the channel config. The channel config is cached and refreshed periodically
+//(see core.ChannelConfigRefresh), so a config update is only seen once the cached config is refreshed.
+func (cc *Client) configSequence() (uint64, error) {
+	chConfig, err := cc.context.ChannelService().ChannelConfig()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to retrieve channel config")
+	}
+	return chConfig.Sequence(), nil
+}
+
+// absoluteMaxBytesConfig is implemented by a channel config that includes the batch size of the orderer
+type absoluteMaxBytesConfig interface {
+	AbsoluteMaxBytes() uint32
+}
+
+//maxTransactionBytes returns the AbsoluteMaxBytes of the batch size in the channel config, which is the maximum size
+//of a transaction that the orderer accepts
+func (cc *Client) maxTransactionBytes() (int, error) {
+	chConfig, err := cc.context.ChannelService().ChannelConfig()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to retrieve channel config")
+	}
+	batchSize, ok := chConfig.(absoluteMaxBytesConfig)
+	if !ok || batchSize.AbsoluteMaxBytes() == 0 {
+		return 0, errors.New("channel config does not include the batch size")
+	}
+	return int(batchSize.AbsoluteMaxBytes()), nil
+}
+
+// retryOpts returns the retry options of the invoke. The retryable validation codes of the invoke
+// are added to the retryable codes (the default codes if none are specified) of the event server.
+func retryOpts(o requestOptions) retry.Opts {
+	opts := o.Retry
+	if len(o.RetryableValidationCodes) == 0 {
+		return opts
+	}
+
+	retryableCodes := opts.RetryableCodes
+	if len(retryableCodes) == 0 {
+		retryableCodes = retry.DefaultRetryableCodes
+	}
+	opts.RetryableCodes = make(map[status.Group][]status.Code)
+	for group, codes := range retryableCodes {
+		opts.RetryableCodes[group] = append([]status.Code(nil), codes...)
+	}
+	for _, code := range o.RetryableValidationCodes {
+		opts.RetryableCodes[status.EventServerStatus] = append(opts.RetryableCodes[status.EventServerStatus], status.Code(code))
+	}
+	return opts
+}
+
+// retryBudget returns the retry budget of the invoke, or nil (unlimited) if no budget was specified
+func retryBudget(o requestOptions) *invoke.RetryBudget {
+	if o.RetryBudget > 0 {
+		return invoke.NewRetryBudget(o.RetryBudget)
+	}
+	return nil
+}
+
 //prepareOptsFromOptions Reads apitxn.Opts from Option array
 func (cc *Client) prepareOptsFromOptions(ctx context.Client, options ...RequestOption) (requestOptions, error) {
 	txnOpts := requestOptions{}
@@ -11,10 +11,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	grpcCodes "google.golang.org/grpc/codes"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery/penaltybox"
 	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/staticselection"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
@@ -29,6 +32,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
 )
 
 const (
@@ -375,6 +379,406 @@ func TestTransactionValidationError(t *testing.T) {
 	assert.EqualValues(t, validationCode, status.ToTransactionValidationCode(statusError.Code))
 }
 
+func TestRetryableValidationCode(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("test")
+	peers := []fab.Peer{testPeer1}
+
+	go func() {
+		for _, code := range []pb.TxValidationCode{pb.TxValidationCode_INVALID_OTHER_REASON, pb.TxValidationCode_VALID} {
+			select {
+			case txStatusReg := <-mockEventService.TxStatusRegCh:
+				txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: code}
+			case <-time.After(time.Second * 5):
+				return
+			}
+		}
+	}()
+
+	chClient := setupChannelClient(peers, t)
+	chClient.eventService = mockEventService
+
+	retryOptions := retry.DefaultOpts
+	retryOptions.Attempts = 3
+	retryOptions.BackoffFactor = 1
+	retryOptions.InitialBackoff = time.Millisecond
+
+	response, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		WithRetry(retryOptions), WithRetryableValidationCodes(pb.TxValidationCode_INVALID_OTHER_REASON))
+	assert.Nil(t, err, "expected execute to succeed on retry")
+	assert.Equal(t, pb.TxValidationCode_VALID, response.TxValidationCode)
+	assert.Equal(t, 2, testPeer1.ProcessProposalCalls, "expected the execute to be retried once")
+
+	// The retryable validation codes are added to the retryable codes
+	resolved := retryOpts(requestOptions{RetryableValidationCodes: []pb.TxValidationCode{pb.TxValidationCode_INVALID_OTHER_REASON}})
+	assert.Contains(t, resolved.RetryableCodes[status.EventServerStatus], status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT))
+	assert.Contains(t, resolved.RetryableCodes[status.EventServerStatus], status.Code(pb.TxValidationCode_INVALID_OTHER_REASON))
+	assert.NotContains(t, retry.DefaultRetryableCodes[status.EventServerStatus], status.Code(pb.TxValidationCode_INVALID_OTHER_REASON))
+}
+
+func TestCommitLatencyRecording(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	peers := []fab.Peer{testPeer1}
+
+	go func() {
+		select {
+		case txStatusReg := <-mockEventService.TxStatusRegCh:
+			txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+		case <-time.After(time.Second * 5):
+		}
+	}()
+
+	chClient := setupChannelClient(peers, t)
+	chClient.eventService = mockEventService
+	if err := WithCommitLatencyRecording()(chClient); err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+
+	response, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}})
+	assert.Nil(t, err)
+	assert.True(t, response.CommitLatency.RegistrationToNotification > 0, "expected the commit latency to be recorded")
+}
+
+func TestQueryAggregate(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("value")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = []byte("value")
+	testPeer3 := fcmocks.NewMockPeer("Peer3", "http://peer3.com")
+	testPeer3.Payload = []byte("tampered")
+	testPeer4 := fcmocks.NewMockPeer("Peer4", "http://peer4.com")
+	testPeer4.Status = 500
+	peers := []fab.Peer{testPeer1, testPeer2, testPeer3, testPeer4}
+
+	chClient := setupChannelClient(peers, t)
+
+	result, err := chClient.QueryAggregate(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}, WithTargets(peers...))
+	if err != nil {
+		t.Fatalf("Failed to aggregate query: %s", err)
+	}
+	assert.Equal(t, []byte("value"), result.Payload)
+	assert.Equal(t, 2, result.Agreed)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 0.5, result.Agreement)
+	assert.Equal(t, []string{"http://peer3.com", "http://peer4.com"}, result.Dissenters)
+
+	// None of the targets succeeds
+	_, err = chClient.QueryAggregate(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}, WithTargets(testPeer4))
+	assert.NotNil(t, err, "expected aggregation to fail if none of the targets succeeds")
+}
+
+func TestExecuteBatch(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("test")
+	peers := []fab.Peer{testPeer1}
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			select {
+			case txStatusReg := <-mockEventService.TxStatusRegCh:
+				txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+			case <-time.After(time.Second * 5):
+				return
+			}
+		}
+	}()
+
+	chClient := setupChannelClient(peers, t)
+	chClient.eventService = mockEventService
+	responses := chClient.ExecuteBatch([]Request{
+		{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		{ChaincodeID: "test", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("b"), []byte("a"), []byte("1")}},
+	})
+	if len(responses) != 3 {
+		t.Fatalf("Expecting 3 responses but got %d", len(responses))
+	}
+	for _, i := range []int{0, 2} {
+		assert.Nil(t, responses[i].Error, "expected request %d to succeed", i)
+		assert.Equal(t, pb.TxValidationCode_VALID, responses[i].Response.TxValidationCode)
+		assert.Equal(t, []byte("test"), responses[i].Response.Payload)
+	}
+	assert.NotEqual(t, responses[0].Response.TransactionID, responses[2].Response.TransactionID)
+	assert.NotNil(t, responses[1].Error, "expected request without function to fail")
+}
+
+func TestExecuteBatchWorkers(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("test")
+
+	go func() {
+		for i := 0; i < 3; i++ {
+			select {
+			case txStatusReg := <-mockEventService.TxStatusRegCh:
+				txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+			case <-time.After(time.Second * 5):
+				return
+			}
+		}
+	}()
+
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	chClient.eventService = mockEventService
+	assert.NotNil(t, WithBatchWorkers(0)(chClient), "expected error for zero batch workers")
+	assert.Nil(t, WithBatchWorkers(1)(chClient))
+
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	responses := chClient.ExecuteBatch([]Request{request, request, request})
+	if len(responses) != 3 {
+		t.Fatalf("Expecting 3 responses but got %d", len(responses))
+	}
+	for i, response := range responses {
+		assert.Nil(t, response.Error, "expected request %d to succeed", i)
+		assert.Equal(t, pb.TxValidationCode_VALID, response.Response.TxValidationCode)
+	}
+	assert.Equal(t, 3, testPeer1.ProcessProposalCalls, "expected each request to be endorsed once")
+}
+
+func TestBuildProposal(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+
+	_, err := chClient.BuildProposal(Request{ChaincodeID: "testCC", Args: [][]byte{[]byte("query"), []byte("b")}})
+	assert.NotNil(t, err, "expected error for empty function")
+
+	nonce := []byte("nonce")
+	proposal, err := chClient.BuildProposal(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithNonceGenerator(func() ([]byte, error) { return nonce, nil }))
+	if err != nil {
+		t.Fatalf("Failed to build proposal: %s", err)
+	}
+	assert.NotEmpty(t, proposal.TxnID)
+
+	request, err := requestFromProposal(proposal)
+	if err != nil {
+		t.Fatalf("Failed to extract request from proposal: %s", err)
+	}
+	assert.Equal(t, "testCC", request.ChaincodeID)
+	assert.Equal(t, "invoke", request.Fcn)
+	assert.Equal(t, [][]byte{[]byte("query"), []byte("b")}, request.Args)
+}
+
+// recordingSelectionCache records the endorsers cached for each chaincode
+type recordingSelectionCache struct {
+	endorsers map[string][]fab.Peer
+}
+
+func (c *recordingSelectionCache) Get(key invoke.SelectionCacheKey) ([]fab.Peer, bool) {
+	return nil, false
+}
+
+func (c *recordingSelectionCache) Put(key invoke.SelectionCacheKey, endorsers []fab.Peer) {
+	c.endorsers[key.ChaincodeID] = endorsers
+}
+
+func TestWarmup(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	cache := &recordingSelectionCache{endorsers: make(map[string][]fab.Peer)}
+	chClient.selectionCache = cache
+
+	err := chClient.Warmup("")
+	assert.NotNil(t, err, "expected error for empty chaincode ID")
+
+	err = chClient.Warmup("testCC")
+	if err != nil {
+		t.Fatalf("Failed to warm up: %s", err)
+	}
+	assert.Equal(t, 0, testPeer1.ProcessProposalCalls, "expected no proposal to be sent")
+	assert.Equal(t, 1, len(cache.endorsers["testCC"]), "expected the selected endorsers to be cached")
+}
+
+func TestPendingTransaction(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("test")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = []byte("test")
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+	chClient.eventService = mockEventService
+
+	pending, err := chClient.NewPendingTransaction(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}})
+	if err != nil {
+		t.Fatalf("Failed to create pending transaction: %s", err)
+	}
+
+	satisfier := func(responses []*fab.TransactionProposalResponse) bool { return len(responses) == 2 }
+
+	// Endorsed by the first org
+	response, err := chClient.EndorsePending(pending, WithTargets(testPeer1))
+	if err != nil {
+		t.Fatalf("Failed to endorse pending transaction: %s", err)
+	}
+	assert.Equal(t, pending.Proposal.TxnID, response.TransactionID)
+	_, err = chClient.SubmitPending(pending, satisfier)
+	assert.NotNil(t, err, "expected submit to fail until the endorsement policy is satisfied")
+
+	// Endorsed later by the second org
+	_, err = chClient.EndorsePending(pending, WithTargets(testPeer2))
+	if err != nil {
+		t.Fatalf("Failed to endorse pending transaction: %s", err)
+	}
+
+	go func() {
+		select {
+		case txStatusReg := <-mockEventService.TxStatusRegCh:
+			txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+		case <-time.After(time.Second * 5):
+		}
+	}()
+
+	response, err = chClient.SubmitPending(pending, satisfier)
+	if err != nil {
+		t.Fatalf("Failed to submit pending transaction: %s", err)
+	}
+	assert.Equal(t, pending.Proposal.TxnID, response.TransactionID)
+	assert.Equal(t, pb.TxValidationCode_VALID, response.TxValidationCode)
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls)
+	assert.Equal(t, 1, testPeer2.ProcessProposalCalls)
+}
+
+func TestSubmitEndorsedTransaction(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	chClient := setupChannelClient(nil, t)
+	chClient.eventService = mockEventService
+
+	_, err := chClient.SubmitEndorsedTransaction(nil, nil)
+	assert.NotNil(t, err, "expected error for missing proposal")
+
+	txh, err := txn.NewHeader(setupTestContext(), channelID)
+	if err != nil {
+		t.Fatalf("Failed to create transaction header: %s", err)
+	}
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a")}})
+	if err != nil {
+		t.Fatalf("Failed to create proposal: %s", err)
+	}
+
+	_, err = chClient.SubmitEndorsedTransaction(proposal, nil)
+	assert.NotNil(t, err, "expected error for missing endorsements")
+
+	// Endorsements collected by another node
+	responses := []*fab.TransactionProposalResponse{{
+		Endorser: "http://peer1.com",
+		Status:   200,
+		ProposalResponse: &pb.ProposalResponse{
+			Response:    &pb.Response{Status: 200, Payload: []byte("test")},
+			Endorsement: &pb.Endorsement{Signature: []byte("signature")},
+		},
+	}}
+
+	go func() {
+		select {
+		case txStatusReg := <-mockEventService.TxStatusRegCh:
+			txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+		case <-time.After(time.Second * 5):
+		}
+	}()
+
+	response, err := chClient.SubmitEndorsedTransaction(proposal, responses)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.Equal(t, proposal.TxnID, response.TransactionID)
+	assert.Equal(t, pb.TxValidationCode_VALID, response.TxValidationCode)
+	assert.Equal(t, []byte("test"), response.Payload)
+}
+
+func TestSubmitStaleEndorsements(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("test")
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	chClient.eventService = fcmocks.NewMockEventService()
+
+	pending, err := chClient.NewPendingTransaction(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}})
+	if err != nil {
+		t.Fatalf("Failed to create pending transaction: %s", err)
+	}
+	_, err = chClient.EndorsePending(pending, WithTargets(testPeer1))
+	if err != nil {
+		t.Fatalf("Failed to endorse pending transaction: %s", err)
+	}
+
+	// The proposal was created an hour ago
+	backdateProposal(pending.Proposal, time.Hour, t)
+
+	_, err = chClient.SubmitPending(pending, nil, WithMaxEndorsementAge(time.Minute))
+	s, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("Expected status error, Received error: %v", err)
+	}
+	assert.EqualValues(t, status.StaleEndorsement, s.Code)
+
+	_, err = chClient.SubmitEndorsedTransaction(pending.Proposal, pending.Responses, WithMaxEndorsementAge(time.Minute))
+	s, ok = status.FromError(err)
+	if !ok {
+		t.Fatalf("Expected status error, Received error: %v", err)
+	}
+	assert.EqualValues(t, status.StaleEndorsement, s.Code)
+}
+
+// backdateProposal moves the timestamp in the channel header of the given proposal back by d
+func backdateProposal(proposal *fab.TransactionProposal, d time.Duration, t *testing.T) {
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal proposal header: %s", err)
+	}
+	chdr, err := protos_utils.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal channel header: %s", err)
+	}
+	chdr.Timestamp.Seconds -= int64(d / time.Second)
+	if hdr.ChannelHeader, err = proto.Marshal(chdr); err != nil {
+		t.Fatalf("Failed to marshal channel header: %s", err)
+	}
+	if proposal.Header, err = proto.Marshal(hdr); err != nil {
+		t.Fatalf("Failed to marshal proposal header: %s", err)
+	}
+}
+
+func TestTransactionEnvelope(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+
+	_, err := chClient.TransactionEnvelope(Response{})
+	assert.NotNil(t, err, "expected error for missing endorsements")
+
+	txh, err := txn.NewHeader(setupTestContext(), channelID)
+	if err != nil {
+		t.Fatalf("Failed to create transaction header: %s", err)
+	}
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}})
+	if err != nil {
+		t.Fatalf("Failed to create proposal: %s", err)
+	}
+	response := Response{
+		Proposal: proposal,
+		Responses: []*fab.TransactionProposalResponse{{
+			Endorser: "http://peer1.com",
+			Status:   200,
+			ProposalResponse: &pb.ProposalResponse{
+				Response:    &pb.Response{Status: 200, Payload: []byte("test")},
+				Endorsement: &pb.Endorsement{Signature: []byte("signature")},
+			},
+		}},
+	}
+
+	envelopeBytes, err := chClient.TransactionEnvelope(response)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	envelope, err := protos_utils.GetEnvelopeFromBlock(envelopeBytes)
+	assert.Nil(t, err)
+	payload, err := protos_utils.GetPayload(envelope)
+	assert.Nil(t, err)
+	channelHeader, err := protos_utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	assert.Nil(t, err)
+	assert.Equal(t, string(proposal.TxnID), channelHeader.TxId)
+	assert.Equal(t, channelID, channelHeader.ChannelId)
+}
+
 func TestExecuteTxWithRetries(t *testing.T) {
 	testStatus := status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", nil)
 	testResp := []byte("test")
@@ -405,6 +809,96 @@ func TestExecuteTxWithRetries(t *testing.T) {
 	assert.Equal(t, testResp, resp.Payload, "expected correct response")
 }
 
+func TestRetryExcludesFailedEndorser(t *testing.T) {
+	testResp := []byte("test")
+
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserServerStatus, int32(common.Status_SERVICE_UNAVAILABLE), "test", []interface{}{testPeer1.URL()})
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = testResp
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+
+	retryOpts := retry.DefaultOpts
+	retryOpts.Attempts = 3
+	retryOpts.BackoffFactor = 1
+	retryOpts.InitialBackoff = time.Millisecond
+	retryOpts.RetryableCodes = retry.ChannelClientRetryableCodes
+
+	resp, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithRetry(retryOpts))
+	assert.Nil(t, err, "expected error to be nil")
+	assert.Equal(t, testResp, resp.Payload, "expected correct response")
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls, "expected failed peer to be excluded on retry")
+	assert.Equal(t, 2, testPeer2.ProcessProposalCalls, "expected healthy peer to be called on retry")
+}
+
+func TestRetryBudget(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", nil)
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+
+	retryOpts := retry.DefaultOpts
+	retryOpts.Attempts = 10
+	retryOpts.BackoffFactor = 1
+	retryOpts.InitialBackoff = time.Millisecond
+	retryOpts.RetryableCodes = retry.ChannelClientRetryableCodes
+
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithRetry(retryOpts), WithRetryBudget(2))
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.RetryBudgetExhausted.ToInt32(), s.Code, "expected retry budget to be exhausted")
+	assert.Equal(t, 3, testPeer1.ProcessProposalCalls, "expected the invoke to be retried twice")
+
+	assert.Nil(t, retryBudget(requestOptions{}), "expected retries to be unlimited without a retry budget")
+	assert.Equal(t, 5, retryBudget(requestOptions{RetryBudget: 5}).Remaining())
+}
+
+func TestPenaltyBox(t *testing.T) {
+	testResp := []byte("test")
+
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", []interface{}{testPeer1.URL()})
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = testResp
+
+	selectionService, err := setupTestSelection(nil, []fab.Peer{testPeer1, testPeer2})
+	assert.Nil(t, err, "Got error %s", err)
+	discoveryService, err := setupTestDiscovery(nil, nil)
+	assert.Nil(t, err, "Got error %s", err)
+	ctx := createChannelContext(setupCustomTestContext(t, selectionService, discoveryService, nil), channelID)
+
+	ttl := 500 * time.Millisecond
+	chClient, err := New(ctx, WithPenaltyBox(penaltybox.New(ttl)))
+	assert.Nil(t, err, "Got error %s", err)
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err = chClient.Query(request)
+	assert.NotNil(t, err, "expected endorsement error")
+
+	resp, err := chClient.Query(request)
+	assert.Nil(t, err, "expected failed peer to be skipped while in the penalty box")
+	assert.Equal(t, testResp, resp.Payload)
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls, "expected failed peer to be skipped while in the penalty box")
+
+	time.Sleep(ttl)
+	_, err = chClient.Query(request)
+	assert.NotNil(t, err, "expected failed peer to be re-admitted after the TTL")
+	assert.Equal(t, 2, testPeer1.ProcessProposalCalls, "expected failed peer to be re-admitted after the TTL")
+}
+
+func TestUnreachableEndorser(t *testing.T) {
+	url := "http://peer1.com"
+	assert.Equal(t, url, unreachableEndorser(status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", []interface{}{url})))
+	assert.Equal(t, url, unreachableEndorser(errors.WithMessage(status.New(status.GRPCTransportStatus, int32(grpcCodes.Unavailable), "test", []interface{}{url}), "connection failed")))
+
+	// Errors that don't indicate an unhealthy endorser aren't penalized
+	assert.Empty(t, unreachableEndorser(status.New(status.EndorserServerStatus, int32(common.Status_INTERNAL_SERVER_ERROR), "test", []interface{}{url})))
+	assert.Empty(t, unreachableEndorser(status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(), "test", []interface{}{url})))
+	assert.Empty(t, unreachableEndorser(status.New(status.GRPCTransportStatus, int32(grpcCodes.Unavailable), "test", nil)))
+	assert.Empty(t, unreachableEndorser(errors.New("test")))
+}
+
 func TestMultiErrorPropogation(t *testing.T) {
 	testErr := fmt.Errorf("Test Error")
 
@@ -425,6 +919,40 @@ func TestMultiErrorPropogation(t *testing.T) {
 	assert.Equal(t, "Multiple errors occurred: \nTest Error\nTest Error", statusError.Message, "Expected multi error message")
 }
 
+func TestStatusCodePreservation(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	// Selection error wrapped by the proposal processor handler
+	selectionErr := status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), "no peers", nil)
+	chClient := setupChannelClientWithError(nil, selectionErr, nil, t)
+	_, err := chClient.Query(request)
+	statusError, ok := status.FromError(err)
+	assert.True(t, ok, "Expected status error")
+	assert.Equal(t, selectionErr, statusError)
+
+	// Error returned by a single endorser
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserServerStatus, int32(common.Status_BAD_REQUEST), "bad request", []interface{}{testPeer1.URL()})
+	chClient = setupChannelClient([]fab.Peer{testPeer1}, t)
+	_, err = chClient.Query(request)
+	statusError, ok = status.FromError(err)
+	assert.True(t, ok, "Expected status error")
+	assert.Equal(t, status.EndorserServerStatus, statusError.Group)
+	assert.EqualValues(t, common.Status_BAD_REQUEST, statusError.Code)
+
+	// Endorsement mismatch wrapped by the validation handler
+	testPeer1 = fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("value1")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = []byte("value2")
+	chClient = setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+	_, err = chClient.Query(request)
+	statusError, ok = status.FromError(err)
+	assert.True(t, ok, "Expected status error")
+	assert.Equal(t, status.EndorserClientStatus, statusError.Group)
+	assert.EqualValues(t, status.EndorsementMismatch, status.ToSDKStatusCode(statusError.Code))
+}
+
 type serviceInit interface {
 	Initialize(context context.Channel) error
 }
@@ -641,3 +1169,52 @@ func createClientContext(client context.Client) context.ClientProvider {
 		return client, nil
 	}
 }
+
+func TestBuildProposalMatchesExecute(t *testing.T) {
+	var requests []fab.ChaincodeInvokeRequest
+	chClient := setupChannelClient(nil, t)
+	chClient.transformer = func(request *fab.ChaincodeInvokeRequest) error {
+		requests = append(requests, *request)
+		return nil
+	}
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte(`{"b": 1, "a": 2}`)}}
+	options := []RequestOption{WithNormalizedArgs(), WithCorrelationID("correlationID"), WithResponseEncoding(invoke.EncodingJSON)}
+
+	_, err := chClient.BuildProposal(request, options...)
+	if err != nil {
+		t.Fatalf("Failed to build proposal: %s", err)
+	}
+	// The proposal is created before the transaction times out waiting for the commit
+	chClient.Execute(request, append(options, WithTimeout(core.Execute, 100*time.Millisecond))...)
+
+	if !assert.Len(t, requests, 2, "expecting the proposal to be created by BuildProposal and Execute") {
+		return
+	}
+	built, executed := requests[0], requests[1]
+	assert.Equal(t, [][]byte{[]byte(`{"a":2,"b":1}`)}, built.Args, "expecting the args to be normalized")
+	assert.Equal(t, executed.Args, built.Args)
+	assert.Equal(t, []byte(invoke.EncodingJSON), built.TransientMap[invoke.ResponseEncodingKey])
+	assert.Equal(t, executed.TransientMap[invoke.ResponseEncodingKey], built.TransientMap[invoke.ResponseEncodingKey])
+	assert.NotEmpty(t, built.TransientMap["correlationID"], "expecting a correlation ID to be injected")
+	assert.NotEmpty(t, executed.TransientMap["correlationID"])
+	assert.Len(t, built.TransientMap, len(executed.TransientMap))
+	assert.Nil(t, request.TransientMap, "expecting the request not to be modified")
+}
+
+func TestWithTransactionSizeLimit(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+
+	_, err := chClient.Execute(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("b")}}, WithTransactionSizeLimit(-1))
+	assert.NotNil(t, err, "expecting error for negative transaction size limit")
+
+	// The mock channel config doesn't include the batch size
+	_, err = chClient.maxTransactionBytes()
+	assert.NotNil(t, err, "expecting error for channel config without batch size")
+
+	_, err = chClient.Execute(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("b")}}, WithTransactionSizeLimit(1))
+	s, ok := status.FromError(err)
+	if assert.True(t, ok, "expected status error but got: %v", err) {
+		assert.Equal(t, status.TransactionTooLarge.ToInt32(), s.Code)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"github.com/pkg/errors"
+)
+
+// AggregateResult is the outcome of sending the same query to several endorsers: the payload
+// returned by the largest number of endorsers along with the ratio of endorsers that agree on it,
+// which may be used to detect a compromised or faulty peer
+type AggregateResult struct {
+	// Payload is the payload returned by the largest number of endorsers
+	Payload []byte
+	// Agreed is the number of endorsers that returned Payload
+	Agreed int
+	// Total is the number of endorsers that were queried, including those that failed
+	Total int
+	// Agreement is the ratio of the endorsers that returned Payload (Agreed/Total)
+	Agreement float64
+	// Dissenters are the URLs of the endorsers that failed or returned a different payload
+	Dissenters []string
+}
+
+// AggregatePayloads tallies the payloads of the successful endorsement results and returns the
+// payload returned by the largest number of endorsers. A tie is broken in favour of the lexicographically
+// smallest payload. An error is returned if none of the results is successful.
+func AggregatePayloads(results []*EndorsementResult) (AggregateResult, error) {
+	var groups []responseGroup
+	for _, result := range results {
+		if result.Success {
+			groups = groupByValue(groups, result.Response.ProposalResponse.GetResponse().Payload, result.Response)
+		}
+	}
+	if len(groups) == 0 {
+		return AggregateResult{Total: len(results)}, errors.New("none of the endorsers returned a successful response")
+	}
+
+	majority := groups[majorityGroup(groups)]
+
+	agreed := make(map[string]bool)
+	for _, r := range majority.responses {
+		agreed[r.Endorser] = true
+	}
+	var dissenters []string
+	for _, result := range results {
+		if !result.Success || !agreed[result.Response.Endorser] {
+			dissenters = append(dissenters, result.Endorser)
+		}
+	}
+
+	return AggregateResult{
+		Payload:    majority.value,
+		Agreed:     len(majority.responses),
+		Total:      len(results),
+		Agreement:  float64(len(majority.responses)) / float64(len(results)),
+		Dissenters: dissenters,
+	}, nil
+}
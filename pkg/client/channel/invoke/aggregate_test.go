@@ -0,0 +1,59 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestAggregatePayloads(t *testing.T) {
+	results := []*EndorsementResult{
+		aggregateResult("peer1", []byte("a")),
+		aggregateResult("peer2", []byte("b")),
+		{Endorser: "peer3", Error: errors.New("endorsement failed")},
+		aggregateResult("peer4", []byte("b")),
+		aggregateResult("peer5", []byte("a")),
+	}
+
+	// Tie is broken in favour of the smallest payload
+	result, err := AggregatePayloads(results)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.Equal(t, []byte("a"), result.Payload)
+	assert.Equal(t, 2, result.Agreed)
+	assert.Equal(t, 5, result.Total)
+	assert.Equal(t, 0.4, result.Agreement)
+	assert.Equal(t, []string{"peer2", "peer3", "peer4"}, result.Dissenters)
+
+	result, err = AggregatePayloads(append(results, aggregateResult("peer6", []byte("b"))))
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.Equal(t, []byte("b"), result.Payload)
+	assert.Equal(t, 3, result.Agreed)
+
+	_, err = AggregatePayloads(results[2:3])
+	assert.NotNil(t, err, "expected error if none of the results is successful")
+}
+
+func aggregateResult(endorser string, payload []byte) *EndorsementResult {
+	return &EndorsementResult{
+		Endorser: endorser,
+		Success:  true,
+		Response: &fab.TransactionProposalResponse{
+			Endorser:         endorser,
+			ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{Status: 200, Payload: payload}},
+		},
+	}
+}
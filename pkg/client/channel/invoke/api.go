@@ -9,8 +9,13 @@ package invoke
 
 import (
 	reqContext "context"
+	"crypto/x509/pkix"
+	"math/rand"
 	"time"
 
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
 	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
@@ -20,11 +25,71 @@ import (
 
 // Opts allows the user to specify more advanced options
 type Opts struct {
-	Targets       []fab.Peer // targets
-	TargetFilter  fab.TargetFilter
-	Retry         retry.Opts
-	Timeouts      map[core.TimeoutType]time.Duration
-	ParentContext reqContext.Context //parent grpc context
+	Targets                  []fab.Peer // targets
+	TargetFilter             fab.TargetFilter
+	Retry                    retry.Opts
+	Timeouts                 map[core.TimeoutType]time.Duration
+	ParentContext            reqContext.Context           //parent grpc context
+	PayloadQuorum            int                          //minimum number of endorsers that must agree on the payload (0 means all)
+	PreferredLabels          map[string]string            //peer metadata labels preferred when selecting endorsers
+	MinEndorsingOrgs         int                          //minimum number of distinct orgs (MSP IDs) that must endorse
+	RequiredOrgs             []string                     //MSP IDs of the orgs that must endorse
+	CommitQuorum             int                          //number of event sources that must report the commit (0 means the first one)
+	Transactor               fab.Transactor               //overrides the client context transactor
+	ChaincodeEventFilter     string                       //if set, the chaincode event matching the filter is returned when the transaction commits
+	EndorserConcurrency      int                          //maximum number of endorsers that are sent the proposal concurrently (0 means no limit)
+	NormalizeArgs            bool                         //canonicalize JSON args and transient values before creating the proposal
+	NonceGenerator           NonceGenerator               //generates the nonce of the transaction header (random nonce if nil)
+	OrdererComparator        fab.OrdererComparator        //order in which orderers are tried (random order if nil)
+	ProposalObserver         ProposalObserver             //invoked with the serialized proposal before it is sent
+	CompareWriteSets         bool                         //compare the RW set writes of the endorsements instead of the response payloads
+	MinBlockHeight           uint64                       //minimum ledger height of the selected endorsers (0 means any height)
+	BlockHeightWait          time.Duration                //maximum time to wait for endorsers to reach MinBlockHeight
+	ProposalSigner           fab.ProposalSigner           //signs the proposal and the transaction (the signing manager of the client context is used if nil)
+	TransientMapOverrides    map[string]map[string][]byte //transient maps sent to specific endorsers, keyed by peer URL or MSP ID
+	AcceptedValidationCodes  []pb.TxValidationCode        //non-VALID validation codes that are treated as success
+	CompressProposal         bool                         //gzip-compress the proposal sent to the endorsers
+	MandatoryPeers           []fab.Peer                   //peers that are always sent the proposal and must endorse it
+	CompareCCVersions        bool                         //fail if the endorsers ran different chaincode versions
+	ProposalMetadata         map[string]string            //gRPC metadata headers sent with the proposal to the endorsers
+	ProposalCallOptions      []grpc.CallOption            //gRPC call options used to send the proposal to the endorsers
+	EndorsementSatisfier     EndorsementSatisfier         //stop sending the proposal once the matching endorsements satisfy the policy
+	CorrelationIDKey         string                       //transient map key of a generated correlation ID that the chaincode must echo
+	Collections              []string                     //private data collections written by the chaincode, used to select the endorsers
+	SkipPayloadComparison    bool                         //only check the status of the endorsements without comparing them
+	CommitObserver           CommitObserver               //invoked with the outcome once the transaction is committed as VALID
+	CRLProvider              CRLProvider                  //provides the CRLs against which the endorser identities are checked
+	ShadowTargets            []fab.Peer                   //shadow (canary) endorsers whose endorsement is compared but never committed
+	RetryBudget              int                          //maximum number of retries of the invoke (unlimited if 0)
+	RequireNonEmptyPayload   bool                         //fail if a successful endorsement carries an empty payload
+	Logger                   Logger                       //receives the handler log messages of the invoke instead of the fabsdk/client logger
+	ReportReadConflicts      bool                         //report the keys that the endorsers read at different versions
+	SubmitApprover           SubmitApprover               //invoked before the transaction is submitted; an error vetoes the submission
+	RetryableValidationCodes []pb.TxValidationCode        //validation codes for which the whole execute is retried
+	ProcessorDecorator       ProcessorDecorator           //wraps the proposal processor of each target before the proposal is sent
+	HealthChecker            HealthChecker                //checks the health of the targets before the proposal is sent
+	HealthCheckTimeout       time.Duration                //timeout of each target health check (DefaultHealthCheckTimeout if 0)
+	ReserveTargets           []fab.Peer                   //healthy peers that replace the targets failing the health check
+	SortResponses            bool                         //sort the endorsement responses by endorser MSP ID and URL
+	ResponseEncoding         string                       //encoding of the response payload requested from the chaincode through the transient map
+	FallbackChaincodeID      string                       //chaincode that is endorsed instead if the endorsement of the request chaincode fails
+	FallbackCondition        FallbackCondition            //errors of the request chaincode that trigger the fallback (IsChaincodeNotFound if nil)
+	AcceptedStatuses         []int32                      //endorsement response statuses that are accepted as success in addition to 200
+	RequireStatusAgreement   bool                         //fail if the endorsers returned different response statuses
+	CollectionOwnerMSPID     string                       //org owning the collections whose peers are preferred when selecting endorsers
+	RedirectInterpreter      RedirectInterpreter          //recognizes a redirect hint in an endorser response, which is followed once
+	MaxEndorsementAge        time.Duration                //fail the endorsements of a proposal created longer ago than this
+	OrgEndorsementTimeouts   map[string]time.Duration     //endorsement timeout of the targets of each org (MSP ID)
+	TraceSelection           bool                         //record the endorser selection attempts in the response
+	CanonicalWriteSets       bool                         //sort the compared write sets by namespace and key
+	EndorsementContext       reqContext.Context           //deadline, cancellation and values applied to the endorsement calls only
+	ConfirmationPeer         fab.Peer                     //trusted peer that must confirm the endorsed result before it's committed
+	PayloadNormalizer        PayloadNormalizer            //normalizes the response payloads before they're compared
+	RequestID                string                       //user-defined request ID included in the handler log messages
+	CheckConfigSequence      bool                         //abort if the channel config sequence changes between selection and commit
+	EventFailoverWindow      time.Duration                //fail over to an alternate event service if no TxStatus event arrives within the window
+	CheckTransactionSize     bool                         //reject a transaction larger than MaxTransactionBytes before it's submitted
+	MaxTransactionBytes      int                          //maximum estimated size of the transaction envelope (0 for the AbsoluteMaxBytes of the channel)
 }
 
 // Request contains the parameters to execute transaction
@@ -42,6 +107,72 @@ type Response struct {
 	TxValidationCode pb.TxValidationCode
 	Proposal         *fab.TransactionProposal
 	Responses        []*fab.TransactionProposalResponse
+	Dissenters       []*fab.TransactionProposalResponse
+	ChaincodeEvent   *fab.CCEvent
+	Endorsements     []*EndorsementResult
+	StaleEndorsers   []string
+	DivergentShadows []string
+	ReadConflicts    []ReadVersionConflict
+	CommitLatency    CommitLatency
+	ResponseEncoding string
+	SelectionTrace   *SelectionTrace
+}
+
+// NonceGenerator generates the nonce that is used in the transaction header
+type NonceGenerator func() ([]byte, error)
+
+// ProposalObserver is invoked with the serialized transaction proposal (the content that is signed)
+// before the proposal is sent to the endorsers
+type ProposalObserver func(txnID fab.TransactionID, proposal []byte)
+
+// CommitOutcome is the outcome of a transaction that was committed as VALID
+type CommitOutcome struct {
+	TxID        fab.TransactionID
+	BlockNumber uint64
+	WriteKeys   map[string][]string //keys written by the transaction, by chaincode namespace (nil if the write set couldn't be decoded)
+}
+
+// CommitObserver is invoked with the outcome of a transaction once it's committed as VALID
+// (for example, to invalidate the entries of a local cache that were written by the transaction)
+type CommitObserver func(outcome CommitOutcome)
+
+// SubmitApprover is invoked with the validated endorsement response before the transaction is submitted to the
+// ordering service (for example, for a human or policy check). Returning an error vetoes the submission.
+type SubmitApprover func(response Response) error
+
+// ProcessorDecorator returns the proposal processor to which the proposal for the given target is sent. It is
+// invoked with the default processor of the target (for example, to add instrumentation or to use a specialized
+// transport) and may return it unchanged.
+type ProcessorDecorator func(target fab.Peer, processor fab.ProposalProcessor) fab.ProposalProcessor
+
+// CRLProvider provides the certificate revocation lists against which the identities of the endorsers are checked.
+// The CRLs are trusted as-is (their signatures aren't verified).
+type CRLProvider interface {
+	CRLs() ([]*pkix.CertificateList, error)
+}
+
+// RequestTransformer rewrites the chaincode invoke request (for example Fcn, Args and TransientMap)
+// just before the transaction proposal is created
+type RequestTransformer func(request *fab.ChaincodeInvokeRequest) error
+
+// TransactorProvider returns a transactor for the orderers in the latest channel config
+type TransactorProvider func() (fab.Transactor, error)
+
+// EventServiceResolver returns the event service of the given channel
+type EventServiceResolver interface {
+	EventService(channelID string) (fab.EventService, error)
+}
+
+// ChannelEventServices is an EventServiceResolver that maps channel IDs to event services
+type ChannelEventServices map[string]fab.EventService
+
+// EventService returns the event service of the given channel
+func (s ChannelEventServices) EventService(channelID string) (fab.EventService, error) {
+	eventService, ok := s[channelID]
+	if !ok {
+		return nil, errors.Errorf("no event service for channel [%s]", channelID)
+	}
+	return eventService, nil
 }
 
 //Handler for chaining transaction executions
@@ -51,12 +182,29 @@ type Handler interface {
 
 //ClientContext contains context parameters for handler execution
 type ClientContext struct {
-	CryptoSuite  core.CryptoSuite
-	Discovery    fab.DiscoveryService
-	Selection    fab.SelectionService
-	Membership   fab.ChannelMembership
-	Transactor   fab.Transactor
-	EventService fab.EventService
+	CryptoSuite             core.CryptoSuite
+	Discovery               fab.DiscoveryService
+	Selection               fab.SelectionService
+	Membership              fab.ChannelMembership
+	Transactor              fab.Transactor
+	EventService            fab.EventService
+	RequestTransformer      RequestTransformer
+	AdditionalEventServices []fab.EventService
+	SelectionCircuitBreaker *SelectionCircuitBreaker
+	LatencyTracker          *LatencyTracker
+	EventServiceResolver    EventServiceResolver
+	SelectionCache          SelectionCache
+	SelectionRandom         *rand.Rand
+	EndorserCommManager     fab.CommManager
+	ConcurrencyLimiter      *ConcurrencyLimiter
+	CommitTransactor        TransactorProvider
+	Tracer                  Tracer
+	ErrorRateTracker        *ErrorRateTracker
+	BatchCommitListener     *BatchCommitListener
+	ConfigSequence          ConfigSequenceProvider
+	MaxTransactionBytes     MaxTransactionBytesProvider
+	ChaincodeRateLimiter    *ChaincodeRateLimiter
+	TxStatusFanout          *TxStatusFanout
 }
 
 //RequestContext contains request, opts, response parameters for handler execution
@@ -68,4 +216,8 @@ type RequestContext struct {
 	RetryHandler    retry.Handler
 	Ctx             reqContext.Context
 	SelectionFilter selectopts.PeerFilter
+	FailedEndorsers []string
+	RetryBudget     *RetryBudget
+	ConfigSequence  uint64
+	targetsSelected bool // Opts.Targets were selected by the selection service rather than specified
 }
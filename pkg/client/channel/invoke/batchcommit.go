@@ -0,0 +1,175 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// BatchCommitListener registers a single filtered block event listener per event service and demultiplexes the
+// TxStatus outcomes of the filtered blocks to the pending transactions. When set in the client context, the
+// CommitTxHandler registers the transactions with the listener instead of registering a TxStatus event per
+// transaction with the event service, which reduces the registration churn when many transactions are submitted.
+// Note that the CommitLatency of a RecordingEventService isn't recorded for the transactions of the listener.
+type BatchCommitListener struct {
+	mutex     sync.Mutex
+	listeners map[fab.EventService]*blockListener
+}
+
+// blockListener is the filtered block event registration of an event service and the transactions pending on it
+type blockListener struct {
+	eventService fab.EventService
+	reg          fab.Registration
+	pending      map[string][]chan *fab.TxStatusEvent
+}
+
+// NewBatchCommitListener returns a new BatchCommitListener. The block listener of an event service is registered
+// when the first transaction is registered on that event service.
+func NewBatchCommitListener() *BatchCommitListener {
+	return &BatchCommitListener{listeners: make(map[fab.EventService]*blockListener)}
+}
+
+// Register registers the given transaction on the block listener of the given event service. The TxStatus event
+// of the transaction is delivered on the returned channel. The channel is closed without an event if the block
+// listener stops (for example, because the event service disconnected), in which case the transaction must be
+// registered again. The returned function must be called to release the registration.
+func (l *BatchCommitListener) Register(eventService fab.EventService, txID string) (<-chan *fab.TxStatusEvent, func(), error) {
+	l.mutex.Lock()
+	listener, ok := l.listeners[eventService]
+	l.mutex.Unlock()
+
+	if !ok {
+		// The block listener is registered without holding the mutex so that a slow registration on one
+		// event service doesn't hold up the transactions of the other event services
+		reg, blockch, err := eventService.RegisterFilteredBlockEvent()
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "error registering for filtered block events")
+		}
+		listener = &blockListener{eventService: eventService, reg: reg, pending: make(map[string][]chan *fab.TxStatusEvent)}
+
+		l.mutex.Lock()
+		if current, ok := l.listeners[eventService]; ok {
+			// Another transaction registered a block listener on the event service in the meantime
+			l.mutex.Unlock()
+			eventService.Unregister(reg)
+			listener = current
+		} else {
+			l.listeners[eventService] = listener
+			l.mutex.Unlock()
+			go l.listen(listener, blockch)
+		}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	eventch := make(chan *fab.TxStatusEvent, 1)
+	if l.listeners[listener.eventService] != listener {
+		// The block listener stopped after it was looked up
+		close(eventch)
+		return eventch, func() {}, nil
+	}
+	listener.pending[txID] = append(listener.pending[txID], eventch)
+	return eventch, func() { l.release(listener, txID, eventch) }, nil
+}
+
+// Close unregisters the block listeners of all of the event services. The channels of the transactions that
+// are still pending are closed without a TxStatus event.
+func (l *BatchCommitListener) Close() {
+	l.mutex.Lock()
+	var listeners []*blockListener
+	for eventService, listener := range l.listeners {
+		delete(l.listeners, eventService)
+		listener.fail()
+		listeners = append(listeners, listener)
+	}
+	l.mutex.Unlock()
+
+	for _, listener := range listeners {
+		listener.eventService.Unregister(listener.reg)
+	}
+}
+
+// listen delivers the TxStatus outcomes of the filtered blocks to the pending transactions. If the event
+// channel is closed (for example, because the event service disconnected) then the listener is removed so
+// that the next registration registers a new block listener, and the channels of the transactions that are
+// still pending are closed so that their commit handlers can register again.
+func (l *BatchCommitListener) listen(listener *blockListener, eventch <-chan *fab.FilteredBlockEvent) {
+	for event := range eventch {
+		l.notify(listener, event)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.listeners[listener.eventService] == listener {
+		delete(l.listeners, listener.eventService)
+	}
+	if len(listener.pending) > 0 {
+		logger.Debugf("Filtered block event channel closed - failing %d pending transaction(s)", len(listener.pending))
+	}
+	listener.fail()
+}
+
+// fail closes the channels of the pending transactions and removes them. The BatchCommitListener mutex
+// must be held.
+func (listener *blockListener) fail() {
+	for txID, eventchs := range listener.pending {
+		for _, eventch := range eventchs {
+			close(eventch)
+		}
+		delete(listener.pending, txID)
+	}
+}
+
+// notify delivers the TxStatus outcome of each transaction of the filtered block to the channels pending on it
+func (l *BatchCommitListener) notify(listener *blockListener, event *fab.FilteredBlockEvent) {
+	block := event.FilteredBlock
+	if block == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, tx := range block.FilteredTransactions {
+		eventchs, ok := listener.pending[tx.Txid]
+		if !ok {
+			continue
+		}
+		txStatus := &fab.TxStatusEvent{TxID: tx.Txid, TxValidationCode: tx.TxValidationCode, BlockNumber: block.Number}
+		for _, eventch := range eventchs {
+			select {
+			case eventch <- txStatus:
+			default:
+			}
+		}
+		delete(listener.pending, tx.Txid)
+	}
+}
+
+// release removes the given channel from the channels pending on the transaction
+func (l *BatchCommitListener) release(listener *blockListener, txID string, eventch chan *fab.TxStatusEvent) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	eventchs := listener.pending[txID]
+	for i, ch := range eventchs {
+		if ch == eventch {
+			eventchs = append(eventchs[:i], eventchs[i+1:]...)
+			break
+		}
+	}
+	if len(eventchs) == 0 {
+		delete(listener.pending, txID)
+	} else {
+		listener.pending[txID] = eventchs
+	}
+}
@@ -0,0 +1,324 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// blockEventService is an event service that supports filtered block event registrations
+type blockEventService struct {
+	*fcmocks.MockEventService
+	mutex         sync.Mutex
+	registrations int
+	eventch       chan *fab.FilteredBlockEvent
+}
+
+func newBlockEventService() *blockEventService {
+	return &blockEventService{MockEventService: fcmocks.NewMockEventService(), eventch: make(chan *fab.FilteredBlockEvent, 10)}
+}
+
+func (s *blockEventService) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.registrations++
+	return s.eventch, s.eventch, nil
+}
+
+func (s *blockEventService) Unregister(reg fab.Registration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if reg == s.eventch {
+		s.disconnect()
+	}
+}
+
+// blockingEventService is an event service whose filtered block event registration blocks until it is released
+type blockingEventService struct {
+	*blockEventService
+	registering chan struct{}
+	released    chan struct{}
+}
+
+func (s *blockingEventService) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	close(s.registering)
+	<-s.released
+	return s.blockEventService.RegisterFilteredBlockEvent()
+}
+
+// events returns the channel of the current filtered block event registration
+func (s *blockEventService) events() chan *fab.FilteredBlockEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.eventch
+}
+
+// registrationCount returns the number of filtered block event registrations
+func (s *blockEventService) registrationCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.registrations
+}
+
+// disconnect closes the channel of the current registration, as when the event service disconnects, and
+// provides a new channel for the next registration. The mutex must be held.
+func (s *blockEventService) disconnect() {
+	close(s.eventch)
+	s.eventch = make(chan *fab.FilteredBlockEvent, 10)
+}
+
+// filteredBlock returns a filtered block event with a transaction for each of the given TxIDs
+func filteredBlock(number uint64, code pb.TxValidationCode, txIDs ...string) *fab.FilteredBlockEvent {
+	block := &pb.FilteredBlock{Number: number}
+	for _, txID := range txIDs {
+		block.FilteredTransactions = append(block.FilteredTransactions, &pb.FilteredTransaction{Txid: txID, TxValidationCode: code})
+	}
+	return &fab.FilteredBlockEvent{FilteredBlock: block}
+}
+
+// pendingTxIDs returns the TxIDs pending on the listener of the given event service
+func pendingTxIDs(listener *BatchCommitListener, eventService fab.EventService) []string {
+	listener.mutex.Lock()
+	defer listener.mutex.Unlock()
+
+	var txIDs []string
+	if l, ok := listener.listeners[eventService]; ok {
+		for txID := range l.pending {
+			txIDs = append(txIDs, txID)
+		}
+	}
+	return txIDs
+}
+
+func TestBatchCommitListener(t *testing.T) {
+	eventService := newBlockEventService()
+	listener := NewBatchCommitListener()
+
+	eventch1, release1, err := listener.Register(eventService, "tx1")
+	assert.Nil(t, err)
+	defer release1()
+	eventch2, release2, err := listener.Register(eventService, "tx2")
+	assert.Nil(t, err)
+	eventch3, release3, err := listener.Register(eventService, "tx3")
+	assert.Nil(t, err)
+	release3()
+	assert.Equal(t, 1, eventService.registrationCount(), "expecting a single block listener registration")
+
+	eventService.events() <- filteredBlock(5, pb.TxValidationCode_VALID, "tx1", "tx3", "other")
+	eventService.events() <- filteredBlock(6, pb.TxValidationCode_MVCC_READ_CONFLICT, "tx2")
+
+	select {
+	case txStatus := <-eventch1:
+		assert.Equal(t, &fab.TxStatusEvent{TxID: "tx1", TxValidationCode: pb.TxValidationCode_VALID, BlockNumber: 5}, txStatus)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for TxStatus event of tx1")
+	}
+	select {
+	case txStatus := <-eventch2:
+		assert.Equal(t, pb.TxValidationCode_MVCC_READ_CONFLICT, txStatus.TxValidationCode)
+		assert.Equal(t, uint64(6), txStatus.BlockNumber)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for TxStatus event of tx2")
+	}
+	release2()
+	assert.Empty(t, eventch3, "expecting no TxStatus event for a released transaction")
+	assert.Empty(t, pendingTxIDs(listener, eventService))
+
+	listener.Close()
+	_, _, err = listener.Register(eventService, "tx4")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, eventService.registrationCount(), "expecting the block listener to be registered again after close")
+}
+
+func TestBatchCommitListenerDisconnect(t *testing.T) {
+	eventService := newBlockEventService()
+	listener := NewBatchCommitListener()
+	defer listener.Close()
+
+	eventch, release, err := listener.Register(eventService, "tx1")
+	assert.Nil(t, err)
+	defer release()
+
+	eventService.mutex.Lock()
+	eventService.disconnect()
+	eventService.mutex.Unlock()
+
+	select {
+	case _, ok := <-eventch:
+		assert.False(t, ok, "expecting the pending transaction to be failed")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the pending transaction to be failed")
+	}
+
+	// The transaction registers again on a new block listener
+	eventch, release, err = listener.Register(eventService, "tx1")
+	assert.Nil(t, err)
+	defer release()
+	assert.Equal(t, 2, eventService.registrationCount(), "expecting a new block listener registration")
+
+	eventService.events() <- filteredBlock(8, pb.TxValidationCode_VALID, "tx1")
+	select {
+	case txStatus, ok := <-eventch:
+		if assert.True(t, ok) {
+			assert.Equal(t, pb.TxValidationCode_VALID, txStatus.TxValidationCode)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for TxStatus event of tx1")
+	}
+}
+
+func TestBatchCommitListenerSlowRegistration(t *testing.T) {
+	slowEventService := &blockingEventService{blockEventService: newBlockEventService(), registering: make(chan struct{}), released: make(chan struct{})}
+	eventService := newBlockEventService()
+	listener := NewBatchCommitListener()
+	defer listener.Close()
+
+	registered := make(chan error, 1)
+	go func() {
+		_, release, err := listener.Register(slowEventService, "tx1")
+		if err == nil {
+			release()
+		}
+		registered <- err
+	}()
+	<-slowEventService.registering
+
+	// A transaction on another event service registers while the slow registration is in progress
+	done := make(chan error, 1)
+	go func() {
+		_, release, err := listener.Register(eventService, "tx2")
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("registration was held up by the registration on another event service")
+	}
+
+	close(slowEventService.released)
+	assert.Nil(t, <-registered)
+	assert.Equal(t, 1, slowEventService.registrationCount())
+}
+
+func TestExecuteTxHandlerWithBatchCommitListener(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	eventService := newBlockEventService()
+	listener := NewBatchCommitListener()
+	defer listener.Close()
+
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	clientContext.EventService = eventService
+	clientContext.BatchCommitListener = listener
+
+	go func() {
+		for {
+			if txIDs := pendingTxIDs(listener, eventService); len(txIDs) > 0 {
+				eventService.events() <- filteredBlock(7, pb.TxValidationCode_VALID, txIDs...)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, pb.TxValidationCode_VALID, requestContext.Response.TxValidationCode)
+	assert.Empty(t, eventService.TxStatusRegCh, "expecting no TxStatus registration")
+	assert.Equal(t, 1, eventService.registrationCount())
+
+	// The event service disconnects before the block of the transaction is delivered
+	go func() {
+		disconnected := false
+		for {
+			if txIDs := pendingTxIDs(listener, eventService); len(txIDs) > 0 {
+				if !disconnected {
+					eventService.mutex.Lock()
+					eventService.disconnect()
+					eventService.mutex.Unlock()
+					disconnected = true
+				} else if eventService.registrationCount() == 2 {
+					eventService.events() <- filteredBlock(9, pb.TxValidationCode_VALID, txIDs...)
+					return
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, pb.TxValidationCode_VALID, requestContext.Response.TxValidationCode)
+	assert.Equal(t, 2, eventService.registrationCount(), "expecting the transaction to be registered again")
+}
+
+func TestExecuteTxHandlerWithBatchCommitListenerLedgerStatus(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{RWLock: &sync.RWMutex{}, MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	eventService := newBlockEventService()
+	listener := NewBatchCommitListener()
+	defer listener.Close()
+
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	clientContext.EventService = eventService
+	clientContext.BatchCommitListener = listener
+
+	// The block of the transaction is committed while the event service is disconnected, so
+	// the status of the transaction is only available from the ledger
+	go func() {
+		for {
+			if txIDs := pendingTxIDs(listener, eventService); len(txIDs) > 0 {
+				block := servicemocks.NewBlock("testchannel", servicemocks.NewTransaction(txIDs[0], pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+				payload, err := proto.Marshal(block)
+				if err != nil {
+					panic(err)
+				}
+				mockPeer1.RWLock.Lock()
+				mockPeer1.Payload = payload
+				mockPeer1.RWLock.Unlock()
+
+				eventService.mutex.Lock()
+				eventService.disconnect()
+				eventService.mutex.Unlock()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer1}}, t)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, pb.TxValidationCode_VALID, requestContext.Response.TxValidationCode)
+	assert.Equal(t, 2, eventService.registrationCount(), "expecting the transaction to be registered again")
+	assert.Empty(t, pendingTxIDs(listener, eventService), "expecting the registration to be released")
+}
@@ -0,0 +1,123 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+const (
+	qscc             = "qscc"
+	qsccGetChainInfo = "GetChainInfo"
+)
+
+// ledgerHeights resolves the ledger heights of the peers that are considered for selection. The height of a
+// peer that implements fab.PeerState is the height reported by the peer; the height of any other peer is queried
+// with the GetChainInfo function of the peer's qscc. The queried heights are cached for the duration of a
+// selection attempt so that each peer is queried at most once per attempt.
+type ledgerHeights struct {
+	requestContext *RequestContext
+	clientContext  *ClientContext
+	mutex          sync.Mutex
+	heights        map[string]ledgerHeight
+}
+
+// ledgerHeight is the result of the query of the ledger height of a peer
+type ledgerHeight struct {
+	height uint64
+	known  bool
+}
+
+func newLedgerHeights(requestContext *RequestContext, clientContext *ClientContext) *ledgerHeights {
+	return &ledgerHeights{
+		requestContext: requestContext,
+		clientContext:  clientContext,
+		heights:        make(map[string]ledgerHeight),
+	}
+}
+
+// height returns the ledger height of the given peer, or false if it can't be determined
+func (l *ledgerHeights) height(peer fab.Peer) (uint64, bool) {
+	if state, ok := peer.(fab.PeerState); ok {
+		return state.BlockHeight(), true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	h, ok := l.heights[peer.URL()]
+	if !ok {
+		height, err := queryLedgerHeight(l.requestContext, l.clientContext, peer)
+		if err != nil {
+			newLogFields(l.requestContext).debugf("unable to query the ledger height of peer [%s]: %s", peer.URL(), err)
+		}
+		h = ledgerHeight{height: height, known: err == nil}
+		l.heights[peer.URL()] = h
+	}
+	return h.height, h.known
+}
+
+// queryLedgerHeight queries the height of the ledger of the given peer with the GetChainInfo function of qscc
+func queryLedgerHeight(requestContext *RequestContext, clientContext *ClientContext, peer fab.Peer) (uint64, error) {
+	payload, err := queryQSCC(requestContext, clientContext, peer, qsccGetChainInfo)
+	if err != nil {
+		return 0, err
+	}
+
+	info := &common.BlockchainInfo{}
+	if err := proto.Unmarshal(payload, info); err != nil {
+		return 0, errors.Wrap(err, "unmarshal of chain info failed")
+	}
+	return info.Height, nil
+}
+
+// queryQSCC invokes the given function of the qscc of the given peer with the channel ID and the given
+// arguments, and returns the payload of the response
+func queryQSCC(requestContext *RequestContext, clientContext *ClientContext, peer fab.Peer, fcn string, args ...string) ([]byte, error) {
+	transactor := transactor(requestContext, clientContext)
+	if transactor == nil {
+		return nil, errors.Errorf("no transactor to invoke qscc %s with", fcn)
+	}
+
+	txh, err := transactor.CreateTransactionHeader()
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating transaction header failed")
+	}
+	request := fab.ChaincodeInvokeRequest{
+		ChaincodeID: qscc,
+		Fcn:         fcn,
+		Args:        [][]byte{[]byte(txh.ChannelID())},
+	}
+	for _, arg := range args {
+		request.Args = append(request.Args, []byte(arg))
+	}
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, request)
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating transaction proposal failed")
+	}
+
+	responses, err := transactor.SendTransactionProposal(proposal, []fab.ProposalProcessor{peer})
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, errors.Errorf("no response to qscc %s", fcn)
+	}
+	response := responses[0]
+	if response.Status != http.StatusOK {
+		return nil, errors.Errorf("qscc %s failed with status %d", fcn, response.Status)
+	}
+	return response.ProposalResponse.GetResponse().GetPayload(), nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+)
+
+// chaincodeVersion returns the version of the chaincode that produced the given proposal response
+// or an empty string if the endorser didn't include the chaincode ID in the response
+func chaincodeVersion(r *fab.TransactionProposalResponse) (string, error) {
+	prp, err := protos_utils.GetProposalResponsePayload(r.ProposalResponse.GetPayload())
+	if err != nil {
+		return "", errors.WithMessage(err, "unmarshal of proposal response payload failed")
+	}
+	ccAction, err := protos_utils.GetChaincodeAction(prp.Extension)
+	if err != nil {
+		return "", errors.WithMessage(err, "unmarshal of chaincode action failed")
+	}
+	return ccAction.GetChaincodeId().GetVersion(), nil
+}
+
+// validateChaincodeVersions checks that all of the successful endorsements were produced by the same
+// chaincode version. Endorsements that don't include the chaincode version are ignored.
+func validateChaincodeVersions(responses []*fab.TransactionProposalResponse) error {
+	endorsers := make(map[string][]string)
+	for _, r := range responses {
+		if r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+			continue
+		}
+		version, err := chaincodeVersion(r)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("failed to extract chaincode version from proposal response of [%s]", r.Endorser))
+		}
+		if version != "" {
+			endorsers[version] = append(endorsers[version], r.Endorser)
+		}
+	}
+
+	if len(endorsers) <= 1 {
+		return nil
+	}
+
+	var versions []string
+	for version, peers := range endorsers {
+		versions = append(versions, fmt.Sprintf("%s %v", version, peers))
+	}
+	sort.Strings(versions)
+	return status.New(status.EndorserClientStatus, status.ChaincodeVersionMismatch.ToInt32(),
+		"endorsers ran different chaincode versions: "+strings.Join(versions, ", "), nil)
+}
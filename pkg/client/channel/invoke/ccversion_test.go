@@ -0,0 +1,69 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestEndorsementValidationHandlerWithChaincodeVersions(t *testing.T) {
+	r1 := versionResponse("peer1", "v1", []byte("value1"), t)
+	r2 := versionResponse("peer2", "v1", []byte("value1"), t)
+	r3 := versionResponse("peer3", "v2", []byte("value2"), t)
+	r4 := versionResponse("peer4", "", []byte("value1"), t)
+
+	requestContext := &RequestContext{Opts: Opts{CompareCCVersions: true}, Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r2, r4}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	assert.Nil(t, requestContext.Error, "expecting endorsements without a version to be ignored")
+
+	requestContext = &RequestContext{Opts: Opts{CompareCCVersions: true}, Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r2, r3}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "v1 [peer1 peer2], v2 [peer3]") {
+		t.Fatal("Expected chaincode version mismatch error, Received error:", requestContext.Error)
+	}
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expecting status error")
+	assert.EqualValues(t, status.ChaincodeVersionMismatch, status.ToSDKStatusCode(s.Code))
+
+	// Without the option the generic mismatch is reported
+	requestContext = &RequestContext{Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r2, r3}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), endorsementMisMatchError) {
+		t.Fatal("Expected error: ", endorsementMisMatchError, ", Received error:", requestContext.Error)
+	}
+}
+
+func versionResponse(endorser, version string, payload []byte, t *testing.T) *fab.TransactionProposalResponse {
+	ccAction := &pb.ChaincodeAction{Response: &pb.Response{Status: 200, Payload: payload}}
+	if version != "" {
+		ccAction.ChaincodeId = &pb.ChaincodeID{Name: "testCC", Version: version}
+	}
+	ccActionBytes, err := proto.Marshal(ccAction)
+	if err != nil {
+		t.Fatalf("Failed to marshal chaincode action: %s", err)
+	}
+	prp, err := proto.Marshal(&pb.ProposalResponsePayload{Extension: ccActionBytes})
+	if err != nil {
+		t.Fatalf("Failed to marshal proposal response payload: %s", err)
+	}
+	return &fab.TransactionProposalResponse{
+		Endorser: endorser,
+		Status:   200,
+		ProposalResponse: &pb.ProposalResponse{
+			Response: &pb.Response{Status: 200, Payload: payload},
+			Payload:  prp,
+		},
+	}
+}
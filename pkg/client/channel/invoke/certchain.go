@@ -0,0 +1,122 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb_msp "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+// EndorserCertificateChain contains the certificate chain of the endorser of a proposal response
+type EndorserCertificateChain struct {
+	Endorser string
+	MSPID    string
+	// Chain starts with the certificate of the endorser, followed by the intermediate certificates
+	// (if any) and ends with the root certificate of the MSP
+	Chain []*x509.Certificate
+}
+
+// EndorserCertificateChains resolves the certificate chain of the endorser of each of the given responses with the root
+// and intermediate certificates of the given channel MSPs (for example, the MSPs of the channel config). The chains are
+// returned in the order of the responses. An error is returned if the certificate of an endorser doesn't chain to the
+// root certificates of its MSP.
+func EndorserCertificateChains(msps []*pb_msp.MSPConfig, responses []*fab.TransactionProposalResponse) ([]EndorserCertificateChain, error) {
+	pools, err := certPools(msps)
+	if err != nil {
+		return nil, err
+	}
+
+	chains := make([]EndorserCertificateChain, 0, len(responses))
+	for _, r := range responses {
+		chain, err := endorserCertificateChain(pools, r)
+		if err != nil {
+			return nil, err
+		}
+		chains = append(chains, chain)
+	}
+	return chains, nil
+}
+
+// mspCertPool contains the root and intermediate certificates of an MSP
+type mspCertPool struct {
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+}
+
+// certPools returns the certificate pools of the given MSPs by MSP ID
+func certPools(msps []*pb_msp.MSPConfig) (map[string]mspCertPool, error) {
+	pools := make(map[string]mspCertPool, len(msps))
+	for _, config := range msps {
+		fabricConfig := &pb_msp.FabricMSPConfig{}
+		if err := proto.Unmarshal(config.Config, fabricConfig); err != nil {
+			return nil, errors.Wrap(err, "unmarshal FabricMSPConfig from config failed")
+		}
+		roots, err := certPool(fabricConfig.RootCerts)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load root certificates of MSP "+fabricConfig.Name)
+		}
+		intermediates, err := certPool(fabricConfig.IntermediateCerts)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load intermediate certificates of MSP "+fabricConfig.Name)
+		}
+		pools[fabricConfig.Name] = mspCertPool{roots: roots, intermediates: intermediates}
+	}
+	return pools, nil
+}
+
+// certPool returns a pool with the given PEM encoded certificates
+func certPool(pemCerts [][]byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, pemCert := range pemCerts {
+		block, _ := pem.Decode(pemCert)
+		if block == nil {
+			return nil, errors.New("certificate is not PEM encoded")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing of certificate failed")
+		}
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
+
+// endorserCertificateChain resolves the certificate chain of the endorser of the given response
+func endorserCertificateChain(pools map[string]mspCertPool, r *fab.TransactionProposalResponse) (EndorserCertificateChain, error) {
+	sID, err := endorserIdentity(r)
+	if err != nil {
+		return EndorserCertificateChain{}, err
+	}
+	pool, ok := pools[sID.Mspid]
+	if !ok {
+		return EndorserCertificateChain{}, errors.Errorf("MSP %s of endorser [%s] isn't a channel MSP", sID.Mspid, r.Endorser)
+	}
+	cert, err := endorserCertificate(r)
+	if err != nil {
+		return EndorserCertificateChain{}, err
+	}
+
+	// As for the MSP validation, the chain is resolved as of the time the certificate was issued
+	// so that the chain of an expired certificate is still returned
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:         pool.roots,
+		Intermediates: pool.intermediates,
+		CurrentTime:   cert.NotBefore.Add(time.Second),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return EndorserCertificateChain{}, errors.Wrapf(err, "failed to resolve certificate chain of endorser [%s]", r.Endorser)
+	}
+	return EndorserCertificateChain{Endorser: r.Endorser, MSPID: sID.Mspid, Chain: chains[0]}, nil
+}
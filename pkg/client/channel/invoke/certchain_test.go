@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb_msp "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+// testCert is a certificate along with its key and PEM encoding
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCert creates a certificate with the given name, issued by the given parent (self-signed if nil)
+func newTestCert(name string, serial int64, isCA bool, parent *testCert, t *testing.T) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+	issuer, issuerKey := template, key
+	if parent != nil {
+		issuer, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatalf("error creating certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("error parsing certificate: %s", err)
+	}
+	return &testCert{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func newTestMSPConfig(name string, roots []*testCert, intermediates []*testCert, t *testing.T) *pb_msp.MSPConfig {
+	fabricConfig := &pb_msp.FabricMSPConfig{Name: name}
+	for _, c := range roots {
+		fabricConfig.RootCerts = append(fabricConfig.RootCerts, c.pem)
+	}
+	for _, c := range intermediates {
+		fabricConfig.IntermediateCerts = append(fabricConfig.IntermediateCerts, c.pem)
+	}
+	config, err := proto.Marshal(fabricConfig)
+	if err != nil {
+		t.Fatalf("Failed to marshal MSP config: %s", err)
+	}
+	return &pb_msp.MSPConfig{Config: config}
+}
+
+func TestEndorserCertificateChains(t *testing.T) {
+	root := newTestCert("ca.org1", 1, true, nil, t)
+	intermediate := newTestCert("ica.org1", 2, true, root, t)
+	peer1 := newTestCert("peer1.org1", 3, false, intermediate, t)
+	peer2 := newTestCert("peer2.org1", 4, false, root, t)
+
+	msps := []*pb_msp.MSPConfig{newTestMSPConfig("Org1MSP", []*testCert{root}, []*testCert{intermediate}, t)}
+	responses := []*fab.TransactionProposalResponse{
+		endorsementFromCert("peer1", peer1.pem, t),
+		endorsementFromCert("peer2", peer2.pem, t),
+	}
+
+	chains, err := EndorserCertificateChains(msps, responses)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(chains))
+	assert.Equal(t, "peer1", chains[0].Endorser)
+	assert.Equal(t, "Org1MSP", chains[0].MSPID)
+	assert.Equal(t, []*x509.Certificate{peer1.cert, intermediate.cert, root.cert}, chains[0].Chain)
+	assert.Equal(t, []*x509.Certificate{peer2.cert, root.cert}, chains[1].Chain)
+
+	// The certificate of the endorser isn't issued by its MSP
+	other := newTestCert("ca.org2", 5, true, nil, t)
+	responses = []*fab.TransactionProposalResponse{endorsementFromCert("peer3", newTestCert("peer3.org2", 6, false, other, t).pem, t)}
+	_, err = EndorserCertificateChains(msps, responses)
+	assert.NotNil(t, err, "expecting error for a certificate that doesn't chain to the MSP roots")
+
+	// The MSP of the endorser isn't a channel MSP
+	_, err = EndorserCertificateChains(nil, []*fab.TransactionProposalResponse{endorsementFromCert("peer1", peer1.pem, t)})
+	assert.NotNil(t, err, "expecting error for an endorser of an unknown MSP")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// SelectionCircuitBreaker protects the selection service. After the given number of consecutive
+// failures the breaker opens and, for the cool-down period, endorser selection fails fast (or uses
+// the fallback targets, if any) without calling the selection service. Once the cool-down period
+// expires the selection service is called again; the breaker closes on the first success and
+// re-opens on the next failure.
+type SelectionCircuitBreaker struct {
+	threshold       int
+	coolDown        time.Duration
+	fallbackTargets []fab.Peer
+
+	mutex     sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// NewSelectionCircuitBreaker returns a new selection circuit breaker
+func NewSelectionCircuitBreaker(threshold int, coolDown time.Duration, fallbackTargets ...fab.Peer) *SelectionCircuitBreaker {
+	return &SelectionCircuitBreaker{threshold: threshold, coolDown: coolDown, fallbackTargets: fallbackTargets}
+}
+
+// getEndorsers invokes the selection function unless the breaker is open
+func (cb *SelectionCircuitBreaker) getEndorsers(fields logFields, selectEndorsers func() ([]fab.Peer, error), filter selectopts.PeerFilter) ([]fab.Peer, error) {
+	if cb.isOpen() {
+		if len(cb.fallbackTargets) == 0 {
+			return nil, errors.New("selection circuit breaker is open")
+		}
+		fields.debugf("selection circuit breaker is open - using fallback targets")
+		return filterPeers(cb.fallbackTargets, filter), nil
+	}
+
+	endorsers, err := selectEndorsers()
+	cb.record(fields, err)
+	return endorsers, err
+}
+
+func (cb *SelectionCircuitBreaker) isOpen() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	return time.Now().Before(cb.openUntil)
+}
+
+func (cb *SelectionCircuitBreaker) record(fields logFields, err error) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if err == nil {
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		fields.warnf("selection failed %d consecutive time(s) - opening circuit breaker for %s", cb.failures, cb.coolDown)
+		cb.openUntil = time.Now().Add(cb.coolDown)
+	}
+}
+
+// filterPeers returns the peers accepted by the given filter (if any)
+func filterPeers(peers []fab.Peer, filter selectopts.PeerFilter) []fab.Peer {
+	if filter == nil {
+		return peers
+	}
+	var filtered []fab.Peer
+	for _, peer := range peers {
+		if filter(peer) {
+			filtered = append(filtered, peer)
+		}
+	}
+	return filtered
+}
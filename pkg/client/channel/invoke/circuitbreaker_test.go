@@ -0,0 +1,70 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestSelectionCircuitBreaker(t *testing.T) {
+	coolDown := 200 * time.Millisecond
+	peer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+
+	calls := 0
+	var selectionErr error
+	selectEndorsers := func() ([]fab.Peer, error) {
+		calls++
+		if selectionErr != nil {
+			return nil, selectionErr
+		}
+		return []fab.Peer{peer}, nil
+	}
+
+	cb := NewSelectionCircuitBreaker(2, coolDown)
+
+	selectionErr = errors.New("selection failed")
+	for i := 0; i < 2; i++ {
+		_, err := cb.getEndorsers(logFields{}, selectEndorsers, nil)
+		assert.Equal(t, selectionErr, err)
+	}
+	assert.Equal(t, 2, calls)
+
+	_, err := cb.getEndorsers(logFields{}, selectEndorsers, nil)
+	assert.EqualError(t, err, "selection circuit breaker is open")
+	assert.Equal(t, 2, calls, "expecting selection service not to be called while the breaker is open")
+
+	time.Sleep(coolDown)
+	selectionErr = nil
+	endorsers, err := cb.getEndorsers(logFields{}, selectEndorsers, nil)
+	assert.Nil(t, err, "expecting the selection service to be probed after the cool-down")
+	assert.Equal(t, []fab.Peer{peer}, endorsers)
+	assert.Equal(t, 3, calls)
+}
+
+func TestSelectionCircuitBreakerFallbackTargets(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	peer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+
+	cb := NewSelectionCircuitBreaker(1, time.Minute, peer1, peer2)
+	failingSelection := func() ([]fab.Peer, error) {
+		return nil, errors.New("selection failed")
+	}
+
+	_, err := cb.getEndorsers(logFields{}, failingSelection, nil)
+	assert.NotNil(t, err, "expecting selection error")
+
+	endorsers, err := cb.getEndorsers(logFields{}, failingSelection, func(peer fab.Peer) bool { return peer.URL() == peer2.URL() })
+	assert.Nil(t, err, "expecting fallback targets to be used while the breaker is open")
+	assert.Equal(t, []fab.Peer{peer2}, endorsers)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+// EndorsementResult contains the outcome of sending the transaction proposal to an endorser
+type EndorsementResult struct {
+	// Endorser is the URL of the endorser
+	Endorser string
+	// Success is true if the endorser returned a successful response
+	Success bool
+	// MSPID is the MSP ID of the endorsing identity (if an endorsement was returned)
+	MSPID string
+	// Identity is the serialized endorsing identity (if an endorsement was returned)
+	Identity []byte
+	// Response is the proposal response returned by the endorser (nil if the endorser failed to respond)
+	Response *fab.TransactionProposalResponse
+	// Error is set if the endorser failed to respond or returned an unsuccessful response
+	Error error
+}
+
+//NewCollectEndorsementsHandler returns a handler that collects the endorsement results of all endorsers,
+//including failures, without validating them. The results are returned in Response.Endorsements and the
+//successful responses in Response.Responses, so that the application can assemble a transaction
+//satisfying the endorsement policy itself.
+func NewCollectEndorsementsHandler(next ...Handler) Handler {
+	return NewProposalProcessorHandler(
+		NewRequestShapingHandler(
+			NewEndorsementCollectorHandler(next...),
+		),
+	)
+}
+
+//NewEndorsementCollectorHandler returns a handler that collects the endorsement results of all endorsers
+func NewEndorsementCollectorHandler(next ...Handler) *EndorsementCollectorHandler {
+	return &EndorsementCollectorHandler{next: getNext(next)}
+}
+
+//EndorsementCollectorHandler for collecting the endorsement results of all endorsers
+type EndorsementCollectorHandler struct {
+	next Handler
+}
+
+//Handle sends the proposal to the endorsers and collects the results
+func (h *EndorsementCollectorHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	targets := requestContext.Opts.Targets
+	if len(targets) == 0 {
+		requestContext.Error = status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), "targets were not provided", nil)
+		return
+	}
+
+	results := make([]*EndorsementResult, len(targets))
+	processors := proposalProcessors(requestContext, clientContext)
+	collectors := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		collectors[i] = &collectingProcessor{target: p, endorser: targets[i].URL(), results: results, index: i}
+	}
+
+	if err := waitForRateLimit(requestContext, clientContext); err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	release, err := acquireEndorsementSlot(requestContext, clientContext)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	// Errors returned by the endorsers are recorded in the results
+	_, proposal, err := createAndSendTransactionProposal(requestContext, clientContext, collectors)
+	release()
+	if proposal == nil {
+		requestContext.Error = err
+		return
+	}
+
+	requestContext.Response.Proposal = proposal
+	requestContext.Response.TransactionID = proposal.TxnID
+
+	fields := newLogFields(requestContext)
+	var responses []*fab.TransactionProposalResponse
+	for i, result := range results {
+		if result == nil {
+			// The proposal wasn't sent to the endorser
+			results[i] = &EndorsementResult{Endorser: targets[i].URL(), Error: err}
+			continue
+		}
+		if result.Success {
+			responses = append(responses, result.Response)
+		} else {
+			fields.with("endorser", result.Endorser).debugf("endorsement failed: %s", result.Error)
+		}
+	}
+
+	if requestContext.Opts.SortResponses {
+		sortResponses(responses)
+	}
+	requestContext.Response.Responses = responses
+	requestContext.Response.Endorsements = results
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+// collectingProcessor records the result of processing the proposal by the target
+type collectingProcessor struct {
+	target   fab.ProposalProcessor
+	endorser string
+	results  []*EndorsementResult
+	index    int
+}
+
+// ProcessTransactionProposal sends the proposal to the target and records the result
+func (p *collectingProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	resp, err := p.target.ProcessTransactionProposal(ctx, request)
+
+	result := &EndorsementResult{Endorser: p.endorser, Response: resp, Error: err}
+	if err == nil {
+		if sID, idErr := endorserIdentity(resp); idErr == nil {
+			result.MSPID = sID.Mspid
+			result.Identity = resp.ProposalResponse.GetEndorsement().Endorser
+		}
+		if resp.ProposalResponse.GetResponse().Status == int32(common.Status_SUCCESS) {
+			result.Success = true
+		} else {
+			result.Error = status.NewFromProposalResponse(resp.ProposalResponse, resp.Endorser)
+		}
+	}
+
+	// Each processor writes only its own slot
+	p.results[p.index] = result
+
+	return resp, err
+}
+
+func (p *collectingProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
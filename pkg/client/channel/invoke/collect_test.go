@@ -0,0 +1,56 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestCollectEndorsementsHandler(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value"), Endorser: serializedIdentity("Org1MSP", t)}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org2MSP", Status: 500, Payload: []byte("error"), Endorser: serializedIdentity("Org2MSP", t)}
+	mockPeer3 := &fcmocks.MockPeer{MockName: "Peer3", MockURL: "http://peer3.com", MockMSP: "Org3MSP", Error: errors.New("connection error")}
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer1, mockPeer2, mockPeer3}}, t)
+	NewCollectEndorsementsHandler().Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+
+	endorsements := requestContext.Response.Endorsements
+	if !assert.Equal(t, 3, len(endorsements)) {
+		return
+	}
+
+	assert.Equal(t, mockPeer1.MockURL, endorsements[0].Endorser)
+	assert.True(t, endorsements[0].Success)
+	assert.Nil(t, endorsements[0].Error)
+	assert.Equal(t, "Org1MSP", endorsements[0].MSPID)
+	assert.NotNil(t, endorsements[0].Identity)
+
+	assert.Equal(t, mockPeer2.MockURL, endorsements[1].Endorser)
+	assert.False(t, endorsements[1].Success)
+	assert.NotNil(t, endorsements[1].Error)
+	assert.Equal(t, "Org2MSP", endorsements[1].MSPID)
+
+	assert.Equal(t, mockPeer3.MockURL, endorsements[2].Endorser)
+	assert.False(t, endorsements[2].Success)
+	assert.NotNil(t, endorsements[2].Error)
+
+	if assert.Equal(t, 1, len(requestContext.Response.Responses)) {
+		assert.Equal(t, endorsements[0].Response, requestContext.Response.Responses[0])
+	}
+	assert.NotEqual(t, fab.EmptyTransactionID, requestContext.Response.TransactionID)
+}
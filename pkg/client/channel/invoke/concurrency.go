@@ -0,0 +1,74 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+// ConcurrencyLimiter bounds the number of invokes that are in the endorsement phase at the same time
+// so that a burst of invokes doesn't overwhelm the endorsers. Once the limit is reached, an invoke either
+// waits until another invoke leaves the endorsement phase (or the request times out) or, if failFast is
+// set, fails immediately with a ConcurrencyLimitExceeded status.
+type ConcurrencyLimiter struct {
+	slots    chan struct{}
+	failFast bool
+}
+
+// NewConcurrencyLimiter returns a new concurrency limiter allowing up to maxConcurrent (at least one)
+// invokes in the endorsement phase
+func NewConcurrencyLimiter(maxConcurrent int, failFast bool) *ConcurrencyLimiter {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &ConcurrencyLimiter{slots: make(chan struct{}, maxConcurrent), failFast: failFast}
+}
+
+// InFlight returns the number of invokes that are currently in the endorsement phase
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.slots)
+}
+
+// acquire takes a slot, waiting for one to be released unless the limiter fails fast
+func (l *ConcurrencyLimiter) acquire(ctx reqContext.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if l.failFast {
+		return status.New(status.ClientStatus, status.ConcurrencyLimitExceeded.ToInt32(), "maximum number of concurrent endorsements reached", []interface{}{cap(l.slots)})
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return status.New(status.ClientStatus, status.Timeout.ToInt32(), "request timed out or been cancelled while waiting to endorse", nil)
+	}
+}
+
+// release gives back a slot taken by acquire
+func (l *ConcurrencyLimiter) release() {
+	<-l.slots
+}
+
+// acquireEndorsementSlot takes a slot from the concurrency limiter of the client (if any). The
+// returned function must be called once the proposal has been endorsed.
+func acquireEndorsementSlot(requestContext *RequestContext, clientContext *ClientContext) (func(), error) {
+	limiter := clientContext.ConcurrencyLimiter
+	if limiter == nil {
+		return func() {}, nil
+	}
+	if err := limiter.acquire(requestContext.Ctx); err != nil {
+		return nil, err
+	}
+	return limiter.release, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+func TestConcurrencyLimiterFailFast(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2, true)
+
+	assert.Nil(t, limiter.acquire(reqContext.Background()))
+	assert.Nil(t, limiter.acquire(reqContext.Background()))
+	assert.Equal(t, 2, limiter.InFlight())
+
+	err := limiter.acquire(reqContext.Background())
+	s, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", err)
+	}
+	assert.Equal(t, status.ConcurrencyLimitExceeded.ToInt32(), s.Code)
+
+	limiter.release()
+	assert.Nil(t, limiter.acquire(reqContext.Background()))
+}
+
+func TestConcurrencyLimiterBlocking(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, false)
+	assert.Nil(t, limiter.acquire(reqContext.Background()))
+
+	// Times out while waiting for the slot
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := limiter.acquire(ctx)
+	s, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", err)
+	}
+	assert.Equal(t, status.Timeout.ToInt32(), s.Code)
+
+	// Acquires the slot once it's released
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- limiter.acquire(reqContext.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	limiter.release()
+	select {
+	case err := <-acquired:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected slot to be acquired once released")
+	}
+}
+
+func TestEndorsementHandlerConcurrencyLimit(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	peers := []fab.Peer{&fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}}
+
+	handler := NewProposalProcessorHandler(NewEndorsementHandler())
+	clientContext := setupChannelClientContext(nil, nil, peers, t)
+	clientContext.ConcurrencyLimiter = NewConcurrencyLimiter(1, true)
+
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, 0, clientContext.ConcurrencyLimiter.InFlight(), "expected slot to be released after endorsement")
+
+	// No slot available
+	assert.Nil(t, clientContext.ConcurrencyLimiter.acquire(reqContext.Background()))
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.ConcurrencyLimitExceeded.ToInt32(), s.Code)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+// ConfigSequenceProvider returns the sequence of the latest channel config
+type ConfigSequenceProvider func() (uint64, error)
+
+// configSequence returns the sequence of the latest channel config
+func configSequence(clientContext *ClientContext) (uint64, error) {
+	if clientContext.ConfigSequence == nil {
+		return 0, errors.New("no channel config sequence provider")
+	}
+	sequence, err := clientContext.ConfigSequence()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to retrieve channel config sequence")
+	}
+	return sequence, nil
+}
+
+// recordConfigSequence records the sequence of the channel config in the request context if
+// Opts.CheckConfigSequence is set
+func recordConfigSequence(requestContext *RequestContext, clientContext *ClientContext) error {
+	if !requestContext.Opts.CheckConfigSequence {
+		return nil
+	}
+	sequence, err := configSequence(clientContext)
+	if err != nil {
+		return err
+	}
+	requestContext.ConfigSequence = sequence
+	return nil
+}
+
+// checkConfigSequence returns an error with status ConfigSequenceChanged if Opts.CheckConfigSequence is set
+// and the sequence of the channel config changed since it was recorded
+func checkConfigSequence(requestContext *RequestContext, clientContext *ClientContext) error {
+	if !requestContext.Opts.CheckConfigSequence {
+		return nil
+	}
+	sequence, err := configSequence(clientContext)
+	if err != nil {
+		return err
+	}
+	if sequence != requestContext.ConfigSequence {
+		return status.New(status.ClientStatus, status.ConfigSequenceChanged.ToInt32(),
+			fmt.Sprintf("channel config sequence changed from %d to %d since the endorsers were selected", requestContext.ConfigSequence, sequence),
+			[]interface{}{requestContext.ConfigSequence, sequence})
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestExecuteTxHandlerWithConfigSequenceCheck(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	executeHandler := NewExecuteHandler()
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+
+	// The config sequence doesn't change
+	var sequences []uint64
+	clientContext.ConfigSequence = func() (uint64, error) {
+		sequences = append(sequences, 3)
+		return 3, nil
+	}
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_VALID)
+
+	requestContext := prepareRequestContext(request, Opts{CheckConfigSequence: true}, t)
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []uint64{3, 3}, sequences, "expecting the sequence to be checked at selection and before commit")
+
+	// The config is updated after the endorsers are selected
+	var sequence uint64 = 3
+	clientContext.ConfigSequence = func() (uint64, error) {
+		current := sequence
+		sequence++
+		return current, nil
+	}
+	requestContext = prepareRequestContext(request, Opts{CheckConfigSequence: true}, t)
+	executeHandler.Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expecting status error")
+	assert.Equal(t, status.ConfigSequenceChanged.ToInt32(), s.Code)
+	assert.Empty(t, mockEventService.TxStatusRegCh, "expecting the transaction not to be submitted")
+
+	// The sequence isn't checked unless requested
+	clientContext.ConfigSequence = nil
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_VALID)
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+
+	requestContext = prepareRequestContext(request, Opts{CheckConfigSequence: true}, t)
+	executeHandler.Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error, "expecting error without a config sequence provider")
+}
@@ -0,0 +1,99 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+//NewConfirmationHandler returns a handler that confirms the endorsed result with Opts.ConfirmationPeer
+func NewConfirmationHandler(next ...Handler) *ConfirmationHandler {
+	return &ConfirmationHandler{next: getNext(next)}
+}
+
+//ConfirmationHandler sends a separate (confirmation) proposal for the request to Opts.ConfirmationPeer, a trusted
+//peer that is independent of the endorsers, and compares its response with the endorsed result. If the confirmation
+//fails or disagrees with the endorsed result then the invoke is aborted (with a ConfirmationMismatch status if it
+//disagrees) before the transaction is committed. The confirmation response is never committed.
+type ConfirmationHandler struct {
+	next Handler
+}
+
+//Handle confirms the endorsed result with the confirmation peer
+func (h *ConfirmationHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	if target := requestContext.Opts.ConfirmationPeer; target != nil && len(requestContext.Response.Responses) > 0 {
+		if err := confirm(requestContext, clientContext, target); err != nil {
+			requestContext.Error = err
+			return
+		}
+	}
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+// confirm sends the confirmation proposal to the target and checks that its response matches the endorsed result
+func confirm(requestContext *RequestContext, clientContext *ClientContext, target fab.Peer) error {
+	confirmationContext := &RequestContext{
+		Request: requestContext.Request,
+		Opts:    confirmationOpts(requestContext.Opts),
+		Ctx:     requestContext.Ctx,
+	}
+	responses, _, err := createAndSendTransactionProposal(confirmationContext, clientContext, peer.PeersToTxnProcessors([]fab.Peer{target}))
+	if err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("confirmation query on [%s] failed", target.URL()))
+	}
+	if len(responses) == 0 {
+		return errors.Errorf("no confirmation response from [%s]", target.URL())
+	}
+	r := responses[0]
+	if r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+		return errors.WithMessage(status.NewFromProposalResponse(r.ProposalResponse, r.Endorser), "confirmation query failed")
+	}
+
+	value, err := comparisonValue(requestContext.Opts, r)
+	if err != nil {
+		return err
+	}
+	var endorsers []interface{}
+	for _, endorsement := range requestContext.Response.Responses {
+		expected, err := comparisonValue(requestContext.Opts, endorsement)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(value, expected) {
+			endorsers = append(endorsers, endorsement.Endorser)
+		}
+	}
+	if len(endorsers) > 0 {
+		newLogFields(requestContext).withEndorser(r).warnf("confirmation disagrees with the endorsed result")
+		return status.New(status.EndorserClientStatus, status.ConfirmationMismatch.ToInt32(),
+			fmt.Sprintf("confirmation from [%s] disagrees with the endorsed result of %v", r.Endorser, endorsers), endorsers)
+	}
+	return nil
+}
+
+// confirmationOpts returns the options of the confirmation proposal, which only targets the confirmation peer
+// and isn't observed
+func confirmationOpts(opts Opts) Opts {
+	confirmation := opts
+	confirmation.Targets = []fab.Peer{opts.ConfirmationPeer}
+	confirmation.ShadowTargets = nil
+	confirmation.TransientMapOverrides = nil
+	confirmation.ProposalObserver = nil
+	return confirmation
+}
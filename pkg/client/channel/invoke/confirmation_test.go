@@ -0,0 +1,59 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestConfirmationHandler(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	trustedPeer := &fcmocks.MockPeer{MockName: "Trusted", MockURL: "http://trusted.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org2MSP", Status: 200, Payload: []byte("value")}
+
+	executeHandler := NewExecuteHandler()
+
+	// The confirmation agrees with the endorsed result
+	requestContext := prepareRequestContext(request, Opts{ConfirmationPeer: trustedPeer}, t)
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_VALID)
+
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, 1, trustedPeer.ProcessProposalCalls)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	if assert.Equal(t, 1, len(requestContext.Response.Responses)) {
+		assert.Equal(t, mockPeer1.MockURL, requestContext.Response.Responses[0].Endorser, "expecting the confirmation not to be committed")
+	}
+
+	// The confirmation disagrees so the transaction isn't committed
+	trustedPeer.Payload = []byte("other")
+	requestContext = prepareRequestContext(request, Opts{ConfirmationPeer: trustedPeer}, t)
+	executeHandler.Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.ConfirmationMismatch.ToInt32(), s.Code)
+	assert.Equal(t, []interface{}{mockPeer1.MockURL}, s.Details, "expecting the endorsers that disagree with the confirmation")
+
+	// The confirmation fails
+	trustedPeer.Status = 500
+	requestContext = prepareRequestContext(request, Opts{ConfirmationPeer: trustedPeer}, t)
+	executeHandler.Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error)
+}
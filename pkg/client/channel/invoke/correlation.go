@@ -0,0 +1,123 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// correlationIDSize is the number of random bytes in a generated correlation ID
+const correlationIDSize = 16
+
+//NewCorrelationIDHandler returns a handler that injects a correlation ID into the transient map
+func NewCorrelationIDHandler(next ...Handler) *CorrelationIDHandler {
+	return &CorrelationIDHandler{next: getNext(next)}
+}
+
+//CorrelationIDHandler injects a generated correlation ID into the transient map under Opts.CorrelationIDKey (if set)
+//and, once the request has been handled, flags the endorsers whose response doesn't echo the correlation ID since
+//they may be running stale chaincode
+type CorrelationIDHandler struct {
+	next Handler
+}
+
+//Handle injects the correlation ID and verifies that it's echoed by the endorsers
+func (h *CorrelationIDHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	id, err := injectCorrelationID(requestContext)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+
+	checkCorrelationID(requestContext, id)
+}
+
+// injectCorrelationID injects a generated correlation ID into the transient map under Opts.CorrelationIDKey and
+// returns it. An empty ID is returned if Opts.CorrelationIDKey isn't set.
+func injectCorrelationID(requestContext *RequestContext) (string, error) {
+	key := requestContext.Opts.CorrelationIDKey
+	if key == "" {
+		return "", nil
+	}
+
+	id, err := newCorrelationID()
+	if err != nil {
+		return "", err
+	}
+	injectTransientValue(requestContext, key, []byte(id))
+	newLogFields(requestContext).debugf("injected correlation ID %s into the transient map", id)
+	return id, nil
+}
+
+// checkCorrelationID records the endorsers whose response doesn't echo the given correlation ID (if any)
+// in Response.StaleEndorsers
+func checkCorrelationID(requestContext *RequestContext, id string) {
+	if id == "" {
+		return
+	}
+	requestContext.Response.StaleEndorsers = staleEndorsers(id, requestContext.Response.Responses, requestContext.Response.Dissenters)
+	for _, endorser := range requestContext.Response.StaleEndorsers {
+		newLogFields(requestContext).with("endorser", endorser).warnf("endorser did not echo correlation ID %s - it may be running stale chaincode", id)
+	}
+}
+
+// newCorrelationID returns a random correlation ID
+func newCorrelationID() (string, error) {
+	b := make([]byte, correlationIDSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generating correlation ID failed")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// injectTransientValue sets the given key in the transient map of the request and in each of the
+// transient map overrides
+func injectTransientValue(requestContext *RequestContext, key string, value []byte) {
+	requestContext.Request.TransientMap = withTransientValue(requestContext.Request.TransientMap, key, value)
+	if overrides := requestContext.Opts.TransientMapOverrides; overrides != nil {
+		requestContext.Opts.TransientMapOverrides = make(map[string]map[string][]byte, len(overrides))
+		for k, transientMap := range overrides {
+			requestContext.Opts.TransientMapOverrides[k] = withTransientValue(transientMap, key, value)
+		}
+	}
+}
+
+// withTransientValue returns a copy of the transient map that includes the given key and value.
+// The transient map passed in is not modified.
+func withTransientValue(transientMap map[string][]byte, key string, value []byte) map[string][]byte {
+	result := make(map[string][]byte, len(transientMap)+1)
+	for k, v := range transientMap {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
+
+// staleEndorsers returns the endorsers whose response payload or message doesn't include the correlation ID
+func staleEndorsers(id string, responses ...[]*fab.TransactionProposalResponse) []string {
+	var stale []string
+	for _, resps := range responses {
+		for _, r := range resps {
+			response := r.ProposalResponse.GetResponse()
+			if !bytes.Contains(response.GetPayload(), []byte(id)) && !bytes.Contains([]byte(response.GetMessage()), []byte(id)) {
+				stale = append(stale, r.Endorser)
+			}
+		}
+	}
+	return stale
+}
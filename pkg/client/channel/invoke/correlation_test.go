@@ -0,0 +1,57 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// echoingEndorsers is a handler that returns an endorsement from each endorser, which echoes the
+// transient map value with the given key unless the endorser is stale
+type echoingEndorsers struct {
+	key   string
+	stale map[string]bool
+}
+
+func (h *echoingEndorsers) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	for _, endorser := range []string{"peer1", "peer2"} {
+		payload := []byte("value")
+		if !h.stale[endorser] {
+			payload = append(payload, requestContext.Request.TransientMap[h.key]...)
+		}
+		requestContext.Response.Responses = append(requestContext.Response.Responses, &fab.TransactionProposalResponse{
+			Endorser:         endorser,
+			ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{Status: 200, Payload: payload}},
+		})
+	}
+}
+
+func TestCorrelationIDHandler(t *testing.T) {
+	transientMap := map[string][]byte{"key": []byte("value")}
+	request := Request{ChaincodeID: "test", Fcn: "invoke", TransientMap: transientMap}
+	endorsers := &echoingEndorsers{key: "correlationID", stale: map[string]bool{"peer2": true}}
+	handler := NewCorrelationIDHandler(endorsers)
+
+	// Not enabled
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Equal(t, request, requestContext.Request, "request should not be modified unless the correlation ID is enabled")
+	assert.Empty(t, requestContext.Response.StaleEndorsers)
+
+	requestContext = prepareRequestContext(request, Opts{CorrelationIDKey: "correlationID"}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Len(t, requestContext.Request.TransientMap["correlationID"], 2*correlationIDSize)
+	assert.Equal(t, "value", string(requestContext.Request.TransientMap["key"]))
+	assert.Len(t, transientMap, 1, "caller's transient map should not be modified")
+	assert.Equal(t, []string{"peer2"}, requestContext.Response.StaleEndorsers)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+// checkRevocation returns an error with status EndorserRevoked if the identity of any of the endorsers
+// is revoked by one of the CRLs of the provider
+func checkRevocation(provider CRLProvider, responses []*fab.TransactionProposalResponse) error {
+	crls, err := provider.CRLs()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get CRLs")
+	}
+
+	for _, r := range responses {
+		cert, err := endorserCertificate(r)
+		if err != nil {
+			return err
+		}
+		for _, crl := range crls {
+			if isRevoked(cert, crl) {
+				return status.New(status.EndorserClientStatus, status.EndorserRevoked.ToInt32(),
+					"endorser identity is revoked", []interface{}{r.Endorser, cert.SerialNumber.String()})
+			}
+		}
+	}
+
+	return nil
+}
+
+// endorserCertificate returns the certificate of the identity that signed the endorsement in the given response
+func endorserCertificate(r *fab.TransactionProposalResponse) (*x509.Certificate, error) {
+	sID, err := endorserIdentity(r)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(sID.IdBytes)
+	if block == nil {
+		return nil, errors.Errorf("endorser identity from [%s] is not a PEM encoded certificate", r.Endorser)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing of endorser certificate from [%s] failed", r.Endorser)
+	}
+	return cert, nil
+}
+
+// isRevoked returns true if the certificate was issued by the issuer of the CRL and its serial number is listed in it
+func isRevoked(cert *x509.Certificate, crl *pkix.CertificateList) bool {
+	var issuer pkix.Name
+	issuer.FillFromRDNSequence(&crl.TBSCertList.Issuer)
+	if issuer.String() != cert.Issuer.String() {
+		return false
+	}
+	for _, revoked := range crl.TBSCertList.RevokedCertificates {
+		if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return true
+		}
+	}
+	return false
+}
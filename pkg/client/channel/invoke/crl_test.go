@@ -0,0 +1,102 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	pb_msp "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+type mockCRLProvider struct {
+	crls []*pkix.CertificateList
+}
+
+func (p *mockCRLProvider) CRLs() ([]*pkix.CertificateList, error) {
+	return p.crls, nil
+}
+
+func TestSignatureValidationHandlerRevokedEndorser(t *testing.T) {
+	caName := pkix.Name{CommonName: "ca.org1"}
+	responses := []*fab.TransactionProposalResponse{
+		endorsementFromCert("peer1", newEndorserCertPem(caName, 1, t), t),
+		endorsementFromCert("peer2", newEndorserCertPem(caName, 2, t), t),
+	}
+
+	otherCACRL := newCRL(pkix.Name{CommonName: "ca.org2"}, 2)
+	for _, opts := range []Opts{{}, {CRLProvider: &mockCRLProvider{crls: []*pkix.CertificateList{otherCACRL}}}} {
+		requestContext := prepareRequestContext(Request{ChaincodeID: "test", Fcn: "invoke"}, opts, t)
+		requestContext.Response.Responses = responses
+		NewSignatureValidationHandler().Handle(requestContext, setupContextForSignatureValidation(nil, nil, nil, t))
+		assert.Nil(t, requestContext.Error)
+	}
+
+	opts := Opts{CRLProvider: &mockCRLProvider{crls: []*pkix.CertificateList{otherCACRL, newCRL(caName, 2)}}}
+	requestContext := prepareRequestContext(Request{ChaincodeID: "test", Fcn: "invoke"}, opts, t)
+	requestContext.Response.Responses = responses
+	NewSignatureValidationHandler().Handle(requestContext, setupContextForSignatureValidation(nil, nil, nil, t))
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.EndorserRevoked.ToInt32(), s.Code)
+	assert.Equal(t, []interface{}{"peer2", "2"}, s.Details)
+}
+
+func endorsementFromCert(endorser string, certPem []byte, t *testing.T) *fab.TransactionProposalResponse {
+	identity, err := proto.Marshal(&pb_msp.SerializedIdentity{Mspid: "Org1MSP", IdBytes: certPem})
+	if err != nil {
+		t.Fatalf("Failed to marshal serialized identity: %s", err)
+	}
+	return &fab.TransactionProposalResponse{
+		Endorser: endorser,
+		ProposalResponse: &pb.ProposalResponse{
+			Response:    &pb.Response{Status: 200},
+			Endorsement: &pb.Endorsement{Endorser: identity},
+		},
+	}
+}
+
+func newEndorserCertPem(issuer pkix.Name, serial int64, t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      issuer,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %s", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newCRL(issuer pkix.Name, revokedSerials ...int64) *pkix.CertificateList {
+	crl := &pkix.CertificateList{}
+	crl.TBSCertList.Issuer = issuer.ToRDNSequence()
+	for _, serial := range revokedSerials {
+		crl.TBSCertList.RevokedCertificates = append(crl.TBSCertList.RevokedCertificates,
+			pkix.RevokedCertificate{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+	}
+	return crl
+}
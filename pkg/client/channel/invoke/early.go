@@ -0,0 +1,125 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+	reqContext "context"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+// EndorsementSatisfier returns true if the given matching endorsements satisfy the endorsement policy
+type EndorsementSatisfier func(responses []*fab.TransactionProposalResponse) bool
+
+// OrgsSatisfier returns an endorsement satisfier that is satisfied once endorsements have been received
+// from at least minOrgs distinct orgs, including each of the required orgs
+func OrgsSatisfier(minOrgs int, requiredOrgs ...string) EndorsementSatisfier {
+	return func(responses []*fab.TransactionProposalResponse) bool {
+		mspIDs, err := endorsingMSPIDs(responses)
+		if err != nil || len(mspIDs) < minOrgs {
+			return false
+		}
+		for _, mspID := range requiredOrgs {
+			if !mspIDs[mspID] {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// earlySatisfaction collects the successful endorsements as they arrive and, once a group of matching
+// endorsements satisfies the endorsement policy, cancels the outstanding proposal calls
+type earlySatisfaction struct {
+	opts      Opts
+	fields    logFields
+	satisfier EndorsementSatisfier
+
+	mutex     sync.Mutex
+	groups    []responseGroup
+	satisfied []*fab.TransactionProposalResponse
+	done      chan struct{}
+}
+
+func newEarlySatisfaction(requestContext *RequestContext, satisfier EndorsementSatisfier) *earlySatisfaction {
+	return &earlySatisfaction{opts: requestContext.Opts, fields: newLogFields(requestContext), satisfier: satisfier, done: make(chan struct{})}
+}
+
+// wrap wraps the processors so that their calls are cancelled once the policy is satisfied
+func (s *earlySatisfaction) wrap(processors []fab.ProposalProcessor) []fab.ProposalProcessor {
+	wrapped := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		wrapped[i] = &satisfyingProcessor{target: p, early: s}
+	}
+	return wrapped
+}
+
+// responses returns the endorsements that satisfied the policy (nil if the policy wasn't satisfied)
+func (s *earlySatisfaction) responses() []*fab.TransactionProposalResponse {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.satisfied
+}
+
+func (s *earlySatisfaction) add(r *fab.TransactionProposalResponse) {
+	if r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+		return
+	}
+	value, err := comparisonValue(s.opts, r)
+	if err != nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.satisfied != nil {
+		return
+	}
+	s.groups = groupByValue(s.groups, value, r)
+	for _, group := range s.groups {
+		if bytes.Equal(group.value, value) && s.satisfier(group.responses) {
+			s.fields.debugf("endorsement policy satisfied by %d endorsement(s) - cancelling outstanding proposals", len(group.responses))
+			s.satisfied = group.responses
+			close(s.done)
+			return
+		}
+	}
+}
+
+// satisfyingProcessor is a proposal processor that records the endorsement of the target and
+// cancels the call to the target once the endorsement policy is satisfied
+type satisfyingProcessor struct {
+	target fab.ProposalProcessor
+	early  *earlySatisfaction
+}
+
+// ProcessTransactionProposal sends the proposal to the target unless the policy is satisfied first
+func (p *satisfyingProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	ctx, cancel := reqContext.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.early.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	resp, err := p.target.ProcessTransactionProposal(ctx, request)
+	if err == nil {
+		p.early.add(resp)
+	}
+	return resp, err
+}
+
+func (p *satisfyingProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
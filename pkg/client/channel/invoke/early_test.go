@@ -0,0 +1,74 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+// slowPeer is a peer that doesn't respond until the context is done
+type slowPeer struct {
+	*fcmocks.MockPeer
+	cancelled chan struct{}
+}
+
+func (p *slowPeer) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	<-ctx.Done()
+	close(p.cancelled)
+	return nil, ctx.Err()
+}
+
+func TestEndorsementHandlerWithEarlySatisfaction(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value"), Endorser: serializedIdentity("Org1MSP", t)}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org2MSP", Status: 200, Payload: []byte("value"), Endorser: serializedIdentity("Org2MSP", t)}
+	peer3 := &slowPeer{MockPeer: &fcmocks.MockPeer{MockName: "Peer3", MockURL: "http://peer3.com", MockMSP: "Org3MSP"}, cancelled: make(chan struct{})}
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peer1, peer2, peer3}, EndorsementSatisfier: OrgsSatisfier(2)}, t)
+
+	start := time.Now()
+	NewEndorsementHandler().Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.True(t, time.Since(start) < 5*time.Second, "expecting the slow peer to be cancelled")
+	assert.Equal(t, 2, len(requestContext.Response.Responses))
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+
+	select {
+	case <-peer3.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("expecting the proposal to the slow peer to be cancelled")
+	}
+}
+
+func TestOrgsSatisfier(t *testing.T) {
+	r1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", Status: 200, Endorser: serializedIdentity("Org1MSP", t)}
+	r2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", Status: 200, Endorser: serializedIdentity("Org2MSP", t)}
+
+	resp1, err := r1.ProcessTransactionProposal(reqContext.Background(), fab.ProcessProposalRequest{})
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	resp2, err := r2.ProcessTransactionProposal(reqContext.Background(), fab.ProcessProposalRequest{})
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+
+	assert.True(t, OrgsSatisfier(1)([]*fab.TransactionProposalResponse{resp1}))
+	assert.False(t, OrgsSatisfier(2)([]*fab.TransactionProposalResponse{resp1}))
+	assert.False(t, OrgsSatisfier(1, "Org2MSP")([]*fab.TransactionProposalResponse{resp1}))
+	assert.True(t, OrgsSatisfier(1, "Org2MSP")([]*fab.TransactionProposalResponse{resp1, resp2}))
+}
@@ -0,0 +1,85 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// ResponseEncodingKey is the transient map key under which the preferred encoding of the response payload
+// is requested from the chaincode. The chaincode reports the encoding that it used with a
+// "responseEncoding=<encoding>" token in the message of its response.
+const ResponseEncodingKey = "responseEncoding"
+
+const (
+	// EncodingProtobuf requests a protobuf encoded response payload
+	EncodingProtobuf = "protobuf"
+	// EncodingJSON requests a JSON encoded response payload
+	EncodingJSON = "json"
+)
+
+//NewResponseEncodingHandler returns a handler that negotiates the encoding of the response payload
+func NewResponseEncodingHandler(next ...Handler) *ResponseEncodingHandler {
+	return &ResponseEncodingHandler{next: getNext(next)}
+}
+
+//ResponseEncodingHandler requests the encoding in Opts.ResponseEncoding (if set) from the chaincode through the
+//transient map and, once the request has been handled, records the encoding that the chaincode reported in
+//Response.ResponseEncoding
+type ResponseEncodingHandler struct {
+	next Handler
+}
+
+//Handle requests the response encoding and records the encoding used by the chaincode
+func (h *ResponseEncodingHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	injectResponseEncoding(requestContext)
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+
+	recordResponseEncoding(requestContext)
+}
+
+// injectResponseEncoding requests the encoding in Opts.ResponseEncoding (if set) through the transient map
+func injectResponseEncoding(requestContext *RequestContext) {
+	if encoding := requestContext.Opts.ResponseEncoding; encoding != "" {
+		injectTransientValue(requestContext, ResponseEncodingKey, []byte(encoding))
+	}
+}
+
+// recordResponseEncoding records the encoding that the chaincode reported in Response.ResponseEncoding
+// if an encoding was requested
+func recordResponseEncoding(requestContext *RequestContext) {
+	encoding := requestContext.Opts.ResponseEncoding
+	if encoding == "" {
+		return
+	}
+	for _, r := range requestContext.Response.Responses {
+		if used, ok := responseEncoding(r); ok {
+			if used != encoding {
+				newLogFields(requestContext).withEndorser(r).debugf("chaincode responded with encoding %s instead of %s", used, encoding)
+			}
+			requestContext.Response.ResponseEncoding = used
+			return
+		}
+	}
+}
+
+// responseEncoding returns the encoding reported in the message of the given response
+func responseEncoding(r *fab.TransactionProposalResponse) (string, bool) {
+	prefix := ResponseEncodingKey + "="
+	for _, token := range strings.Fields(r.ProposalResponse.GetResponse().GetMessage()) {
+		if strings.HasPrefix(token, prefix) {
+			return strings.TrimPrefix(token, prefix), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,51 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// negotiatingEndorser is a handler that returns an endorsement which reports the encoding requested
+// in the transient map
+type negotiatingEndorser struct{}
+
+func (h *negotiatingEndorser) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	message := "correlated"
+	if encoding, ok := requestContext.Request.TransientMap[ResponseEncodingKey]; ok {
+		message += " " + ResponseEncodingKey + "=" + string(encoding)
+	}
+	requestContext.Response.Responses = append(requestContext.Response.Responses, &fab.TransactionProposalResponse{
+		Endorser:         "peer1",
+		ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{Status: 200, Message: message, Payload: []byte("value")}},
+	})
+}
+
+func TestResponseEncodingHandler(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", TransientMap: map[string][]byte{"key": []byte("value")}}
+	handler := NewResponseEncodingHandler(&negotiatingEndorser{})
+
+	// Not enabled
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Equal(t, request, requestContext.Request, "request should not be modified unless an encoding is requested")
+	assert.Empty(t, requestContext.Response.ResponseEncoding)
+
+	overrides := map[string]map[string][]byte{"Org2MSP": {"key": []byte("other")}}
+	requestContext = prepareRequestContext(request, Opts{ResponseEncoding: EncodingJSON, TransientMapOverrides: overrides}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, EncodingJSON, string(requestContext.Request.TransientMap[ResponseEncodingKey]))
+	assert.Equal(t, EncodingJSON, string(requestContext.Opts.TransientMapOverrides["Org2MSP"][ResponseEncodingKey]))
+	assert.Len(t, request.TransientMap, 1, "caller's transient map should not be modified")
+	assert.Equal(t, EncodingJSON, requestContext.Response.ResponseEncoding)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// endorsementContextProcessor is a proposal processor that sends the proposal with a context that is also
+// bound by the endorsement context of the request options
+type endorsementContextProcessor struct {
+	target fab.ProposalProcessor
+	ctx    reqContext.Context
+}
+
+// withEndorsementContext wraps the processors so that the deadline, cancellation and values of the given
+// endorsement context apply to the proposals
+func withEndorsementContext(processors []fab.ProposalProcessor, ctx reqContext.Context) []fab.ProposalProcessor {
+	wrapped := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		wrapped[i] = &endorsementContextProcessor{target: p, ctx: ctx}
+	}
+	return wrapped
+}
+
+// ProcessTransactionProposal sends the proposal to the target with a context that is done as soon as either
+// the request context or the endorsement context is done
+func (p *endorsementContextProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	merged, cancel := mergeContexts(ctx, p.ctx)
+	defer cancel()
+
+	return p.target.ProcessTransactionProposal(merged, request)
+}
+
+func (p *endorsementContextProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
+
+// valuesContext is a context that looks up the values that aren't found in the embedded context in another context
+type valuesContext struct {
+	reqContext.Context
+	values reqContext.Context
+}
+
+// Value returns the value of the embedded context for the key or, if there is none, the value of the other context
+func (c *valuesContext) Value(key interface{}) interface{} {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.values.Value(key)
+}
+
+// mergeContexts returns a child of ctx that also has the deadline (if earlier), cancellation and values of other
+func mergeContexts(ctx, other reqContext.Context) (reqContext.Context, reqContext.CancelFunc) {
+	merged, cancel := reqContext.WithCancel(ctx)
+	if deadline, ok := other.Deadline(); ok {
+		merged, cancel = withDeadline(merged, cancel, deadline)
+	}
+
+	if other.Err() != nil {
+		cancel()
+	} else if other.Done() != nil {
+		go func() {
+			select {
+			case <-other.Done():
+				cancel()
+			case <-merged.Done():
+			}
+		}()
+	}
+	return &valuesContext{Context: merged, values: other}, cancel
+}
+
+// withDeadline adds the deadline to the context; the returned function cancels both contexts
+func withDeadline(ctx reqContext.Context, cancel reqContext.CancelFunc, deadline time.Time) (reqContext.Context, reqContext.CancelFunc) {
+	ctx, cancelDeadline := reqContext.WithDeadline(ctx, deadline)
+	return ctx, func() {
+		cancelDeadline()
+		cancel()
+	}
+}
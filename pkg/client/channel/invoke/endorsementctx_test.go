@@ -0,0 +1,69 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// contextPeer is a peer that fails the proposal if its context is done and records the deadline of the context
+type contextPeer struct {
+	*fcmocks.MockPeer
+	deadline time.Time
+}
+
+func (p *contextPeer) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.deadline, _ = ctx.Deadline()
+	return p.MockPeer.ProcessTransactionProposal(ctx, request)
+}
+
+func TestEndorsementContextExcludesCommit(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	peer1 := &contextPeer{MockPeer: &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}}
+
+	endorsementCtx, cancelEndorsement := reqContext.WithTimeout(reqContext.Background(), time.Second)
+	defer cancelEndorsement()
+
+	requestContext := prepareRequestContext(request, Opts{EndorsementContext: endorsementCtx}, t)
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{peer1}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+
+	// The endorsement context is cancelled once the transaction is submitted, which doesn't affect the commit wait
+	go func() {
+		select {
+		case txStatusReg := <-mockEventService.TxStatusRegCh:
+			cancelEndorsement()
+			time.Sleep(10 * time.Millisecond)
+			txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+		case <-time.After(5 * time.Second):
+		}
+	}()
+
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error, "expecting the commit not to be bound by the endorsement context")
+	assert.Equal(t, pb.TxValidationCode_VALID, requestContext.Response.TxValidationCode)
+	expected, _ := endorsementCtx.Deadline()
+	assert.Equal(t, expected, peer1.deadline, "expecting the endorsement to be bound by the deadline of the endorsement context")
+
+	// A cancelled endorsement context fails the endorsement
+	requestContext = prepareRequestContext(request, Opts{EndorsementContext: endorsementCtx}, t)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error, "expecting the endorsement to fail with a cancelled endorsement context")
+	assert.Empty(t, requestContext.Response.Responses)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// EndorsementSignature is the portable form of an endorsement which allows the signature to be
+// verified without parsing protobuf: the signature is over the concatenation of the payload and the
+// serialized endorser identity. Byte fields are base64 encoded in JSON.
+type EndorsementSignature struct {
+	Endorser    string `json:"endorser"`
+	MSPID       string `json:"mspId"`
+	Certificate []byte `json:"certificate"`
+	Identity    []byte `json:"identity"`
+	Payload     []byte `json:"payload"`
+	Signature   []byte `json:"signature"`
+}
+
+// NewEndorsementSignatures returns the endorsement signatures of the given responses, in the same order
+func NewEndorsementSignatures(responses []*fab.TransactionProposalResponse) ([]EndorsementSignature, error) {
+	signatures := make([]EndorsementSignature, len(responses))
+	for i, r := range responses {
+		sID, err := endorserIdentity(r)
+		if err != nil {
+			return nil, err
+		}
+		endorsement := r.ProposalResponse.GetEndorsement()
+		signatures[i] = EndorsementSignature{
+			Endorser:    r.Endorser,
+			MSPID:       sID.Mspid,
+			Certificate: sID.IdBytes,
+			Identity:    endorsement.Endorser,
+			Payload:     r.ProposalResponse.Payload,
+			Signature:   endorsement.Signature,
+		}
+	}
+	return signatures, nil
+}
+
+// MarshalEndorsementSignatures serializes the endorsement signatures of the given responses to JSON
+func MarshalEndorsementSignatures(responses []*fab.TransactionProposalResponse) ([]byte, error) {
+	signatures, err := NewEndorsementSignatures(responses)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(signatures)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of endorsement signatures failed")
+	}
+	return data, nil
+}
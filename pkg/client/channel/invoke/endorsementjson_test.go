@@ -0,0 +1,55 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestMarshalEndorsementSignatures(t *testing.T) {
+	identity := serializedIdentity("Org1MSP", t)
+	responses := []*fab.TransactionProposalResponse{{
+		Endorser: "peer1",
+		ProposalResponse: &pb.ProposalResponse{
+			Payload:     []byte("payload"),
+			Endorsement: &pb.Endorsement{Endorser: identity, Signature: []byte("signature")},
+		},
+	}}
+
+	data, err := MarshalEndorsementSignatures(responses)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+
+	var decoded []map[string]string
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	expected := map[string]string{
+		"endorser":    "peer1",
+		"mspId":       "Org1MSP",
+		"certificate": "Y2VydA==",
+		"identity":    base64.StdEncoding.EncodeToString(identity),
+		"payload":     "cGF5bG9hZA==",
+		"signature":   "c2lnbmF0dXJl",
+	}
+	if assert.Len(t, decoded, 1) {
+		assert.Equal(t, expected, decoded[0])
+	}
+
+	// Missing endorsement
+	responses[0].ProposalResponse.Endorsement = nil
+	_, err = MarshalEndorsementSignatures(responses)
+	assert.NotNil(t, err)
+}
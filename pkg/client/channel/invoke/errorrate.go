@@ -0,0 +1,148 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+)
+
+const (
+	// DefaultErrorRateWindow is the window over which the error rate of a peer is computed if none is specified
+	DefaultErrorRateWindow = time.Minute
+
+	// minErrorRateWeight is the selection weight of a peer whose recent proposals all failed. It isn't zero
+	// so that such a peer is still selected when no alternative is available.
+	minErrorRateWeight = 0.05
+
+	// maxErrorRateSamples is the maximum number of outcomes that are tracked per peer within the window
+	maxErrorRateSamples = 1000
+)
+
+// endorsementOutcome is the outcome of sending a proposal to a peer
+type endorsementOutcome struct {
+	time   time.Time
+	failed bool
+}
+
+// ErrorRateTracker tracks the outcomes of the proposals sent to the peers within a sliding window and derives
+// a selection weight for each peer from its error rate, so that the selection favours the peers that haven't
+// failed recently. As the errors of a peer age out of the window its weight recovers.
+type ErrorRateTracker struct {
+	window time.Duration
+
+	mutex    sync.Mutex
+	outcomes map[string][]endorsementOutcome
+}
+
+// NewErrorRateTracker returns a new error rate tracker with the given window (DefaultErrorRateWindow if not positive)
+func NewErrorRateTracker(window time.Duration) *ErrorRateTracker {
+	if window <= 0 {
+		window = DefaultErrorRateWindow
+	}
+	return &ErrorRateTracker{
+		window:   window,
+		outcomes: make(map[string][]endorsementOutcome),
+	}
+}
+
+// Record records the outcome of a proposal sent to the peer with the given URL
+func (t *ErrorRateTracker) Record(peerURL string, failed bool) {
+	address := endpoint.ToAddress(peerURL)
+	now := time.Now()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	outcomes := append(t.prune(address, now), endorsementOutcome{time: now, failed: failed})
+	if len(outcomes) > maxErrorRateSamples {
+		outcomes = outcomes[len(outcomes)-maxErrorRateSamples:]
+	}
+	t.outcomes[address] = outcomes
+}
+
+// ErrorRate returns the fraction of the proposals sent to the peer with the given URL within the window
+// that failed (0 if no proposals were sent)
+func (t *ErrorRateTracker) ErrorRate(peerURL string) float64 {
+	address := endpoint.ToAddress(peerURL)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	outcomes := t.prune(address, time.Now())
+	if len(outcomes) == 0 {
+		return 0
+	}
+	failed := 0
+	for _, o := range outcomes {
+		if o.failed {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(outcomes))
+}
+
+// Weight returns the selection weight of the given peer: one minus its error rate, but never less than
+// minErrorRateWeight
+func (t *ErrorRateTracker) Weight(peer fab.Peer) float64 {
+	weight := 1 - t.ErrorRate(peer.URL())
+	if weight < minErrorRateWeight {
+		return minErrorRateWeight
+	}
+	return weight
+}
+
+// prune removes the outcomes of the given peer that are older than the window and returns the remaining outcomes.
+// The caller must hold the lock.
+func (t *ErrorRateTracker) prune(address string, now time.Time) []endorsementOutcome {
+	outcomes := t.outcomes[address]
+	i := 0
+	for i < len(outcomes) && now.Sub(outcomes[i].time) > t.window {
+		i++
+	}
+	if i == len(outcomes) {
+		delete(t.outcomes, address)
+		return nil
+	}
+	outcomes = outcomes[i:]
+	t.outcomes[address] = outcomes
+	return outcomes
+}
+
+// errorRateProcessor is a proposal processor that records the outcome of each proposal sent to the target
+type errorRateProcessor struct {
+	target   fab.ProposalProcessor
+	endorser string
+	tracker  *ErrorRateTracker
+}
+
+// withErrorRateTracking wraps the processors of the given targets so that their error rates are tracked
+func withErrorRateTracking(processors []fab.ProposalProcessor, targets []fab.Peer, tracker *ErrorRateTracker) []fab.ProposalProcessor {
+	tracked := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		tracked[i] = &errorRateProcessor{target: p, endorser: targets[i].URL(), tracker: tracker}
+	}
+	return tracked
+}
+
+// ProcessTransactionProposal sends the proposal to the target and records whether it failed. A proposal that
+// is abandoned because the request context is done isn't held against the target.
+func (p *errorRateProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	resp, err := p.target.ProcessTransactionProposal(ctx, request)
+	if err == nil || ctx.Err() == nil {
+		p.tracker.Record(p.endorser, err != nil)
+	}
+	return resp, err
+}
+
+func (p *errorRateProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
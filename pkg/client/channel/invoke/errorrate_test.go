@@ -0,0 +1,71 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestErrorRateTracker(t *testing.T) {
+	tracker := NewErrorRateTracker(50 * time.Millisecond)
+	peer1 := fcmocks.NewMockPeer("p1", "grpcs://peer1:7051")
+	peer2 := fcmocks.NewMockPeer("p2", "grpcs://peer2:7051")
+
+	assert.Equal(t, 1.0, tracker.Weight(peer1), "expecting full weight for a peer without outcomes")
+
+	tracker.Record("peer1:7051", true)
+	tracker.Record("peer1:7051", false)
+	tracker.Record(peer2.URL(), true)
+	assert.Equal(t, 0.5, tracker.ErrorRate(peer1.URL()))
+	assert.Equal(t, 0.5, tracker.Weight(peer1))
+	assert.Equal(t, 1.0, tracker.ErrorRate(peer2.URL()))
+	assert.Equal(t, minErrorRateWeight, tracker.Weight(peer2), "expecting the minimum weight for a peer whose proposals all failed")
+
+	// The peers recover as their errors age out of the window
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1.0, tracker.Weight(peer1))
+	assert.Equal(t, 1.0, tracker.Weight(peer2))
+}
+
+// failingProcessor fails every proposal
+type failingProcessor struct{}
+
+func (p *failingProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestWithErrorRateTracking(t *testing.T) {
+	tracker := NewErrorRateTracker(time.Minute)
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+
+	processors := withErrorRateTracking([]fab.ProposalProcessor{&failingProcessor{}, &deadlineRecorder{}}, []fab.Peer{peer1, peer2}, tracker)
+	_, err := processors[0].ProcessTransactionProposal(reqContext.Background(), fab.ProcessProposalRequest{})
+	assert.NotNil(t, err)
+	_, err = processors[1].ProcessTransactionProposal(reqContext.Background(), fab.ProcessProposalRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, tracker.ErrorRate(peer1.URL()))
+	assert.Equal(t, 0.0, tracker.ErrorRate(peer2.URL()))
+
+	// A proposal abandoned because the request is done isn't held against the peer
+	ctx, cancel := reqContext.WithCancel(reqContext.Background())
+	cancel()
+	_, err = processors[0].ProcessTransactionProposal(ctx, fab.ProcessProposalRequest{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1.0, tracker.ErrorRate(peer1.URL()))
+
+	tracker.Record(peer1.URL(), false)
+	assert.Equal(t, 0.5, tracker.ErrorRate(peer1.URL()))
+}
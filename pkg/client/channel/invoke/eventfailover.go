@@ -0,0 +1,130 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// txStatusFailover delivers the TxStatus events of the registration on the primary event service and, each time
+// the failover window elapses, the TxStatus events of the next of the alternate event services. The alternates are
+// registered up front, before the transaction is submitted, so that a block that an alternate delivers before the
+// failover isn't missed; its TxStatus event is held until the failover releases the alternate.
+type txStatusFailover struct {
+	txID        string
+	fields      logFields
+	alternates  []chan struct{}
+	window      time.Duration
+	notifier    chan *fab.TxStatusEvent
+	done        chan struct{}
+	unregisters []func()
+}
+
+// registerTxStatusEventForCommit registers for the TxStatus event on the given event service and the additional event
+// services of the client context. If Opts.EventFailoverWindow is set then the additional event services are the
+// alternates of the returned failover. Otherwise, the TxStatus events of all of the event services are delivered at
+// once and the returned failover is nil.
+func registerTxStatusEventForCommit(requestContext *RequestContext, clientContext *ClientContext, eventService fab.EventService, txID string, fields logFields) (<-chan *fab.TxStatusEvent, int, *txStatusFailover, func(), error) {
+	if requestContext.Opts.EventFailoverWindow <= 0 {
+		statusNotifier, sources, unregister, err := registerTxStatusEventWithTimeout(requestContext, clientContext, eventService, txID, fields)
+		return statusNotifier, sources, nil, unregister, err
+	}
+	f, err := registerTxStatusEventWithFailover(requestContext, clientContext, eventService, txID, fields)
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
+	return f.notifier, f.sources(), f, f.unregister, nil
+}
+
+// registerTxStatusEventWithFailover registers for the TxStatus event on the given event service and on each of the
+// additional event services of the client context, which are the alternates that the failover releases in turn.
+func registerTxStatusEventWithFailover(requestContext *RequestContext, clientContext *ClientContext, eventService fab.EventService, txID string, fields logFields) (*txStatusFailover, error) {
+	primary := *clientContext
+	primary.AdditionalEventServices = nil
+	eventch, _, unregister, err := registerTxStatusEventWithTimeout(requestContext, &primary, eventService, txID, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &txStatusFailover{
+		txID:     txID,
+		fields:   fields,
+		window:   requestContext.Opts.EventFailoverWindow,
+		notifier: make(chan *fab.TxStatusEvent, 1+len(clientContext.AdditionalEventServices)),
+		done:     make(chan struct{}),
+	}
+	f.add(eventch, unregister, nil)
+	for _, alternate := range clientContext.AdditionalEventServices {
+		eventch, unregister, err := registerTxStatus(clientContext, alternate, txID, fields)
+		if err != nil {
+			fields.warnf("error registering for TxStatus event on alternate event service: %s", err)
+			continue
+		}
+		released := make(chan struct{})
+		f.alternates = append(f.alternates, released)
+		f.add(eventch, unregister, released)
+	}
+	return f, nil
+}
+
+// sources returns the number of event services on which the TxStatus event is registered, including the primary
+func (f *txStatusFailover) sources() int {
+	return len(f.unregisters)
+}
+
+// next returns a channel on which the time is delivered once the failover window elapses,
+// or nil if there is no failover or no alternate event services are left
+func (f *txStatusFailover) next() <-chan time.Time {
+	if f == nil || len(f.alternates) == 0 {
+		return nil
+	}
+	return time.After(f.window)
+}
+
+// failover releases the TxStatus event of the next alternate event service, including an event that the alternate
+// delivered before the failover. The earlier registrations are kept, so the event is still delivered if the stalled
+// event service recovers.
+func (f *txStatusFailover) failover() {
+	released := f.alternates[0]
+	f.alternates = f.alternates[1:]
+
+	f.fields.infof("no TxStatus event received within %s - failing over to an alternate event service", f.window)
+	close(released)
+}
+
+// add delivers the TxStatus event of the given registration on the notifier. If released is not nil then
+// the event is held until released is closed.
+func (f *txStatusFailover) add(eventch <-chan *fab.TxStatusEvent, unregister func(), released <-chan struct{}) {
+	f.unregisters = append(f.unregisters, unregister)
+	go func() {
+		select {
+		case txStatus, ok := <-eventch:
+			if !ok {
+				return
+			}
+			if released != nil {
+				select {
+				case <-released:
+				case <-f.done:
+					return
+				}
+			}
+			f.notifier <- txStatus
+		case <-f.done:
+		}
+	}()
+}
+
+// unregister removes the registrations from all of the event services
+func (f *txStatusFailover) unregister() {
+	close(f.done)
+	for _, unregister := range f.unregisters {
+		unregister()
+	}
+}
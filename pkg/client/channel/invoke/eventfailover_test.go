@@ -0,0 +1,88 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestExecuteTxHandlerWithEventFailover(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	executeHandler := NewExecuteHandler()
+	window := 50 * time.Millisecond
+
+	// The event peer of the channel stalls, so the handler fails over to the alternate event service after the window
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	stalledEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = stalledEventService
+	alternateEventService := fcmocks.NewMockEventService()
+	unusedEventService := fcmocks.NewMockEventService()
+	clientContext.AdditionalEventServices = []fab.EventService{alternateEventService, unusedEventService}
+
+	go func() {
+		txStatusReg := <-alternateEventService.TxStatusRegCh
+		time.Sleep(2 * window)
+		txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+	}()
+
+	requestContext := prepareRequestContext(request, Opts{EventFailoverWindow: window}, t)
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, pb.TxValidationCode_VALID, requestContext.Response.TxValidationCode)
+	assert.Equal(t, 1, len(stalledEventService.TxStatusRegCh), "expecting a registration on the event service of the channel")
+	assert.Equal(t, 1, len(unusedEventService.TxStatusRegCh), "expecting a registration on each alternate event service")
+
+	// The event peer of the channel reports the commit within the window
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	alternateEventService = fcmocks.NewMockEventService()
+	clientContext.AdditionalEventServices = []fab.EventService{alternateEventService}
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_VALID)
+
+	requestContext = prepareRequestContext(request, Opts{EventFailoverWindow: time.Minute}, t)
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, 1, len(alternateEventService.TxStatusRegCh), "expecting a registration on the alternate event service")
+}
+
+func TestExecuteTxHandlerWithEventFailoverEarlyBlock(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	window := 100 * time.Millisecond
+
+	// The alternate event service delivers the block of the transaction before the failover fires
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	clientContext.EventService = fcmocks.NewMockEventService()
+	alternateEventService := fcmocks.NewMockEventService()
+	clientContext.AdditionalEventServices = []fab.EventService{alternateEventService}
+
+	delivered := make(chan time.Time, 1)
+	go func() {
+		txStatusReg := <-alternateEventService.TxStatusRegCh
+		txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+		delivered <- time.Now()
+	}()
+
+	start := time.Now()
+	requestContext := prepareRequestContext(request, Opts{EventFailoverWindow: window}, t)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, pb.TxValidationCode_VALID, requestContext.Response.TxValidationCode)
+	assert.True(t, (<-delivered).Sub(start) < window, "expecting the block to be delivered before the failover")
+	assert.True(t, time.Since(start) >= window, "expecting the event of the alternate to be held until the failover")
+}
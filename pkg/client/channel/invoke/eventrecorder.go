@@ -0,0 +1,135 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// CommitLatency contains the timing of the TxStatus event of a transaction, as recorded by a RecordingEventService
+type CommitLatency struct {
+	// RegistrationToNotification is the time from the TxStatus registration until the event was received
+	RegistrationToNotification time.Duration
+	// SubmitToNotification is the time from sending the transaction to the orderer until the event was received
+	SubmitToNotification time.Duration
+}
+
+// CommitLatencyRecorder is implemented by event services that record the latency of the TxStatus events of
+// transactions. The CommitTxHandler reports the recorded latency of the transaction in Response.CommitLatency.
+type CommitLatencyRecorder interface {
+	CommitLatency(txID string, submitted time.Time) (CommitLatency, bool)
+}
+
+// txStatusTiming is the registration and notification time of a TxStatus registration
+type txStatusTiming struct {
+	registered time.Time
+	notified   time.Time
+}
+
+// RecordingEventService decorates an event service and records the time at which each TxStatus
+// registration is made and the time at which its event is received. The CommitTxHandler reports
+// the recorded latency in Response.CommitLatency.
+type RecordingEventService struct {
+	fab.EventService
+	mutex   sync.Mutex
+	timings map[string]*txStatusTiming
+	regs    map[fab.Registration]recordedReg
+}
+
+// recordedReg is a TxStatus registration of the recording event service
+type recordedReg struct {
+	txID string
+	done chan struct{}
+}
+
+// NewRecordingEventService returns a RecordingEventService which decorates the given event service
+func NewRecordingEventService(eventService fab.EventService) *RecordingEventService {
+	return &RecordingEventService{
+		EventService: eventService,
+		timings:      make(map[string]*txStatusTiming),
+		regs:         make(map[fab.Registration]recordedReg),
+	}
+}
+
+// RegisterTxStatusEvent registers for the TxStatus event with the decorated event service and records
+// the time of the registration and of the event
+func (s *RecordingEventService) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+	registered := time.Now()
+	reg, eventch, err := s.EventService.RegisterTxStatusEvent(txID)
+	if err != nil {
+		return reg, eventch, err
+	}
+
+	done := make(chan struct{})
+	s.mutex.Lock()
+	s.timings[txID] = &txStatusTiming{registered: registered}
+	s.regs[reg] = recordedReg{txID: txID, done: done}
+	s.mutex.Unlock()
+
+	recordedch := make(chan *fab.TxStatusEvent, 1)
+	go func() {
+		for {
+			select {
+			case event, ok := <-eventch:
+				if !ok {
+					close(recordedch)
+					return
+				}
+				s.notified(txID)
+				select {
+				case recordedch <- event:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return reg, recordedch, nil
+}
+
+// Unregister removes the given registration from the decorated event service and discards its timing
+func (s *RecordingEventService) Unregister(reg fab.Registration) {
+	s.mutex.Lock()
+	if r, ok := s.regs[reg]; ok {
+		delete(s.regs, reg)
+		delete(s.timings, r.txID)
+		close(r.done)
+	}
+	s.mutex.Unlock()
+
+	s.EventService.Unregister(reg)
+}
+
+// notified records the time at which the TxStatus event of the given transaction was received
+func (s *RecordingEventService) notified(txID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if timing, ok := s.timings[txID]; ok && timing.notified.IsZero() {
+		timing.notified = time.Now()
+	}
+}
+
+// CommitLatency returns the latency of the TxStatus event of the given transaction relative to its
+// registration and to the given submit time. False is returned if the event wasn't received by this
+// event service (or the registration was removed).
+func (s *RecordingEventService) CommitLatency(txID string, submitted time.Time) (CommitLatency, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	timing, ok := s.timings[txID]
+	if !ok || timing.notified.IsZero() {
+		return CommitLatency{}, false
+	}
+	return CommitLatency{
+		RegistrationToNotification: timing.notified.Sub(timing.registered),
+		SubmitToNotification:       timing.notified.Sub(submitted),
+	}, true
+}
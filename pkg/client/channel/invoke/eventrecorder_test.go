@@ -0,0 +1,57 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestRecordingEventService(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	recorder := NewRecordingEventService(mockEventService)
+
+	submitted := time.Now()
+	go func() {
+		txStatusReg := <-mockEventService.TxStatusRegCh
+		time.Sleep(10 * time.Millisecond)
+		txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID}
+	}()
+
+	reg, eventch, err := recorder.RegisterTxStatusEvent("txid")
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+
+	_, ok := fab.EventService(recorder).(CommitLatencyRecorder)
+	assert.True(t, ok, "expected the recording event service to be a commit latency recorder")
+
+	_, ok = recorder.CommitLatency("txid", submitted)
+	assert.False(t, ok, "expected no latency before the event is received")
+
+	select {
+	case event := <-eventch:
+		assert.Equal(t, "txid", event.TxID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for TxStatus event")
+	}
+
+	latency, ok := recorder.CommitLatency("txid", submitted)
+	assert.True(t, ok)
+	assert.True(t, latency.RegistrationToNotification >= 10*time.Millisecond)
+	assert.True(t, latency.SubmitToNotification >= latency.RegistrationToNotification)
+
+	// The timing is discarded on unregister
+	recorder.Unregister(reg)
+	_, ok = recorder.CommitLatency("txid", submitted)
+	assert.False(t, ok)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+// FallbackCondition returns true if the endorsement error of the primary chaincode triggers the
+// endorsement with the fallback chaincode
+type FallbackCondition func(err error) bool
+
+// chaincodeNotFoundPattern matches the messages with which the peers report that a chaincode isn't
+// installed or instantiated
+var chaincodeNotFoundPattern = regexp.MustCompile(`chaincode \S+ not found|could not find chaincode|cannot retrieve package for chaincode`)
+
+// IsChaincodeNotFound is the default FallbackCondition. It returns true if an endorser reported that the
+// chaincode isn't installed or instantiated.
+func IsChaincodeNotFound(err error) bool {
+	if errs, ok := errors.Cause(err).(multi.Errors); ok {
+		for _, e := range errs {
+			if IsChaincodeNotFound(e) {
+				return true
+			}
+		}
+		return false
+	}
+	if s, ok := status.FromError(err); ok {
+		return chaincodeNotFoundPattern.MatchString(s.Message)
+	}
+	return chaincodeNotFoundPattern.MatchString(err.Error())
+}
+
+// endorsementError returns the given error of sending the proposal or, if there is none, the status of
+// the first unsuccessful response
+func endorsementError(err error, responses []*fab.TransactionProposalResponse) error {
+	if err != nil {
+		return err
+	}
+	for _, r := range responses {
+		if r.ProposalResponse.GetResponse().GetStatus() >= 400 {
+			return status.NewFromProposalResponse(r.ProposalResponse, r.Endorser)
+		}
+	}
+	return nil
+}
+
+// endorseWithFallbackChaincode endorses a copy of the request with the fallback chaincode, so that the request of
+// the request context (which is used again if the invoke is retried) still refers to the primary chaincode. Unless
+// the targets were specified, the endorsers are selected again for the fallback chaincode and the request context
+// is updated with them. The early satisfaction of the endorsement policy (if any) is returned for the fallback
+// endorsements.
+func endorseWithFallbackChaincode(requestContext *RequestContext, clientContext *ClientContext) ([]*fab.TransactionProposalResponse, *fab.TransactionProposal, *earlySatisfaction, error) {
+	fallbackContext := *requestContext
+	fallbackContext.Request.ChaincodeID = requestContext.Opts.FallbackChaincodeID
+	if requestContext.targetsSelected {
+		endorsers, err := SelectEndorsers(&fallbackContext, clientContext)
+		if err != nil {
+			return nil, nil, nil, errors.WithMessage(err, "Failed to get endorsing peers for fallback chaincode")
+		}
+		fallbackContext.Opts.Targets = endorsers
+		requestContext.Opts.Targets = endorsers
+	}
+
+	processors := proposalProcessors(&fallbackContext, clientContext)
+	var early *earlySatisfaction
+	if satisfier := fallbackContext.Opts.EndorsementSatisfier; satisfier != nil {
+		early = newEarlySatisfaction(&fallbackContext, satisfier)
+		processors = early.wrap(processors)
+	}
+
+	responses, proposal, err := createAndSendTransactionProposal(&fallbackContext, clientContext, processors)
+	return responses, proposal, early, err
+}
+
+// useFallbackChaincode returns true if the proposal of the primary chaincode failed with an error
+// that satisfies the fallback condition of the request options
+func useFallbackChaincode(opts Opts, err error, responses []*fab.TransactionProposalResponse) bool {
+	if opts.FallbackChaincodeID == "" {
+		return false
+	}
+	err = endorsementError(err, responses)
+	if err == nil {
+		return false
+	}
+	condition := opts.FallbackCondition
+	if condition == nil {
+		condition = IsChaincodeNotFound
+	}
+	return condition(err)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// blueGreenPeer is a mock peer on which the first chaincode that is invoked isn't instantiated
+type blueGreenPeer struct {
+	*fcmocks.MockPeer
+}
+
+func (p *blueGreenPeer) ProcessTransactionProposal(ctx reqContext.Context, tp fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	if p.ProcessProposalCalls == 0 {
+		p.ProcessProposalCalls++
+		return &fab.TransactionProposalResponse{
+			Endorser: p.MockURL,
+			Status:   500,
+			ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{
+				Status: 500, Message: "make sure the chaincode primary has been successfully instantiated and try again: chaincode primary not found"}},
+		}, nil
+	}
+	return p.MockPeer.ProcessTransactionProposal(ctx, tp)
+}
+
+func TestEndorsementHandlerWithFallbackChaincode(t *testing.T) {
+	request := Request{ChaincodeID: "primary", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	handler := NewProposalProcessorHandler(NewEndorsementHandler(NewEndorsementValidationHandler()))
+
+	// No fallback
+	peer := &blueGreenPeer{MockPeer: fcmocks.NewMockPeer("Peer1", "http://peer1.com")}
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peer}}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.NotNil(t, requestContext.Error)
+	assert.Equal(t, 1, peer.ProcessProposalCalls)
+
+	// The fallback chaincode is endorsed with a copy of the request
+	peer = &blueGreenPeer{MockPeer: fcmocks.NewMockPeer("Peer1", "http://peer1.com")}
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{peer}, FallbackChaincodeID: "fallback"}, t)
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	var chaincodeIDs []string
+	clientContext.RequestTransformer = func(request *fab.ChaincodeInvokeRequest) error {
+		chaincodeIDs = append(chaincodeIDs, request.ChaincodeID)
+		return nil
+	}
+	handler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, 2, peer.ProcessProposalCalls)
+	assert.Equal(t, []string{"primary", "fallback"}, chaincodeIDs)
+	assert.Equal(t, "primary", requestContext.Request.ChaincodeID, "expecting the request not to be modified")
+
+	// The condition isn't satisfied
+	peer = &blueGreenPeer{MockPeer: fcmocks.NewMockPeer("Peer1", "http://peer1.com")}
+	never := func(err error) bool { return false }
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{peer}, FallbackChaincodeID: "fallback", FallbackCondition: never}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.NotNil(t, requestContext.Error)
+	assert.Equal(t, 1, peer.ProcessProposalCalls)
+	assert.Equal(t, "primary", requestContext.Request.ChaincodeID)
+}
+
+// chaincodeSelection is a selection service that records the chaincodes it's asked to select endorsers for
+type chaincodeSelection struct {
+	peers        map[string][]fab.Peer
+	chaincodeIDs []string
+}
+
+func (s *chaincodeSelection) GetEndorsersForChaincode(chaincodeIDs []string, opts ...options.Opt) ([]fab.Peer, error) {
+	s.chaincodeIDs = append(s.chaincodeIDs, chaincodeIDs...)
+	return s.peers[chaincodeIDs[0]], nil
+}
+
+func TestEndorsementHandlerWithFallbackChaincodeSelection(t *testing.T) {
+	request := Request{ChaincodeID: "primary", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	handler := NewProposalProcessorHandler(NewEndorsementHandler(NewEndorsementValidationHandler()))
+
+	primaryPeer := &blueGreenPeer{MockPeer: fcmocks.NewMockPeer("Peer1", "http://peer1.com")}
+	fallbackPeer := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	selection := &chaincodeSelection{peers: map[string][]fab.Peer{"primary": {primaryPeer}, "fallback": {fallbackPeer}}}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	clientContext.Selection = selection
+
+	// The endorsers are selected again for the fallback chaincode, and the satisfier starts afresh
+	satisfier := func(responses []*fab.TransactionProposalResponse) bool { return len(responses) > 0 }
+	requestContext := prepareRequestContext(request, Opts{FallbackChaincodeID: "fallback", EndorsementSatisfier: satisfier}, t)
+	handler.Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.Equal(t, []string{"primary", "fallback"}, selection.chaincodeIDs)
+	assert.Equal(t, 1, primaryPeer.ProcessProposalCalls)
+	assert.Equal(t, 1, fallbackPeer.ProcessProposalCalls)
+	assert.Equal(t, []fab.Peer{fallbackPeer}, requestContext.Opts.Targets)
+	if assert.Len(t, requestContext.Response.Responses, 1) {
+		assert.Equal(t, fallbackPeer.URL(), requestContext.Response.Responses[0].Endorser)
+	}
+	assert.Equal(t, "primary", requestContext.Request.ChaincodeID)
+}
+
+func TestIsChaincodeNotFound(t *testing.T) {
+	notFound := status.New(status.EndorserServerStatus, 500, "cannot retrieve package for chaincode primary/1.0", nil)
+	assert.True(t, IsChaincodeNotFound(notFound))
+	assert.True(t, IsChaincodeNotFound(multi.Append(errors.New("timeout"), notFound)))
+	assert.True(t, IsChaincodeNotFound(errors.New("could not find chaincode with name 'primary'")))
+	assert.False(t, IsChaincodeNotFound(status.New(status.EndorserServerStatus, 500, "key not found", nil)))
+	assert.False(t, IsChaincodeNotFound(multi.Append(errors.New("timeout"), errors.New("failed"))))
+}
@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+// Fault describes the faults that a FaultInjectionHandler injects at its position in the handler chain.
+// The faults are applied in the order of the fields: delay, drop and then status.
+type Fault struct {
+	// Delay delays the rest of the chain. The invoke fails if the request context is done first.
+	Delay time.Duration
+	// DropEndorsers are the URLs of the endorsers whose responses are removed from the response
+	DropEndorsers []string
+	// DropAllResponses removes all of the endorsement responses from the response
+	DropAllResponses bool
+	// Status, if set, fails the invoke with the given status instead of continuing the chain
+	Status *status.Status
+}
+
+//NewFaultInjectionHandler returns a handler that injects the given fault into the handler chain. It is
+//intended for resilience testing of the application's error paths without a broken network and must be
+//placed explicitly at the chosen position of a custom handler chain; it's never part of the default chains.
+func NewFaultInjectionHandler(fault Fault, next ...Handler) *FaultInjectionHandler {
+	return &FaultInjectionHandler{fault: fault, next: getNext(next)}
+}
+
+//FaultInjectionHandler for injecting faults into the handler chain
+type FaultInjectionHandler struct {
+	fault Fault
+	next  Handler
+}
+
+//Handle injects the fault
+func (h *FaultInjectionHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	fields := newLogFields(requestContext)
+
+	if h.fault.Delay > 0 {
+		fields.with("delay", h.fault.Delay).debugf("injecting delay")
+		select {
+		case <-time.After(h.fault.Delay):
+		case <-requestContext.Ctx.Done():
+			requestContext.Error = errors.New("request context done while injecting delay")
+			return
+		}
+	}
+
+	if h.fault.DropAllResponses || len(h.fault.DropEndorsers) > 0 {
+		requestContext.Response.Responses = h.dropResponses(requestContext.Response.Responses, fields)
+		if len(requestContext.Response.Responses) == 0 {
+			requestContext.Response.Payload = nil
+		}
+	}
+
+	if h.fault.Status != nil {
+		fields.debugf("injecting status: %s", h.fault.Status)
+		requestContext.Error = h.fault.Status
+		return
+	}
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+// dropResponses returns the given responses without the ones that the fault drops
+func (h *FaultInjectionHandler) dropResponses(responses []*fab.TransactionProposalResponse, fields logFields) []*fab.TransactionProposalResponse {
+	dropped := make(map[string]bool)
+	for _, url := range h.fault.DropEndorsers {
+		dropped[endpoint.ToAddress(url)] = true
+	}
+
+	var remaining []*fab.TransactionProposalResponse
+	for _, r := range responses {
+		if h.fault.DropAllResponses || dropped[endpoint.ToAddress(r.Endorser)] {
+			fields.withEndorser(r).debugf("injecting dropped response")
+			continue
+		}
+		remaining = append(remaining, r)
+	}
+	return remaining
+}
@@ -0,0 +1,62 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+func TestFaultInjectionHandler(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peers := []fab.Peer{mockPeer1, mockPeer2}
+
+	// Drop the response of an endorser
+	handler := NewProposalProcessorHandler(NewEndorsementHandler(NewFaultInjectionHandler(Fault{DropEndorsers: []string{"http://peer1.com"}})))
+	requestContext := prepareRequestContext(request, Opts{Targets: peers}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+	if assert.Len(t, requestContext.Response.Responses, 1) {
+		assert.Equal(t, "http://peer2.com", requestContext.Response.Responses[0].Endorser)
+	}
+
+	// Drop all of the responses
+	handler = NewProposalProcessorHandler(NewEndorsementHandler(NewFaultInjectionHandler(Fault{DropAllResponses: true})))
+	requestContext = prepareRequestContext(request, Opts{Targets: peers}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Empty(t, requestContext.Response.Responses)
+	assert.Nil(t, requestContext.Response.Payload)
+
+	// Inject a status; the rest of the chain isn't invoked
+	injected := status.New(status.EndorserServerStatus, 500, "injected failure", nil)
+	handler = NewProposalProcessorHandler(NewFaultInjectionHandler(Fault{Status: injected}, NewEndorsementHandler()))
+	requestContext = prepareRequestContext(request, Opts{Targets: peers}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Equal(t, injected, requestContext.Error)
+	assert.Equal(t, 2, mockPeer1.ProcessProposalCalls, "expected no further proposals")
+
+	// Delay until the request context is done
+	handler = NewProposalProcessorHandler(NewFaultInjectionHandler(Fault{Delay: time.Minute}, NewEndorsementHandler()))
+	requestContext = prepareRequestContext(request, Opts{Targets: peers}, t)
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), 10*time.Millisecond)
+	defer cancel()
+	requestContext.Ctx = ctx
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.NotNil(t, requestContext.Error)
+	assert.Equal(t, 2, mockPeer1.ProcessProposalCalls, "expected no further proposals")
+}
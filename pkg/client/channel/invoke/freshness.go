@@ -0,0 +1,61 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+)
+
+// proposalTimestamp returns the timestamp in the channel header of the given proposal
+func proposalTimestamp(proposal *fab.TransactionProposal) (time.Time, error) {
+	if proposal == nil || proposal.Proposal == nil {
+		return time.Time{}, errors.New("no transaction proposal to read the timestamp from")
+	}
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "unmarshal proposal header failed")
+	}
+	chdr, err := protos_utils.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "unmarshal channel header failed")
+	}
+	timestamp, err := ptypes.Timestamp(chdr.Timestamp)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid proposal timestamp")
+	}
+	return timestamp, nil
+}
+
+// validateFreshness checks that the proposal which the endorsers signed was created within Opts.MaxEndorsementAge,
+// which guards against replayed endorsements. Each endorsement is bound to the proposal (and therefore to its
+// timestamp) since the endorser signs the hash of the proposal. The endorsements are checked when they're
+// validated and again before the transaction is submitted, which also covers the endorsements of a proposal
+// that was endorsed elsewhere (see Client.SubmitEndorsedTransaction).
+func validateFreshness(requestContext *RequestContext) error {
+	created, err := proposalTimestamp(requestContext.Response.Proposal)
+	if err != nil {
+		return err
+	}
+	maxAge := requestContext.Opts.MaxEndorsementAge
+	if age := time.Since(created); age > maxAge {
+		var endorsers []string
+		for _, r := range requestContext.Response.Responses {
+			endorsers = append(endorsers, r.Endorser)
+		}
+		return status.New(status.EndorserClientStatus, status.StaleEndorsement.ToInt32(),
+			fmt.Sprintf("endorsements of proposal created at %s are older than %s", created.Format(time.RFC3339), maxAge), []interface{}{endorsers})
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+// DefaultHealthCheckTimeout is the timeout of a target health check if no timeout is specified
+const DefaultHealthCheckTimeout = time.Second
+
+// HealthChecker checks that the given target is healthy before the proposal is sent to it. The context
+// is done once the health check timeout expires.
+type HealthChecker func(ctx reqContext.Context, target fab.Peer) error
+
+// DialHealthCheck is a lightweight HealthChecker that checks that a TCP connection can be established to the target
+func DialHealthCheck(ctx reqContext.Context, target fab.Peer) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint.ToAddress(target.URL()))
+	if err != nil {
+		return errors.Wrapf(err, "dialing [%s] failed", target.URL())
+	}
+	return conn.Close()
+}
+
+// healthyTargets checks the health of the given targets concurrently and replaces each target that fails
+// the check by the next reserve target (that isn't already a target) that passes it. A target that can't
+// be replaced is removed. An error is returned if none of the targets is healthy.
+func healthyTargets(requestContext *RequestContext, targets []fab.Peer) ([]fab.Peer, error) {
+	opts := requestContext.Opts
+
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	wg.Add(len(targets))
+	for i, target := range targets {
+		go func(i int, target fab.Peer) {
+			defer wg.Done()
+			errs[i] = checkHealth(requestContext, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	used := make(map[string]bool)
+	for _, target := range targets {
+		used[target.URL()] = true
+	}
+
+	fields := newLogFields(requestContext)
+	reserve := opts.ReserveTargets
+	var healthy []fab.Peer
+	for i, target := range targets {
+		if errs[i] == nil {
+			healthy = append(healthy, target)
+			continue
+		}
+		fields.with("endorser", target.URL()).warnf("target failed health check: %s", errs[i])
+
+		for len(reserve) > 0 {
+			replacement := reserve[0]
+			reserve = reserve[1:]
+			if used[replacement.URL()] {
+				continue
+			}
+			used[replacement.URL()] = true
+			if err := checkHealth(requestContext, replacement); err != nil {
+				fields.with("endorser", replacement.URL()).warnf("reserve target failed health check: %s", err)
+				continue
+			}
+			fields.with("endorser", replacement.URL()).infof("replacing unhealthy target [%s]", target.URL())
+			healthy = append(healthy, replacement)
+			break
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil, status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), "none of the targets passed the health check", nil)
+	}
+	return healthy, nil
+}
+
+// checkHealth runs the health checker of the request against the target with the health check timeout
+func checkHealth(requestContext *RequestContext, target fab.Peer) error {
+	timeout := requestContext.Opts.HealthCheckTimeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+
+	ctx, cancel := reqContext.WithTimeout(requestContext.Ctx, timeout)
+	defer cancel()
+
+	return requestContext.Opts.HealthChecker(ctx, target)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+func TestProposalProcessorHandlerWithHealthCheck(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+	reserve1 := fcmocks.NewMockPeer("r1", "reserve1:7051")
+	reserve2 := fcmocks.NewMockPeer("r2", "reserve2:7051")
+
+	unhealthy := map[string]bool{"peer2:7051": true, "reserve1:7051": true}
+	checker := func(ctx reqContext.Context, target fab.Peer) error {
+		if unhealthy[target.URL()] {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}
+
+	handler := NewProposalProcessorHandler()
+
+	// The unhealthy target is replaced by the first healthy reserve target
+	requestContext := prepareRequestContext(Request{}, Opts{HealthChecker: checker, ReserveTargets: []fab.Peer{peer1, reserve1, reserve2}}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{peer1, peer2}, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.Equal(t, []fab.Peer{peer1, reserve2}, requestContext.Opts.Targets)
+
+	// No healthy target
+	requestContext = prepareRequestContext(Request{}, Opts{HealthChecker: checker, ReserveTargets: []fab.Peer{reserve1}}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{peer2}, t))
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.NoPeersFound.ToInt32(), s.Code)
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	checker := func(ctx reqContext.Context, target fab.Peer) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	requestContext := prepareRequestContext(Request{}, Opts{HealthChecker: checker, HealthCheckTimeout: 10 * time.Millisecond}, t)
+	start := time.Now()
+	err := checkHealth(requestContext, fcmocks.NewMockPeer("p1", "peer1:7051"))
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < time.Second, "expected health check to time out")
+}
+
+func TestDialHealthCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	addr := listener.Addr().String()
+
+	assert.Nil(t, DialHealthCheck(reqContext.Background(), fcmocks.NewMockPeer("p1", "grpcs://"+addr)))
+
+	listener.Close()
+	assert.NotNil(t, DialHealthCheck(reqContext.Background(), fcmocks.NewMockPeer("p1", "grpcs://"+addr)))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+)
+
+const (
+	// DefaultLatencyFactor is the factor applied to the p99 latency of a peer if none is specified
+	DefaultLatencyFactor = 3.0
+
+	// latencyWindow is the number of recent latencies that are tracked per peer
+	latencyWindow = 100
+
+	// minLatencySamples is the number of latencies that must be tracked for a peer
+	// before an adaptive timeout is applied to it
+	minLatencySamples = 10
+)
+
+// LatencyTracker tracks the endorsement latencies of the peers and derives an adaptive timeout
+// for each peer (the p99 of its recent latencies multiplied by a factor), so that a peer that's
+// typically fast but currently hung is abandoned early instead of waiting for the request timeout.
+type LatencyTracker struct {
+	factor     float64
+	minTimeout time.Duration
+
+	mutex     sync.RWMutex
+	latencies map[string][]time.Duration
+	next      map[string]int
+}
+
+// NewLatencyTracker returns a new latency tracker. The adaptive timeout of a peer is its p99
+// latency multiplied by factor (DefaultLatencyFactor if not positive), but never less than minTimeout.
+func NewLatencyTracker(factor float64, minTimeout time.Duration) *LatencyTracker {
+	if factor <= 0 {
+		factor = DefaultLatencyFactor
+	}
+	return &LatencyTracker{
+		factor:     factor,
+		minTimeout: minTimeout,
+		latencies:  make(map[string][]time.Duration),
+		next:       make(map[string]int),
+	}
+}
+
+// Record records the latency of an endorsement by the peer with the given URL
+func (lt *LatencyTracker) Record(peerURL string, latency time.Duration) {
+	address := endpoint.ToAddress(peerURL)
+
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+
+	latencies := lt.latencies[address]
+	if len(latencies) < latencyWindow {
+		lt.latencies[address] = append(latencies, latency)
+		return
+	}
+	latencies[lt.next[address]] = latency
+	lt.next[address] = (lt.next[address] + 1) % latencyWindow
+}
+
+// Timeout returns the adaptive timeout of the peer with the given URL. False is returned
+// if not enough latencies have been tracked for the peer.
+func (lt *LatencyTracker) Timeout(peerURL string) (time.Duration, bool) {
+	lt.mutex.RLock()
+	latencies := append([]time.Duration{}, lt.latencies[endpoint.ToAddress(peerURL)]...)
+	lt.mutex.RUnlock()
+
+	if len(latencies) < minLatencySamples {
+		return 0, false
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	p99 := latencies[(len(latencies)*99-1)/100]
+
+	timeout := time.Duration(float64(p99) * lt.factor)
+	if timeout < lt.minTimeout {
+		timeout = lt.minTimeout
+	}
+	return timeout, true
+}
+
+// latencyProcessor is a proposal processor that records the latency of the target
+// and abandons the target once its adaptive timeout expires
+type latencyProcessor struct {
+	target   fab.ProposalProcessor
+	endorser string
+	tracker  *LatencyTracker
+	fields   logFields
+}
+
+// withLatencyTracking wraps the processors of the given targets so that their latencies are tracked
+func withLatencyTracking(processors []fab.ProposalProcessor, targets []fab.Peer, tracker *LatencyTracker, fields logFields) []fab.ProposalProcessor {
+	tracked := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		tracked[i] = &latencyProcessor{target: p, endorser: targets[i].URL(), tracker: tracker, fields: fields}
+	}
+	return tracked
+}
+
+// ProcessTransactionProposal sends the proposal to the target within its adaptive timeout (if known). If the
+// target is abandoned after its adaptive timeout then the timeout is recorded as its latency, so that the
+// timeout of a peer that became slower grows with its latency instead of the peer being abandoned indefinitely.
+func (p *latencyProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	parent := ctx
+	timeout, adaptive := p.tracker.Timeout(p.endorser)
+	if adaptive {
+		var cancel reqContext.CancelFunc
+		ctx, cancel = reqContext.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	resp, err := p.target.ProcessTransactionProposal(ctx, request)
+	if err == nil {
+		p.tracker.Record(p.endorser, time.Since(start))
+	} else if adaptive && ctx.Err() == reqContext.DeadlineExceeded && parent.Err() == nil {
+		p.fields.with("endorser", p.endorser).debugf("abandoned endorser after its adaptive timeout")
+		p.tracker.Record(p.endorser, timeout)
+	}
+
+	return resp, err
+}
+
+func (p *latencyProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
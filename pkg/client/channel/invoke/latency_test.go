@@ -0,0 +1,82 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	lt := NewLatencyTracker(2, 5*time.Millisecond)
+
+	for i := 1; i < minLatencySamples; i++ {
+		lt.Record("grpcs://peer1.com:7051", time.Duration(i)*time.Millisecond)
+	}
+	_, ok := lt.Timeout("peer1.com:7051")
+	assert.False(t, ok, "expecting no timeout until enough latencies are tracked")
+
+	lt.Record("peer1.com:7051", 10*time.Millisecond)
+	timeout, ok := lt.Timeout("grpcs://peer1.com:7051")
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Millisecond, timeout)
+
+	// Only the most recent latencies are tracked
+	for i := 0; i < latencyWindow; i++ {
+		lt.Record("peer1.com:7051", time.Millisecond)
+	}
+	timeout, ok = lt.Timeout("peer1.com:7051")
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Millisecond, timeout, "expecting the minimum timeout")
+}
+
+func TestLatencyProcessorAbandonsHungPeer(t *testing.T) {
+	peer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	lt := NewLatencyTracker(DefaultLatencyFactor, 10*time.Millisecond)
+	for i := 0; i < minLatencySamples; i++ {
+		lt.Record(peer.URL(), time.Millisecond)
+	}
+
+	target := &hungProcessor{release: make(chan struct{}), returned: make(chan struct{})}
+	defer close(target.release)
+	processors := withLatencyTracking(withCancellation([]fab.ProposalProcessor{target}), []fab.Peer{peer}, lt, logFields{})
+
+	start := time.Now()
+	_, err := processors[0].ProcessTransactionProposal(reqContext.Background(), fab.ProcessProposalRequest{})
+	assert.NotNil(t, err, "expecting the hung peer to be abandoned")
+	assert.True(t, time.Since(start) < time.Second, "expecting the hung peer to be abandoned after its adaptive timeout")
+
+	// The abandoned call is recorded as a sample capped at the timeout, so the timeout grows if the peer stays slow
+	lt.mutex.RLock()
+	latencies := append([]time.Duration{}, lt.latencies[endpoint.ToAddress(peer.URL())]...)
+	lt.mutex.RUnlock()
+	if assert.Equal(t, minLatencySamples+1, len(latencies)) {
+		assert.Equal(t, 10*time.Millisecond, latencies[minLatencySamples], "expecting the timeout to be recorded")
+	}
+	timeout, ok := lt.Timeout(peer.URL())
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Millisecond, timeout, "expecting the timeout to grow")
+
+	// A call that is abandoned because the request is done isn't recorded
+	cancelled := &hungProcessor{release: make(chan struct{}), returned: make(chan struct{})}
+	defer close(cancelled.release)
+	processors = withLatencyTracking(withCancellation([]fab.ProposalProcessor{cancelled}), []fab.Peer{peer}, lt, logFields{})
+	ctx, cancel := reqContext.WithCancel(reqContext.Background())
+	cancel()
+	_, err = processors[0].ProcessTransactionProposal(ctx, fab.ProcessProposalRequest{})
+	assert.NotNil(t, err, "expecting the cancelled request to fail")
+	lt.mutex.RLock()
+	assert.Equal(t, minLatencySamples+1, len(lt.latencies[endpoint.ToAddress(peer.URL())]), "expecting no sample for the cancelled request")
+	lt.mutex.RUnlock()
+}
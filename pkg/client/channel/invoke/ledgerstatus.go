@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+)
+
+const qsccGetBlockByTxID = "GetBlockByTxID"
+
+// queryTxStatus queries the ledgers of the targets of the request for the block of the given transaction and
+// returns the TxStatus of the transaction, or false if none of the targets has committed the transaction. It
+// covers the blocks that were delivered while no TxStatus event registration was in place.
+func queryTxStatus(requestContext *RequestContext, clientContext *ClientContext, txID string) (*fab.TxStatusEvent, bool) {
+	fields := newLogFields(requestContext)
+	for _, peer := range requestContext.Opts.Targets {
+		payload, err := queryQSCC(requestContext, clientContext, peer, qsccGetBlockByTxID, txID)
+		if err != nil {
+			fields.debugf("unable to query the block of the transaction from peer [%s]: %s", peer.URL(), err)
+			continue
+		}
+		txStatus, err := txStatusFromBlock(payload, txID)
+		if err != nil {
+			fields.debugf("unable to read the status of the transaction from the block of peer [%s]: %s", peer.URL(), err)
+			continue
+		}
+		return txStatus, true
+	}
+	return nil, false
+}
+
+// txStatusFromBlock returns the TxStatus of the given transaction in the given marshalled block
+func txStatusFromBlock(payload []byte, txID string) (*fab.TxStatusEvent, error) {
+	block := &common.Block{}
+	if err := proto.Unmarshal(payload, block); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of block failed")
+	}
+	if block.Header == nil || block.Data == nil || block.Metadata == nil {
+		return nil, errors.New("incomplete block")
+	}
+	if len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		return nil, errors.New("block has no transactions filter")
+	}
+	filter := block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+
+	for i, data := range block.Data.Data {
+		envelope, err := protos_utils.GetEnvelopeFromBlock(data)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := protos_utils.GetPayload(envelope)
+		if err != nil {
+			return nil, err
+		}
+		if payload.Header == nil {
+			continue
+		}
+		chdr, err := protos_utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+		if err != nil {
+			return nil, err
+		}
+		if chdr.TxId != txID {
+			continue
+		}
+		if i >= len(filter) {
+			return nil, errors.Errorf("transactions filter has no validation code for transaction %d", i)
+		}
+		return &fab.TxStatusEvent{TxID: txID, TxValidationCode: pb.TxValidationCode(filter[i]), BlockNumber: block.Header.Number}, nil
+	}
+	return nil, errors.Errorf("transaction [%s] not found in block %d", txID, block.Header.Number)
+}
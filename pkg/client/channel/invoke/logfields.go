@@ -0,0 +1,108 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+)
+
+// logField is a key/value pair attached to a log message
+type logField struct {
+	key   string
+	value interface{}
+}
+
+// logFields contains the key/value pairs that identify an invocation in the handler log messages
+// and the logger of the invocation (if one is specified in the request options)
+type logFields struct {
+	fields []logField
+	logger Logger
+}
+
+// newLogFields returns the fields (request ID, TxID, channel and chaincode) identifying the invocation
+// in the given request context. Fields that are not known yet are omitted.
+func newLogFields(requestContext *RequestContext) logFields {
+	fields := logFields{logger: requestContext.Opts.Logger}
+	if requestID := requestContext.Opts.RequestID; requestID != "" {
+		fields = fields.with("requestID", requestID)
+	}
+	if txnID := requestContext.Response.TransactionID; txnID != fab.EmptyTransactionID {
+		fields = fields.with("txID", txnID)
+	}
+	if channelID := channelIDFromProposal(requestContext.Response.Proposal); channelID != "" {
+		fields = fields.with("channel", channelID)
+	}
+	return fields.with("chaincode", requestContext.Request.ChaincodeID)
+}
+
+// with returns a copy of the fields with the given key/value pair appended
+func (f logFields) with(key string, value interface{}) logFields {
+	fields := make([]logField, len(f.fields), len(f.fields)+1)
+	copy(fields, f.fields)
+	return logFields{fields: append(fields, logField{key: key, value: value}), logger: f.logger}
+}
+
+// withEndorser returns a copy of the fields with the endorser of the given response appended
+func (f logFields) withEndorser(r *fab.TransactionProposalResponse) logFields {
+	return f.with("endorser", r.Endorser)
+}
+
+func (f logFields) String() string {
+	var buf bytes.Buffer
+	for i, field := range f.fields {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		fmt.Fprintf(&buf, "%s=%v", field.key, field.value)
+	}
+	return buf.String()
+}
+
+func (f logFields) debugf(format string, args ...interface{}) {
+	if f.logger != nil {
+		f.logger.Debugf("%s [%s]", fmt.Sprintf(format, args...), f)
+	} else if logging.IsEnabledFor(loggerModule, logging.DEBUG) {
+		logger.Debugf("%s [%s]", fmt.Sprintf(format, args...), f)
+	}
+}
+
+func (f logFields) infof(format string, args ...interface{}) {
+	if f.logger != nil {
+		f.logger.Infof("%s [%s]", fmt.Sprintf(format, args...), f)
+	} else if logging.IsEnabledFor(loggerModule, logging.INFO) {
+		logger.Infof("%s [%s]", fmt.Sprintf(format, args...), f)
+	}
+}
+
+func (f logFields) warnf(format string, args ...interface{}) {
+	if f.logger != nil {
+		f.logger.Warnf("%s [%s]", fmt.Sprintf(format, args...), f)
+	} else if logging.IsEnabledFor(loggerModule, logging.WARNING) {
+		logger.Warnf("%s [%s]", fmt.Sprintf(format, args...), f)
+	}
+}
+
+// channelIDFromProposal extracts the channel ID from the proposal's channel header
+func channelIDFromProposal(proposal *fab.TransactionProposal) string {
+	if proposal == nil || proposal.Proposal == nil {
+		return ""
+	}
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		return ""
+	}
+	chdr, err := protos_utils.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		return ""
+	}
+	return chdr.ChannelId
+}
@@ -0,0 +1,80 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+)
+
+func TestLogFields(t *testing.T) {
+	requestContext := &RequestContext{Request: Request{ChaincodeID: "testCC", Fcn: "invoke"}}
+
+	fields := newLogFields(requestContext)
+	assert.Equal(t, "chaincode=testCC", fields.String())
+
+	txh, err := txn.NewHeader(setupTestContext(), "testChannel")
+	if err != nil {
+		t.Fatalf("Failed to create transaction header: %s", err)
+	}
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{ChaincodeID: "testCC", Fcn: "invoke"})
+	if err != nil {
+		t.Fatalf("Failed to create proposal: %s", err)
+	}
+	requestContext.Response.Proposal = proposal
+	requestContext.Response.TransactionID = proposal.TxnID
+
+	fields = newLogFields(requestContext)
+	assert.Equal(t, "txID="+string(proposal.TxnID)+" channel=testChannel chaincode=testCC", fields.String())
+
+	endorserFields := fields.withEndorser(&fab.TransactionProposalResponse{Endorser: "peer1:7051"})
+	assert.Equal(t, fields.String()+" endorser=peer1:7051", endorserFields.String())
+	assert.Equal(t, 3, len(fields.fields), "with should not modify the original fields")
+}
+
+func TestLogFieldsWithRequestID(t *testing.T) {
+	logger := &recordingLogger{}
+	requestContext := &RequestContext{Request: Request{ChaincodeID: "testCC", Fcn: "invoke"}, Opts: Opts{Logger: logger, RequestID: "order-42"}}
+
+	newLogFields(requestContext).debugf("message")
+	assert.Equal(t, []string{"DEBUG message [requestID=order-42 chaincode=testCC]"}, logger.messages)
+}
+
+// recordingLogger records the messages logged to it
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.messages = append(l.messages, "DEBUG "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.messages = append(l.messages, "INFO "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Warnf(format string, args ...interface{}) {
+	l.messages = append(l.messages, "WARNING "+fmt.Sprintf(format, args...))
+}
+
+func TestLogFieldsWithLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	requestContext := &RequestContext{Request: Request{ChaincodeID: "testCC", Fcn: "invoke"}, Opts: Opts{Logger: logger}}
+
+	fields := newLogFields(requestContext)
+	fields.debugf("message %d", 1)
+	fields.withEndorser(&fab.TransactionProposalResponse{Endorser: "peer1:7051"}).warnf("message %d", 2)
+	assert.Equal(t, []string{
+		"DEBUG message 1 [chaincode=testCC]",
+		"WARNING message 2 [chaincode=testCC endorser=peer1:7051]",
+	}, logger.messages)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+)
+
+// levelLoggerModule is the logging module of the loggers returned by NewLevelLogger
+const levelLoggerModule = "fabsdk/client/invoke"
+
+// Logger receives the handler log messages of a single invoke (see Opts.Logger). A *logging.Logger may be used.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+// printer emits log messages regardless of the level of its logging module
+type printer interface {
+	Printf(format string, args ...interface{})
+}
+
+// levelLogger emits the messages at or above its level, independently of the level of the fabsdk/client module.
+// The messages are printed (with their level) since the level of the logging module is left unchanged.
+type levelLogger struct {
+	level   logging.Level
+	printer printer
+}
+
+// NewLevelLogger returns a logger which emits the handler log messages of an invoke at or above the given level
+// (for example, to debug a single problematic transaction without raising the level of the fabsdk/client module)
+func NewLevelLogger(level logging.Level) Logger {
+	return &levelLogger{level: level, printer: logging.NewLogger(levelLoggerModule)}
+}
+
+func (l *levelLogger) Debugf(format string, args ...interface{}) {
+	l.printf(logging.DEBUG, format, args...)
+}
+
+func (l *levelLogger) Infof(format string, args ...interface{}) {
+	l.printf(logging.INFO, format, args...)
+}
+
+func (l *levelLogger) Warnf(format string, args ...interface{}) {
+	l.printf(logging.WARNING, format, args...)
+}
+
+func (l *levelLogger) printf(level logging.Level, format string, args ...interface{}) {
+	if l.level >= level {
+		l.printer.Printf("%s %s", logging.ParseString(level), fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+)
+
+// recordingPrinter records the messages printed to it
+type recordingPrinter struct {
+	messages []string
+}
+
+func (p *recordingPrinter) Printf(format string, args ...interface{}) {
+	p.messages = append(p.messages, fmt.Sprintf(format, args...))
+}
+
+func TestLevelLogger(t *testing.T) {
+	moduleLevel := logging.GetLevel(levelLoggerModule)
+	NewLevelLogger(logging.DEBUG)
+	assert.Equal(t, moduleLevel, logging.GetLevel(levelLoggerModule), "expected the level of the logging module to be unchanged")
+
+	printer := &recordingPrinter{}
+	logger := &levelLogger{level: logging.INFO, printer: printer}
+	logger.Debugf("message %d", 1)
+	logger.Infof("message %d", 2)
+	logger.Warnf("message %d", 3)
+	assert.Equal(t, []string{"INFO message 2", "WARNING message 3"}, printer.messages, "expected messages below INFO to be filtered")
+
+	printer = &recordingPrinter{}
+	logger = &levelLogger{level: logging.DEBUG, printer: printer}
+	requestContext := &RequestContext{Request: Request{ChaincodeID: "testCC", Fcn: "invoke"}, Opts: Opts{Logger: logger}}
+	newLogFields(requestContext).debugf("message %d", 4)
+	assert.Equal(t, []string{"DEBUG message 4 [chaincode=testCC]"}, printer.messages, "expected the handler messages to be routed to Opts.Logger")
+}
@@ -0,0 +1,84 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+//NewNormalizeArgsHandler returns a handler that canonicalizes the request arguments
+func NewNormalizeArgsHandler(next ...Handler) *NormalizeArgsHandler {
+	return &NormalizeArgsHandler{next: getNext(next)}
+}
+
+//NormalizeArgsHandler canonicalizes the args and transient map values of the request (if
+//enabled by Opts.NormalizeArgs) so that logically identical requests are serialized identically
+type NormalizeArgsHandler struct {
+	next Handler
+}
+
+//Handle normalizes the request arguments
+func (h *NormalizeArgsHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	normalizeArgs(requestContext)
+
+	// Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+// normalizeArgs normalizes the request of the given request context if enabled by Opts.NormalizeArgs
+func normalizeArgs(requestContext *RequestContext) {
+	if requestContext.Opts.NormalizeArgs {
+		requestContext.Request = normalizeRequest(requestContext.Request)
+	}
+}
+
+// normalizeRequest returns a copy of the request with the JSON args and transient
+// map values in canonical form. The request passed in is not modified.
+func normalizeRequest(request Request) Request {
+	if request.Args != nil {
+		args := make([][]byte, len(request.Args))
+		for i, arg := range request.Args {
+			args[i] = normalizeJSON(arg)
+		}
+		request.Args = args
+	}
+	if request.TransientMap != nil {
+		transientMap := make(map[string][]byte, len(request.TransientMap))
+		for k, v := range request.TransientMap {
+			transientMap[k] = normalizeJSON(v)
+		}
+		request.TransientMap = transientMap
+	}
+	return request
+}
+
+// normalizeJSON returns the canonical form (sorted object keys, no insignificant
+// whitespace) of the given JSON object or array. Any other value is returned as is.
+func normalizeJSON(value []byte) []byte {
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return value
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil || decoder.More() {
+		return value
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return value
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
@@ -0,0 +1,43 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeJSON(t *testing.T) {
+	assert.Equal(t, `{"a":1,"b":{"c":"<x>","d":[2,1]}}`, string(normalizeJSON([]byte(` { "b": {"d": [2, 1], "c": "<x>"}, "a": 1 } `))))
+	assert.Equal(t, `[{"a":1.50,"b":2}]`, string(normalizeJSON([]byte(`[{"b":2, "a":1.50}]`))))
+
+	// Non JSON and scalar values are unchanged
+	assert.Equal(t, "move", string(normalizeJSON([]byte("move"))))
+	assert.Equal(t, " 10 ", string(normalizeJSON([]byte(" 10 "))))
+	assert.Equal(t, `{"a":1`, string(normalizeJSON([]byte(`{"a":1`))))
+	assert.Equal(t, `{"a":1} {"b":2}`, string(normalizeJSON([]byte(`{"a":1} {"b":2}`))))
+}
+
+func TestNormalizeArgsHandler(t *testing.T) {
+	args := [][]byte{[]byte("move"), []byte(`{"to":"b", "from":"a"}`)}
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: args, TransientMap: map[string][]byte{"key": []byte(`{"y":1, "x":2}`)}}
+
+	handler := NewNormalizeArgsHandler()
+
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Equal(t, request, requestContext.Request, "request should not be modified unless normalization is enabled")
+
+	requestContext = prepareRequestContext(request, Opts{NormalizeArgs: true}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, "move", string(requestContext.Request.Args[0]))
+	assert.Equal(t, `{"from":"a","to":"b"}`, string(requestContext.Request.Args[1]))
+	assert.Equal(t, `{"x":2,"y":1}`, string(requestContext.Request.TransientMap["key"]))
+	assert.Equal(t, `{"to":"b", "from":"a"}`, string(args[1]), "caller's args should not be modified")
+}
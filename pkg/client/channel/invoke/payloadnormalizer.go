@@ -0,0 +1,45 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// PayloadNormalizer returns the semantic portion of a response payload, which is compared across the endorsers
+// instead of the raw payload (for example, without a nonce that legitimately differs across the endorsers)
+type PayloadNormalizer func(payload []byte) ([]byte, error)
+
+// IgnoreJSONFields returns a PayloadNormalizer for JSON object payloads that removes the given top-level fields.
+// The remaining fields are serialized in key order, so payloads that only differ in the order of their fields
+// also match.
+func IgnoreJSONFields(fields ...string) PayloadNormalizer {
+	return func(payload []byte) ([]byte, error) {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &object); err != nil {
+			return nil, errors.Wrap(err, "payload is not a JSON object")
+		}
+		for _, field := range fields {
+			delete(object, field)
+		}
+		normalized, err := json.Marshal(object)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal of normalized payload failed")
+		}
+		return normalized, nil
+	}
+}
+
+// normalizedPayload returns the given response payload, normalized with Opts.PayloadNormalizer if set
+func normalizedPayload(opts Opts, payload []byte) ([]byte, error) {
+	if opts.PayloadNormalizer == nil {
+		return payload, nil
+	}
+	return opts.PayloadNormalizer(payload)
+}
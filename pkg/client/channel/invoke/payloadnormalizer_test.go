@@ -0,0 +1,54 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestIgnoreJSONFields(t *testing.T) {
+	normalize := IgnoreJSONFields("nonce")
+
+	p1, err := normalize([]byte(`{"value":1,"nonce":"a"}`))
+	assert.Nil(t, err)
+	p2, err := normalize([]byte(`{"nonce":"b","value":1}`))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"value":1}`, string(p1))
+	assert.Equal(t, p1, p2)
+
+	_, err = normalize([]byte("value"))
+	assert.NotNil(t, err, "expecting error for a payload that isn't a JSON object")
+}
+
+func TestEndorsementValidationHandlerWithPayloadNormalizer(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte(`{"value":1,"nonce":"a"}`)}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte(`{"value":1,"nonce":"b"}`)}
+	peers := []fab.Peer{mockPeer1, mockPeer2}
+
+	queryHandler := NewQueryHandler()
+
+	requestContext := prepareRequestContext(request, Opts{Targets: peers}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.NotNil(t, requestContext.Error, "expecting the raw payloads not to match")
+
+	requestContext = prepareRequestContext(request, Opts{Targets: peers, PayloadNormalizer: IgnoreJSONFields("nonce")}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Contains(t, string(requestContext.Response.Payload), "nonce", "expecting the unnormalized payload to be returned")
+
+	mockPeer2.Payload = []byte(`{"value":2,"nonce":"b"}`)
+	requestContext = prepareRequestContext(request, Opts{Targets: peers, PayloadNormalizer: IgnoreJSONFields("nonce")}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.NotNil(t, requestContext.Error, "expecting the semantic portions not to match")
+}
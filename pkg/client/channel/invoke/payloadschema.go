@@ -0,0 +1,45 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+// PayloadValidator validates the response payload returned by the chaincode
+// (for example, against a JSON schema or a protobuf message definition)
+type PayloadValidator interface {
+	Validate(payload []byte) error
+}
+
+//NewPayloadSchemaHandler returns a handler that validates the response payload using the given validator.
+//In order to reject the payload before the transaction is committed, the handler must be chained after
+//the endorsement handlers and before the commit handler.
+func NewPayloadSchemaHandler(validator PayloadValidator, next ...Handler) *PayloadSchemaHandler {
+	return &PayloadSchemaHandler{validator: validator, next: getNext(next)}
+}
+
+//PayloadSchemaHandler for validating the response payload
+type PayloadSchemaHandler struct {
+	validator PayloadValidator
+	next      Handler
+}
+
+//Handle validates the response payload
+func (h *PayloadSchemaHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	if err := h.validator.Validate(requestContext.Response.Payload); err != nil {
+		newLogFields(requestContext).debugf("payload validation failed: %s", err)
+		requestContext.Error = status.New(status.ClientStatus, status.InvalidPayload.ToInt32(),
+			"response payload validation failed: "+err.Error(), nil)
+		return
+	}
+
+	// Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
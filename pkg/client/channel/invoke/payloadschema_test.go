@@ -0,0 +1,50 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+type prefixValidator struct {
+	prefix []byte
+}
+
+func (v *prefixValidator) Validate(payload []byte) error {
+	if !bytes.HasPrefix(payload, v.prefix) {
+		return errors.Errorf("payload doesn't start with [%s]", v.prefix)
+	}
+	return nil
+}
+
+func TestPayloadSchemaHandler(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peers := []fab.Peer{mockPeer}
+
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	NewQueryHandler(NewPayloadSchemaHandler(&prefixValidator{prefix: []byte("val")})).Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+	assert.Nil(t, requestContext.Error)
+
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	NewQueryHandler(NewPayloadSchemaHandler(&prefixValidator{prefix: []byte("{")})).Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.ClientStatus, s.Group)
+	assert.Equal(t, status.InvalidPayload.ToInt32(), s.Code)
+}
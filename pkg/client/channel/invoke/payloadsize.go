@@ -0,0 +1,46 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+//NewPayloadSizeLimitHandler returns a handler that rejects the responses if the payload returned by any of the
+//endorsers exceeds maxBytes. The handler must be chained directly after the endorsement handler so that the
+//payloads are checked before they are processed further. A maxBytes of zero or less doesn't limit the payloads.
+func NewPayloadSizeLimitHandler(maxBytes int, next ...Handler) *PayloadSizeLimitHandler {
+	return &PayloadSizeLimitHandler{maxBytes: maxBytes, next: getNext(next)}
+}
+
+//PayloadSizeLimitHandler for enforcing a maximum response payload size
+type PayloadSizeLimitHandler struct {
+	maxBytes int
+	next     Handler
+}
+
+//Handle checks the size of the response payloads
+func (h *PayloadSizeLimitHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	for _, r := range requestContext.Response.Responses {
+		if h.maxBytes <= 0 {
+			break
+		}
+		if size := len(r.ProposalResponse.GetResponse().GetPayload()); size > h.maxBytes {
+			newLogFields(requestContext).withEndorser(r).warnf("response payload of %d bytes exceeds the limit of %d bytes", size, h.maxBytes)
+			requestContext.Error = status.New(status.ClientStatus, status.PayloadTooLarge.ToInt32(),
+				fmt.Sprintf("response payload from [%s] of %d bytes exceeds the limit of %d bytes", r.Endorser, size, h.maxBytes), nil)
+			return
+		}
+	}
+
+	// Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
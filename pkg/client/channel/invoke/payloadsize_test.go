@@ -0,0 +1,48 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+func TestPayloadSizeLimitHandler(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peers := []fab.Peer{mockPeer}
+
+	handler := NewProposalProcessorHandler(NewEndorsementHandler(NewPayloadSizeLimitHandler(5)))
+
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+	assert.Nil(t, requestContext.Error)
+
+	mockPeer.Payload = []byte("value1")
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.ClientStatus, s.Group)
+	assert.Equal(t, status.PayloadTooLarge.ToInt32(), s.Code)
+
+	// A limit of zero or less doesn't limit the payloads
+	for _, maxBytes := range []int{0, -1} {
+		handler = NewProposalProcessorHandler(NewEndorsementHandler(NewPayloadSizeLimitHandler(maxBytes)))
+		requestContext = prepareRequestContext(request, Opts{}, t)
+		handler.Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+		assert.Nil(t, requestContext.Error, "expecting no limit for maxBytes %d", maxBytes)
+	}
+}
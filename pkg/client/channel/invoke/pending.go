@@ -0,0 +1,202 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// PendingEndorsements accumulates the endorsements of a transaction proposal across calls, for example for
+// a governance transaction that is endorsed over time by different orgs. Once the endorsements satisfy the
+// endorsement policy, the transaction may be submitted with the proposal and the accumulated responses.
+// PendingEndorsements may be serialized with Marshal so that it can be persisted between calls.
+type PendingEndorsements struct {
+	Proposal  *fab.TransactionProposal
+	Responses []*fab.TransactionProposalResponse
+}
+
+// NewPendingEndorsements returns a new, empty collection of the endorsements of the given proposal
+func NewPendingEndorsements(proposal *fab.TransactionProposal) *PendingEndorsements {
+	return &PendingEndorsements{Proposal: proposal}
+}
+
+// Add adds the given successful responses to the collection. A response replaces the response previously
+// added for the same endorser (if any). An error with status EndorsementMismatch is returned, and none of
+// the responses is added, if a response doesn't match the responses that were already added.
+func (p *PendingEndorsements) Add(responses ...*fab.TransactionProposalResponse) error {
+	merged := append([]*fab.TransactionProposalResponse(nil), p.Responses...)
+	for _, r := range responses {
+		if len(merged) > 0 && !bytes.Equal(r.ProposalResponse.GetPayload(), merged[0].ProposalResponse.GetPayload()) {
+			return status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(),
+				fmt.Sprintf("ProposalResponsePayloads do not match: endorsement from [%s] differs from the pending endorsements", r.Endorser), nil)
+		}
+		merged = replaceResponse(merged, r)
+	}
+	p.Responses = merged
+	return nil
+}
+
+// Satisfies returns true if the accumulated endorsements satisfy the given endorsement policy
+// (for example, OrgsSatisfier)
+func (p *PendingEndorsements) Satisfies(satisfier EndorsementSatisfier) bool {
+	return len(p.Responses) > 0 && satisfier(p.Responses)
+}
+
+// replaceResponse replaces the response of the same endorser in the given responses, or appends the response
+func replaceResponse(responses []*fab.TransactionProposalResponse, r *fab.TransactionProposalResponse) []*fab.TransactionProposalResponse {
+	for i, existing := range responses {
+		if existing.Endorser == r.Endorser {
+			responses[i] = r
+			return responses
+		}
+	}
+	return append(responses, r)
+}
+
+// pendingEndorsementsJSON is the serialized form of PendingEndorsements
+type pendingEndorsementsJSON struct {
+	TxnID     string                `json:"txnId"`
+	Proposal  []byte                `json:"proposal"`
+	Responses []pendingResponseJSON `json:"responses"`
+}
+
+type pendingResponseJSON struct {
+	Endorser string `json:"endorser"`
+	Status   int32  `json:"status"`
+	Response []byte `json:"response"`
+}
+
+// Marshal serializes the pending endorsements (to JSON) so that they can be persisted
+func (p *PendingEndorsements) Marshal() ([]byte, error) {
+	if p.Proposal == nil {
+		return nil, errors.New("proposal is required")
+	}
+	proposalBytes, err := proto.Marshal(p.Proposal.Proposal)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of transaction proposal failed")
+	}
+
+	pending := pendingEndorsementsJSON{TxnID: string(p.Proposal.TxnID), Proposal: proposalBytes}
+	for _, r := range p.Responses {
+		responseBytes, err := proto.Marshal(r.ProposalResponse)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal of proposal response from [%s] failed", r.Endorser)
+		}
+		pending.Responses = append(pending.Responses, pendingResponseJSON{Endorser: r.Endorser, Status: r.Status, Response: responseBytes})
+	}
+
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of pending endorsements failed")
+	}
+	return data, nil
+}
+
+// UnmarshalPendingEndorsements restores pending endorsements that were serialized with Marshal
+func UnmarshalPendingEndorsements(data []byte) (*PendingEndorsements, error) {
+	var pending pendingEndorsementsJSON
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of pending endorsements failed")
+	}
+
+	proposal := &pb.Proposal{}
+	if err := proto.Unmarshal(pending.Proposal, proposal); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of transaction proposal failed")
+	}
+
+	p := NewPendingEndorsements(&fab.TransactionProposal{TxnID: fab.TransactionID(pending.TxnID), Proposal: proposal})
+	for _, r := range pending.Responses {
+		response := &pb.ProposalResponse{}
+		if err := proto.Unmarshal(r.Response, response); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal of proposal response from [%s] failed", r.Endorser)
+		}
+		p.Responses = append(p.Responses, &fab.TransactionProposalResponse{Endorser: r.Endorser, Status: r.Status, ProposalResponse: response})
+	}
+	return p, nil
+}
+
+//NewPendingEndorsementHandler returns a handler that sends the proposal of the pending endorsements to the
+//targets and adds their successful endorsements to the pending endorsements. The results of all of the
+//targets are returned in Response.Endorsements and the accumulated responses in Response.Responses.
+func NewPendingEndorsementHandler(pending *PendingEndorsements, next ...Handler) *PendingEndorsementHandler {
+	return &PendingEndorsementHandler{pending: pending, next: getNext(next)}
+}
+
+//PendingEndorsementHandler for endorsing a pending proposal incrementally
+type PendingEndorsementHandler struct {
+	pending *PendingEndorsements
+	next    Handler
+}
+
+//Handle sends the pending proposal to the targets and accumulates the successful endorsements
+func (h *PendingEndorsementHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	targets := requestContext.Opts.Targets
+	if len(targets) == 0 {
+		requestContext.Error = status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), "targets were not provided", nil)
+		return
+	}
+
+	proposal := h.pending.Proposal
+	requestContext.Response.Proposal = proposal
+	requestContext.Response.TransactionID = proposal.TxnID
+
+	results := make([]*EndorsementResult, len(targets))
+	processors := proposalProcessors(requestContext, clientContext)
+	collectors := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		collectors[i] = &collectingProcessor{target: p, endorser: targets[i].URL(), results: results, index: i}
+	}
+
+	// Errors returned by the endorsers are recorded in the results
+	_, err := transactor(requestContext, clientContext).SendTransactionProposal(proposal, collectors)
+
+	fields := newLogFields(requestContext)
+	var responses []*fab.TransactionProposalResponse
+	var failure error
+	for i, result := range results {
+		if result == nil {
+			// The proposal wasn't sent to the endorser
+			results[i] = &EndorsementResult{Endorser: targets[i].URL(), Error: err}
+			failure = err
+			continue
+		}
+		if result.Success {
+			responses = append(responses, result.Response)
+		} else {
+			fields.with("endorser", result.Endorser).debugf("endorsement failed: %s", result.Error)
+			failure = result.Error
+		}
+	}
+	requestContext.Response.Endorsements = results
+
+	if len(responses) == 0 {
+		requestContext.Error = failure
+		return
+	}
+	if err := h.pending.Add(responses...); err != nil {
+		requestContext.Error = err
+		return
+	}
+	fields.debugf("%d endorsement(s) added - %d pending endorsement(s)", len(responses), len(h.pending.Responses))
+
+	requestContext.Response.Responses = h.pending.Responses
+	requestContext.Response.Payload = h.pending.Responses[0].ProposalResponse.GetResponse().GetPayload()
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
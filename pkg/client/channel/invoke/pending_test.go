@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestPendingEndorsements(t *testing.T) {
+	pending := NewPendingEndorsements(&fab.TransactionProposal{TxnID: "txn1", Proposal: &pb.Proposal{Header: []byte("header"), Payload: []byte("payload")}})
+	satisfier := func(responses []*fab.TransactionProposalResponse) bool { return len(responses) >= 2 }
+	assert.False(t, pending.Satisfies(satisfier))
+
+	assert.Nil(t, pending.Add(pendingResponse("peer1", []byte("rwset"))))
+	assert.False(t, pending.Satisfies(satisfier))
+
+	// A new response from the same endorser replaces the previous one
+	assert.Nil(t, pending.Add(pendingResponse("peer1", []byte("rwset"))))
+	assert.Equal(t, 1, len(pending.Responses))
+
+	// Mismatched responses aren't added
+	err := pending.Add(pendingResponse("peer2", []byte("rwset")), pendingResponse("peer3", []byte("other")))
+	s, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", err)
+	}
+	assert.Equal(t, status.EndorsementMismatch.ToInt32(), s.Code)
+	assert.Equal(t, 1, len(pending.Responses))
+
+	assert.Nil(t, pending.Add(pendingResponse("peer2", []byte("rwset"))))
+	assert.True(t, pending.Satisfies(satisfier))
+
+	// Round trip
+	data, err := pending.Marshal()
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	restored, err := UnmarshalPendingEndorsements(data)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.Equal(t, pending.Proposal.TxnID, restored.Proposal.TxnID)
+	assert.Equal(t, pending.Proposal.Payload, restored.Proposal.Payload)
+	if assert.Equal(t, 2, len(restored.Responses)) {
+		assert.Equal(t, "peer2", restored.Responses[1].Endorser)
+		assert.Equal(t, []byte("rwset"), restored.Responses[1].ProposalResponse.Payload)
+	}
+
+	_, err = UnmarshalPendingEndorsements([]byte("invalid"))
+	assert.NotNil(t, err)
+}
+
+func TestPendingEndorsementHandler(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a")}}
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org2MSP", Status: 500}
+
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	proposal, err := BuildChaincodeProposal(prepareRequestContext(request, Opts{}, t), clientContext)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	pending := NewPendingEndorsements(proposal)
+
+	// First call: one endorsement
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peer1, peer2}}, t)
+	NewPendingEndorsementHandler(pending).Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.Equal(t, proposal.TxnID, requestContext.Response.TransactionID)
+	assert.Equal(t, 1, len(pending.Responses))
+	assert.Equal(t, 2, len(requestContext.Response.Endorsements))
+
+	// Second call: another endorsement is added to the pending endorsements
+	peer2.Status = 200
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{peer2}}, t)
+	NewPendingEndorsementHandler(pending).Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.Equal(t, 2, len(pending.Responses))
+	assert.Equal(t, 2, len(requestContext.Response.Responses))
+
+	// All of the targets fail
+	peer2.Status = 500
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{peer2}}, t)
+	NewPendingEndorsementHandler(pending).Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error)
+	assert.Equal(t, 2, len(pending.Responses))
+}
+
+func pendingResponse(endorser string, payload []byte) *fab.TransactionProposalResponse {
+	return &fab.TransactionProposalResponse{
+		Endorser:         endorser,
+		Status:           200,
+		ProposalResponse: &pb.ProposalResponse{Payload: payload, Response: &pb.Response{Status: 200}},
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+)
+
+// proposalProcessors returns the proposal processors for the targets in the request options
+func proposalProcessors(requestContext *RequestContext, clientContext *ClientContext) []fab.ProposalProcessor {
+	targets := requestContext.Opts.Targets
+	processors := peer.PeersToTxnProcessors(targets)
+	if decorator := requestContext.Opts.ProcessorDecorator; decorator != nil {
+		processors = withDecorator(processors, targets, decorator)
+	}
+	if interpreter := requestContext.Opts.RedirectInterpreter; interpreter != nil {
+		processors = withRedirect(processors, clientContext.Discovery, interpreter, newLogFields(requestContext))
+	}
+	processors = withCancellation(processors)
+	if timeouts := requestContext.Opts.OrgEndorsementTimeouts; len(timeouts) > 0 {
+		processors = withOrgDeadlines(processors, targets, timeouts)
+	}
+	if commManager := clientContext.EndorserCommManager; commManager != nil {
+		processors = withCommManager(processors, commManager)
+	}
+	if tracker := clientContext.LatencyTracker; tracker != nil {
+		processors = withLatencyTracking(processors, targets, tracker, newLogFields(requestContext))
+	}
+	if tracker := clientContext.ErrorRateTracker; tracker != nil {
+		processors = withErrorRateTracking(processors, targets, tracker)
+	}
+	if n := requestContext.Opts.EndorserConcurrency; n > 0 && n < len(processors) {
+		processors = withConcurrencyLimit(processors, n)
+	}
+	if ctx := requestContext.Opts.EndorsementContext; ctx != nil {
+		processors = withEndorsementContext(processors, ctx)
+	}
+	return processors
+}
+
+// limitedProcessor is a proposal processor that waits for a slot in a
+// shared semaphore before sending the proposal to the target
+type limitedProcessor struct {
+	target    fab.ProposalProcessor
+	semaphore chan struct{}
+}
+
+// withConcurrencyLimit wraps the processors so that no more than n of them process a proposal at once
+func withConcurrencyLimit(processors []fab.ProposalProcessor, n int) []fab.ProposalProcessor {
+	semaphore := make(chan struct{}, n)
+	limited := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		limited[i] = &limitedProcessor{target: p, semaphore: semaphore}
+	}
+	return limited
+}
+
+// ProcessTransactionProposal sends the proposal to the target once a slot is available
+func (p *limitedProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	select {
+	case p.semaphore <- struct{}{}:
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "waiting to send proposal")
+	}
+	defer func() { <-p.semaphore }()
+
+	return p.target.ProcessTransactionProposal(ctx, request)
+}
+
+func (p *limitedProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
+
+// cancellableProcessor is a proposal processor that returns as soon as the request context
+// is done, even if the target hasn't responded yet
+type cancellableProcessor struct {
+	target fab.ProposalProcessor
+}
+
+// processResult contains the result of processing a proposal
+type processResult struct {
+	resp *fab.TransactionProposalResponse
+	err  error
+}
+
+// commManagerProcessor is a proposal processor that connects to the target
+// through the given CommManager
+type commManagerProcessor struct {
+	target      fab.ProposalProcessor
+	commManager fab.CommManager
+}
+
+// withCommManager wraps the processors so that the connections to the targets are obtained
+// from the given CommManager (for example, a connection pool) instead of the default one
+func withCommManager(processors []fab.ProposalProcessor, commManager fab.CommManager) []fab.ProposalProcessor {
+	wrapped := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		wrapped[i] = &commManagerProcessor{target: p, commManager: commManager}
+	}
+	return wrapped
+}
+
+// ProcessTransactionProposal sends the proposal to the target using the CommManager
+func (p *commManagerProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	return p.target.ProcessTransactionProposal(contextImpl.RequestWithCommManager(ctx, p.commManager), request)
+}
+
+// decoratedProcessor is a proposal processor returned by a ProcessorDecorator. It keeps
+// track of the target so that the target peer can still be resolved from the processor.
+type decoratedProcessor struct {
+	fab.ProposalProcessor
+	target fab.Peer
+}
+
+// withDecorator replaces the processor of each target by the processor returned by the decorator
+func withDecorator(processors []fab.ProposalProcessor, targets []fab.Peer, decorator ProcessorDecorator) []fab.ProposalProcessor {
+	decorated := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		decorated[i] = &decoratedProcessor{ProposalProcessor: decorator(targets[i], p), target: targets[i]}
+	}
+	return decorated
+}
+
+func (p *decoratedProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
+
+// withCancellation wraps the processors so that they abort when the request context is done
+func withCancellation(processors []fab.ProposalProcessor) []fab.ProposalProcessor {
+	cancellable := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		cancellable[i] = &cancellableProcessor{target: p}
+	}
+	return cancellable
+}
+
+// ProcessTransactionProposal sends the proposal to the target and waits for the response or for the
+// request context to be done. The context is passed to the target so that the in-flight call is also
+// cancelled; the result channel is buffered so that the sending goroutine exits once the target returns.
+func (p *cancellableProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "proposal processing cancelled")
+	}
+
+	result := make(chan processResult, 1)
+	go func() {
+		resp, err := p.target.ProcessTransactionProposal(ctx, request)
+		result <- processResult{resp: resp, err: err}
+	}()
+
+	select {
+	case r := <-result:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "proposal processing cancelled")
+	}
+}
+
+func (p *cancellableProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
+
+// deadlineProcessor is a proposal processor that bounds the time the target has to respond
+type deadlineProcessor struct {
+	target  fab.ProposalProcessor
+	timeout time.Duration
+}
+
+// withOrgDeadlines wraps the processor of each target whose MSP ID has an entry in timeouts so that
+// the proposal is abandoned once the timeout of its org elapses
+func withOrgDeadlines(processors []fab.ProposalProcessor, targets []fab.Peer, timeouts map[string]time.Duration) []fab.ProposalProcessor {
+	wrapped := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		timeout, ok := timeouts[targets[i].MSPID()]
+		if !ok || timeout <= 0 {
+			wrapped[i] = p
+			continue
+		}
+		wrapped[i] = &deadlineProcessor{target: p, timeout: timeout}
+	}
+	return wrapped
+}
+
+// ProcessTransactionProposal sends the proposal to the target with the timeout of its org. The request
+// context still applies, so the timeout of an org can shorten but not extend the overall deadline.
+func (p *deadlineProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	ctx, cancel := reqContext.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	return p.target.ProcessTransactionProposal(ctx, request)
+}
+
+func (p *deadlineProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
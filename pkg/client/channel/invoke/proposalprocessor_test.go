@@ -0,0 +1,268 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+// countingProcessor records the maximum number of proposals that it processes at the same time
+type countingProcessor struct {
+	mutex   *sync.Mutex
+	current *int
+	max     *int
+}
+
+func (p *countingProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.mutex.Lock()
+	*p.current++
+	if *p.current > *p.max {
+		*p.max = *p.current
+	}
+	p.mutex.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	p.mutex.Lock()
+	*p.current--
+	p.mutex.Unlock()
+
+	return &fab.TransactionProposalResponse{}, nil
+}
+
+func TestWithConcurrencyLimit(t *testing.T) {
+	var mutex sync.Mutex
+	var current, max int
+
+	var processors []fab.ProposalProcessor
+	for i := 0; i < 10; i++ {
+		processors = append(processors, &countingProcessor{mutex: &mutex, current: &current, max: &max})
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range withConcurrencyLimit(processors, 3) {
+		wg.Add(1)
+		go func(p fab.ProposalProcessor) {
+			defer wg.Done()
+			_, err := p.ProcessTransactionProposal(reqContext.Background(), fab.ProcessProposalRequest{})
+			assert.Nil(t, err)
+		}(p)
+	}
+	wg.Wait()
+
+	assert.True(t, max <= 3, "expecting at most 3 concurrent proposals but got %d", max)
+	assert.True(t, max > 0)
+}
+
+func TestWithConcurrencyLimitCancelled(t *testing.T) {
+	var mutex sync.Mutex
+	var current, max int
+
+	processors := withConcurrencyLimit([]fab.ProposalProcessor{&countingProcessor{mutex: &mutex, current: &current, max: &max}}, 1)
+	processors[0].(*limitedProcessor).semaphore <- struct{}{}
+
+	ctx, cancel := reqContext.WithCancel(reqContext.Background())
+	cancel()
+	_, err := processors[0].ProcessTransactionProposal(ctx, fab.ProcessProposalRequest{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, max)
+}
+
+// hungProcessor blocks until it is released
+type hungProcessor struct {
+	release  chan struct{}
+	returned chan struct{}
+}
+
+func (p *hungProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	defer close(p.returned)
+	<-p.release
+	return &fab.TransactionProposalResponse{}, nil
+}
+
+func TestWithCancellation(t *testing.T) {
+	target := &hungProcessor{release: make(chan struct{}), returned: make(chan struct{})}
+	processors := withCancellation([]fab.ProposalProcessor{target})
+
+	ctx, cancel := reqContext.WithCancel(reqContext.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := processors[0].ProcessTransactionProposal(ctx, fab.ProcessProposalRequest{})
+	assert.NotNil(t, err, "expecting cancellation error")
+	assert.True(t, time.Since(start) < time.Second, "expecting proposal processing to be aborted promptly")
+
+	// The sending goroutine exits once the target returns
+	close(target.release)
+	select {
+	case <-target.returned:
+	case <-time.After(time.Second):
+		t.Fatal("expecting the target to return after being released")
+	}
+
+	_, err = processors[0].ProcessTransactionProposal(ctx, fab.ProcessProposalRequest{})
+	assert.NotNil(t, err, "expecting proposal not to be sent with a cancelled context")
+}
+
+// commManagerRecorder records the CommManager of the request context passed to it
+type commManagerRecorder struct {
+	commManager fab.CommManager
+}
+
+func (p *commManagerRecorder) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.commManager, _ = contextImpl.RequestCommManager(ctx)
+	return &fab.TransactionProposalResponse{}, nil
+}
+
+func TestWithCommManager(t *testing.T) {
+	pool := &comm.MockCommManager{}
+	recorder := &commManagerRecorder{}
+
+	processors := withCommManager([]fab.ProposalProcessor{recorder}, pool)
+	_, err := processors[0].ProcessTransactionProposal(reqContext.Background(), fab.ProcessProposalRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, pool, recorder.commManager, "expecting the connection to be obtained from the pool")
+}
+
+// recordingProcessor records the proposals sent to the target
+type recordingProcessor struct {
+	target fab.ProposalProcessor
+	mutex  *sync.Mutex
+	sent   map[string]int
+	url    string
+}
+
+func (p *recordingProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.mutex.Lock()
+	p.sent[p.url]++
+	p.mutex.Unlock()
+	return p.target.ProcessTransactionProposal(ctx, request)
+}
+
+func TestProcessorDecorator(t *testing.T) {
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org2MSP", Status: 200, Payload: []byte("value")}
+	peers := []fab.Peer{peer1, peer2}
+
+	var mutex sync.Mutex
+	sent := make(map[string]int)
+	decorator := func(target fab.Peer, processor fab.ProposalProcessor) fab.ProposalProcessor {
+		return &recordingProcessor{target: processor, mutex: &mutex, sent: sent, url: target.URL()}
+	}
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	requestContext := prepareRequestContext(request, Opts{ProcessorDecorator: decorator}, t)
+	NewQueryHandler().Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	assert.Equal(t, map[string]int{"http://peer1.com": 1, "http://peer2.com": 1}, sent)
+	assert.Equal(t, 1, peer1.ProcessProposalCalls)
+
+	// The target peer is still resolved from the decorated processor
+	processors := proposalProcessors(requestContext, &ClientContext{})
+	for i, processor := range processors {
+		target, ok := targetPeer(processor)
+		if assert.True(t, ok, "expected target peer to be resolved") {
+			assert.Equal(t, requestContext.Opts.Targets[i].URL(), target.URL())
+		}
+	}
+}
+
+// deadlineRecorder records the deadline of the request context passed to it
+type deadlineRecorder struct {
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (p *deadlineRecorder) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.deadline, p.hasDeadline = ctx.Deadline()
+	return &fab.TransactionProposalResponse{}, nil
+}
+
+func TestWithOrgDeadlines(t *testing.T) {
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP"}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org2MSP"}
+	recorder1 := &deadlineRecorder{}
+	recorder2 := &deadlineRecorder{}
+
+	processors := withOrgDeadlines([]fab.ProposalProcessor{recorder1, recorder2}, []fab.Peer{peer1, peer2}, map[string]time.Duration{"Org1MSP": time.Minute})
+	for _, p := range processors {
+		_, err := p.ProcessTransactionProposal(reqContext.Background(), fab.ProcessProposalRequest{})
+		assert.Nil(t, err)
+	}
+	assert.True(t, recorder1.hasDeadline, "expecting the timeout of Org1MSP to be applied")
+	assert.True(t, time.Until(recorder1.deadline) <= time.Minute)
+	assert.False(t, recorder2.hasDeadline, "expecting no timeout for Org2MSP")
+
+	// A target of an org with a short timeout is abandoned
+	target := &hungProcessor{release: make(chan struct{}), returned: make(chan struct{})}
+	defer close(target.release)
+	processors = withOrgDeadlines(withCancellation([]fab.ProposalProcessor{target}), []fab.Peer{peer1}, map[string]time.Duration{"Org1MSP": 10 * time.Millisecond})
+
+	start := time.Now()
+	_, err := processors[0].ProcessTransactionProposal(reqContext.Background(), fab.ProcessProposalRequest{})
+	assert.NotNil(t, err, "expecting the proposal to time out")
+	assert.True(t, time.Since(start) < time.Second, "expecting the proposal to be abandoned promptly")
+}
+
+type testContextKey string
+
+// contextRecorder records the context passed to it
+type contextRecorder struct {
+	ctx reqContext.Context
+}
+
+func (p *contextRecorder) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.ctx = ctx
+	return &fab.TransactionProposalResponse{}, nil
+}
+
+func TestWithEndorsementContext(t *testing.T) {
+	requestCtx, cancelRequest := reqContext.WithTimeout(reqContext.WithValue(reqContext.Background(), testContextKey("request"), "r"), time.Minute)
+	defer cancelRequest()
+	endorsementCtx, cancelEndorsement := reqContext.WithTimeout(reqContext.WithValue(requestCtx, testContextKey("endorsement"), "e"), time.Second)
+	defer cancelEndorsement()
+
+	recorder := &contextRecorder{}
+	processors := withEndorsementContext([]fab.ProposalProcessor{recorder}, endorsementCtx)
+	_, err := processors[0].ProcessTransactionProposal(requestCtx, fab.ProcessProposalRequest{})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "r", recorder.ctx.Value(testContextKey("request")))
+	assert.Equal(t, "e", recorder.ctx.Value(testContextKey("endorsement")))
+	deadline, ok := recorder.ctx.Deadline()
+	expected, _ := endorsementCtx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, expected, deadline, "expecting the earlier deadline of the endorsement context")
+	assert.NotNil(t, recorder.ctx.Err(), "expecting the context to be done once the proposal was processed")
+
+	// Cancelling the endorsement context aborts the proposal but not the request
+	target := &hungProcessor{release: make(chan struct{}), returned: make(chan struct{})}
+	defer close(target.release)
+	cancelledCtx, cancel := reqContext.WithCancel(requestCtx)
+	processors = withEndorsementContext(withCancellation([]fab.ProposalProcessor{target}), cancelledCtx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = processors[0].ProcessTransactionProposal(requestCtx, fab.ProcessProposalRequest{})
+	assert.NotNil(t, err, "expecting the proposal to be cancelled")
+	assert.Nil(t, requestCtx.Err())
+}
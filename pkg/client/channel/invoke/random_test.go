@@ -0,0 +1,83 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+// shufflingSelection is a selection service that returns its peers in a random order, using the source of
+// randomness of the selection options or the shared source if none is given
+type shufflingSelection struct {
+	peers  []fab.Peer
+	random *rand.Rand
+}
+
+func (s *shufflingSelection) GetEndorsersForChaincode(chaincodeIDs []string, opts ...options.Opt) ([]fab.Peer, error) {
+	params := selectopts.NewParams(opts)
+	s.random = params.Random
+
+	perm := rand.Perm(len(s.peers))
+	if params.Random != nil {
+		perm = params.Random.Perm(len(s.peers))
+	}
+	shuffled := make([]fab.Peer, len(s.peers))
+	for i, j := range perm {
+		shuffled[i] = s.peers[j]
+	}
+	return shuffled, nil
+}
+
+func TestSelectionRandom(t *testing.T) {
+	var peers []fab.Peer
+	for _, url := range []string{"peer1:7051", "peer2:7051", "peer3:7051", "peer4:7051", "peer5:7051"} {
+		peers = append(peers, fcmocks.NewMockPeer(url, url))
+	}
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	selectOrders := func(random *rand.Rand) ([][]string, *shufflingSelection) {
+		selection := &shufflingSelection{peers: peers}
+		clientContext := setupChannelClientContext(nil, nil, peers, t)
+		clientContext.Selection = selection
+		clientContext.SelectionRandom = random
+
+		var orders [][]string
+		for i := 0; i < 5; i++ {
+			requestContext := prepareRequestContext(request, Opts{}, t)
+			NewProposalProcessorHandler().Handle(requestContext, clientContext)
+			if requestContext.Error != nil {
+				t.Fatalf("Got error: %s", requestContext.Error)
+			}
+			var order []string
+			for _, target := range requestContext.Opts.Targets {
+				order = append(order, target.URL())
+			}
+			orders = append(orders, order)
+		}
+		return orders, selection
+	}
+
+	// The same seed selects the endorsers in the same order
+	seeded := NewSeededRandom(42)
+	orders, selection := selectOrders(seeded)
+	assert.True(t, seeded == selection.random, "expecting the seeded source to be passed to the selection service")
+	reproduced, _ := selectOrders(NewSeededRandom(42))
+	assert.Equal(t, orders, reproduced, "expecting a seeded source to reproduce the selection")
+
+	// Without a seeded source the selection service falls back to its shared source
+	orders, selection = selectOrders(nil)
+	assert.Nil(t, selection.random, "expecting no source of randomness to be passed to the selection service")
+	assert.Equal(t, 5, len(orders))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+// ChaincodeRateLimiter limits the rate at which each chaincode with a limit is invoked, independently of the
+// concurrency of the client. It keeps a token bucket per chaincode ID: an invoke takes a token from the bucket
+// of its chaincode before it's endorsed and, if the bucket is empty, waits until a token is added (or the
+// request times out). The invokes of the chaincodes without a limit aren't throttled.
+type ChaincodeRateLimiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket contains the tokens available for the invokes of a chaincode
+type tokenBucket struct {
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+// NewChaincodeRateLimiter returns a new rate limiter without any chaincode limits
+func NewChaincodeRateLimiter() *ChaincodeRateLimiter {
+	return &ChaincodeRateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// SetLimit limits the invokes of the given chaincode to perSecond invokes per second on average, with bursts of
+// up to burst (at least one) invokes. A rate that isn't positive removes the limit of the chaincode.
+func (l *ChaincodeRateLimiter) SetLimit(chaincodeID string, perSecond float64, burst int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if perSecond <= 0 {
+		delete(l.buckets, chaincodeID)
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	l.buckets[chaincodeID] = &tokenBucket{perSecond: perSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve takes a token from the bucket of the given chaincode and returns how long the invoke must wait
+// until the token is available. False is returned if the chaincode doesn't have a limit.
+func (l *ChaincodeRateLimiter) reserve(chaincodeID string, now time.Time) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b, ok := l.buckets[chaincodeID]
+	if !ok {
+		return 0, false
+	}
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.perSecond)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0, true
+	}
+	return time.Duration(-b.tokens / b.perSecond * float64(time.Second)), true
+}
+
+// cancel gives back a token taken by reserve
+func (l *ChaincodeRateLimiter) cancel(chaincodeID string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if b, ok := l.buckets[chaincodeID]; ok {
+		b.tokens = math.Min(b.burst, b.tokens+1)
+	}
+}
+
+// wait takes a token from the bucket of the given chaincode, waiting for it to be available
+func (l *ChaincodeRateLimiter) wait(ctx reqContext.Context, chaincodeID string) error {
+	delay, ok := l.reserve(chaincodeID, time.Now())
+	if !ok || delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(chaincodeID)
+		return status.New(status.ClientStatus, status.Timeout.ToInt32(),
+			fmt.Sprintf("request timed out or been cancelled while waiting for the rate limit of chaincode %s", chaincodeID), nil)
+	}
+}
+
+// waitForRateLimit waits for the rate limit (if any) of the chaincode of the request
+func waitForRateLimit(requestContext *RequestContext, clientContext *ClientContext) error {
+	limiter := clientContext.ChaincodeRateLimiter
+	if limiter == nil {
+		return nil
+	}
+	return limiter.wait(requestContext.Ctx, requestContext.Request.ChaincodeID)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+func TestChaincodeRateLimiterReserve(t *testing.T) {
+	limiter := NewChaincodeRateLimiter()
+	limiter.SetLimit("expensiveCC", 10, 2)
+	start := limiter.buckets["expensiveCC"].last
+
+	// The burst is available immediately
+	for i := 0; i < 2; i++ {
+		delay, ok := limiter.reserve("expensiveCC", start)
+		assert.True(t, ok)
+		assert.Equal(t, time.Duration(0), delay)
+	}
+	delay, _ := limiter.reserve("expensiveCC", start)
+	assert.Equal(t, 100*time.Millisecond, delay)
+	delay, _ = limiter.reserve("expensiveCC", start)
+	assert.Equal(t, 200*time.Millisecond, delay)
+
+	// The bucket refills up to the burst
+	delay, _ = limiter.reserve("expensiveCC", start.Add(time.Minute))
+	assert.Equal(t, time.Duration(0), delay)
+	delay, _ = limiter.reserve("expensiveCC", start.Add(time.Minute))
+	assert.Equal(t, time.Duration(0), delay)
+
+	_, ok := limiter.reserve("cheapCC", start)
+	assert.False(t, ok, "expecting no limit for the chaincode")
+
+	limiter.SetLimit("expensiveCC", 0, 0)
+	_, ok = limiter.reserve("expensiveCC", start)
+	assert.False(t, ok, "expecting the limit to be removed")
+}
+
+func TestChaincodeRateLimiterWait(t *testing.T) {
+	limiter := NewChaincodeRateLimiter()
+	limiter.SetLimit("expensiveCC", 20, 1)
+
+	assert.Nil(t, limiter.wait(reqContext.Background(), "expensiveCC"))
+	start := time.Now()
+	assert.Nil(t, limiter.wait(reqContext.Background(), "expensiveCC"))
+	assert.True(t, time.Since(start) >= 40*time.Millisecond, "expecting the invoke to wait for a token")
+
+	// Times out while waiting for a token, which is given back
+	limiter.SetLimit("expensiveCC", 0.001, 1)
+	assert.Nil(t, limiter.wait(reqContext.Background(), "expensiveCC"))
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := limiter.wait(ctx, "expensiveCC")
+	s, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", err)
+	}
+	assert.Equal(t, status.Timeout.ToInt32(), s.Code)
+	assert.True(t, limiter.buckets["expensiveCC"].tokens > -1, "expecting the token to be given back")
+}
+
+func TestEndorsementHandlerChaincodeRateLimit(t *testing.T) {
+	peers := []fab.Peer{&fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}}
+
+	handler := NewProposalProcessorHandler(NewEndorsementHandler())
+	clientContext := setupChannelClientContext(nil, nil, peers, t)
+	clientContext.ChaincodeRateLimiter = NewChaincodeRateLimiter()
+	clientContext.ChaincodeRateLimiter.SetLimit("expensiveCC", 0.001, 1)
+
+	expensive := Request{ChaincodeID: "expensiveCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	requestContext := prepareRequestContext(expensive, Opts{}, t)
+	handler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+
+	// The limit of the expensive chaincode is reached
+	requestContext = prepareRequestContext(expensive, Opts{}, t)
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), 10*time.Millisecond)
+	defer cancel()
+	requestContext.Ctx = ctx
+	handler.Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.Timeout.ToInt32(), s.Code)
+
+	// Cheap chaincodes aren't throttled
+	cheap := Request{ChaincodeID: "cheapCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	for i := 0; i < 3; i++ {
+		requestContext = prepareRequestContext(cheap, Opts{}, t)
+		handler.Handle(requestContext, clientContext)
+		assert.Nil(t, requestContext.Error)
+	}
+}
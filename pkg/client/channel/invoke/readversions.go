@@ -0,0 +1,93 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// ReadVersion is the version (block and transaction number of the last update) at which a key was read
+type ReadVersion struct {
+	BlockNum uint64
+	TxNum    uint64
+}
+
+// ReadVersionConflict reports a key that the endorsers read at different versions, which predicts a
+// likely MVCC conflict when the transaction is committed
+type ReadVersionConflict struct {
+	Namespace string
+	Key       string
+	// Versions contains the version read by each endorser that read the key (nil if the key didn't exist)
+	Versions map[string]*ReadVersion
+}
+
+// readVersionConflicts returns the keys that the endorsers of the given responses read at different versions,
+// sorted by namespace and key. Responses whose RW set can't be decoded are ignored.
+func readVersionConflicts(fields logFields, responses []*fab.TransactionProposalResponse) []ReadVersionConflict {
+	type nsKey struct{ namespace, key string }
+	versions := make(map[nsKey]map[string]*ReadVersion)
+	for _, r := range responses {
+		rwSet, err := txRwSet(r)
+		if err != nil {
+			fields.withEndorser(r).debugf("unable to extract the read set: %s", err)
+			continue
+		}
+		for _, nsRwSet := range rwSet.NsRwSets {
+			if nsRwSet.KvRwSet == nil {
+				continue
+			}
+			for _, read := range nsRwSet.KvRwSet.Reads {
+				k := nsKey{namespace: nsRwSet.NameSpace, key: read.Key}
+				if versions[k] == nil {
+					versions[k] = make(map[string]*ReadVersion)
+				}
+				var version *ReadVersion
+				if v := read.GetVersion(); v != nil {
+					version = &ReadVersion{BlockNum: v.BlockNum, TxNum: v.TxNum}
+				}
+				versions[k][r.Endorser] = version
+			}
+		}
+	}
+
+	var conflicts []ReadVersionConflict
+	for k, byEndorser := range versions {
+		if !sameReadVersions(byEndorser) {
+			conflicts = append(conflicts, ReadVersionConflict{Namespace: k.namespace, Key: k.key, Versions: byEndorser})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Namespace != conflicts[j].Namespace {
+			return conflicts[i].Namespace < conflicts[j].Namespace
+		}
+		return conflicts[i].Key < conflicts[j].Key
+	})
+	return conflicts
+}
+
+// sameReadVersions returns true if all of the given versions are the same
+func sameReadVersions(versions map[string]*ReadVersion) bool {
+	var first *ReadVersion
+	n := 0
+	for _, v := range versions {
+		if n > 0 && !readVersionEqual(first, v) {
+			return false
+		}
+		first = v
+		n++
+	}
+	return true
+}
+
+func readVersionEqual(v1, v2 *ReadVersion) bool {
+	if v1 == nil || v2 == nil {
+		return v1 == v2
+	}
+	return *v1 == *v2
+}
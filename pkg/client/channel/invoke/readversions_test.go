@@ -0,0 +1,53 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+)
+
+func TestReadConflictReport(t *testing.T) {
+	writes := []*kvrwset.KVWrite{{Key: "key3", Value: []byte("value3")}}
+	r1 := rwSetResponse("peer1", []byte("payload"), &kvrwset.KVRWSet{
+		Reads: []*kvrwset.KVRead{
+			{Key: "key1", Version: &kvrwset.Version{BlockNum: 1}},
+			{Key: "key2", Version: &kvrwset.Version{BlockNum: 5, TxNum: 1}},
+		},
+		Writes: writes,
+	}, t)
+	r2 := rwSetResponse("peer2", []byte("payload"), &kvrwset.KVRWSet{
+		Reads: []*kvrwset.KVRead{
+			{Key: "key1", Version: &kvrwset.Version{BlockNum: 1}},
+			{Key: "key2", Version: &kvrwset.Version{BlockNum: 6}},
+			{Key: "key4"},
+		},
+		Writes: writes,
+	}, t)
+	r3 := rwSetResponse("peer3", []byte("payload"), &kvrwset.KVRWSet{
+		Reads:  []*kvrwset.KVRead{{Key: "key4", Version: &kvrwset.Version{BlockNum: 7}}},
+		Writes: writes,
+	}, t)
+	responses := []*fab.TransactionProposalResponse{r1, r2, r3}
+
+	requestContext := &RequestContext{Opts: Opts{CompareWriteSets: true}, Response: Response{Responses: responses}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	assert.Nil(t, requestContext.Error)
+	assert.Empty(t, requestContext.Response.ReadConflicts, "read conflicts should only be reported if requested")
+
+	requestContext = &RequestContext{Opts: Opts{CompareWriteSets: true, ReportReadConflicts: true}, Response: Response{Responses: responses}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	assert.Nil(t, requestContext.Error, "read conflicts should not fail the request")
+	assert.Equal(t, []ReadVersionConflict{
+		{Namespace: "testCC", Key: "key2", Versions: map[string]*ReadVersion{"peer1": {BlockNum: 5, TxNum: 1}, "peer2": {BlockNum: 6}}},
+		{Namespace: "testCC", Key: "key4", Versions: map[string]*ReadVersion{"peer2": nil, "peer3": {BlockNum: 7}}},
+	}, requestContext.Response.ReadConflicts)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+)
+
+// RedirectInterpreter recognizes a redirect (for example, not-leader) hint in the response of an endorser.
+// It returns the URL of the peer to which the proposal should be sent instead, and true if the response
+// is a redirect.
+type RedirectInterpreter func(response *fab.TransactionProposalResponse) (string, bool)
+
+// RedirectOnMessage returns a RedirectInterpreter that recognizes an unsuccessful response whose message
+// starts with the given signal followed by the URL of the peer, for example "NOT_LEADER grpcs://peer2:7051"
+func RedirectOnMessage(signal string) RedirectInterpreter {
+	return func(response *fab.TransactionProposalResponse) (string, bool) {
+		r := response.ProposalResponse.GetResponse()
+		if r.GetStatus() < 300 || !strings.HasPrefix(r.GetMessage(), signal) {
+			return "", false
+		}
+		url := strings.TrimSpace(strings.TrimPrefix(r.GetMessage(), signal))
+		return url, url != ""
+	}
+}
+
+// redirectingProcessor is a proposal processor that re-dispatches the proposal once to the peer
+// indicated by a redirect hint in the response of the target
+type redirectingProcessor struct {
+	target      fab.ProposalProcessor
+	discovery   fab.DiscoveryService
+	interpreter RedirectInterpreter
+	fields      logFields
+}
+
+// withRedirect wraps the processors so that the redirect hints recognized by the interpreter are followed
+func withRedirect(processors []fab.ProposalProcessor, discovery fab.DiscoveryService, interpreter RedirectInterpreter, fields logFields) []fab.ProposalProcessor {
+	wrapped := make([]fab.ProposalProcessor, len(processors))
+	for i, p := range processors {
+		wrapped[i] = &redirectingProcessor{target: p, discovery: discovery, interpreter: interpreter, fields: fields}
+	}
+	return wrapped
+}
+
+// ProcessTransactionProposal sends the proposal to the target and, if the target responds with a redirect,
+// sends the same proposal to the indicated peer. A redirect in the response of that peer isn't followed.
+func (p *redirectingProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	resp, err := p.target.ProcessTransactionProposal(ctx, request)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	url, ok := p.interpreter(resp)
+	if !ok {
+		return resp, nil
+	}
+
+	p.fields.withEndorser(resp).debugf("endorser redirected the proposal to [%s]", url)
+	redirectTarget, err := p.resolve(url)
+	if err != nil {
+		return nil, errors.WithMessage(err, "following redirect failed")
+	}
+	return redirectTarget.ProcessTransactionProposal(ctx, request)
+}
+
+// resolve returns the discovered peer with the given URL
+func (p *redirectingProcessor) resolve(url string) (fab.Peer, error) {
+	if p.discovery == nil {
+		return nil, errors.Errorf("no discovery service to resolve redirect target [%s]", url)
+	}
+	peers, err := p.discovery.GetPeers()
+	if err != nil {
+		return nil, errors.WithMessage(err, "getting peers from discovery service failed")
+	}
+	for _, peer := range peers {
+		if endpoint.ToAddress(peer.URL()) == endpoint.ToAddress(url) {
+			return peer, nil
+		}
+	}
+	return nil, errors.Errorf("redirect target [%s] isn't a discovered peer", url)
+}
+
+func (p *redirectingProcessor) unwrap() fab.ProposalProcessor {
+	return p.target
+}
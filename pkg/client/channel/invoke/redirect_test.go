@@ -0,0 +1,50 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestEndorsementHandlerWithRedirect(t *testing.T) {
+	follower := &fcmocks.MockPeer{MockName: "Follower", MockURL: "http://follower.com", MockMSP: "Org1MSP", Status: 307, ResponseMessage: "NOT_LEADER http://leader.com"}
+	leader := &fcmocks.MockPeer{MockName: "Leader", MockURL: "http://leader.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	opts := Opts{Targets: []fab.Peer{follower}, RedirectInterpreter: RedirectOnMessage("NOT_LEADER")}
+
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	clientContext.Discovery = fcmocks.NewMockDiscoveryService(nil, []fab.Peer{follower, leader})
+
+	requestContext := prepareRequestContext(request, opts, t)
+	NewQueryHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	if assert.Len(t, requestContext.Response.Responses, 1) {
+		assert.Equal(t, "http://leader.com", requestContext.Response.Responses[0].Endorser)
+	}
+	assert.Equal(t, 1, follower.ProcessProposalCalls)
+	assert.Equal(t, 1, leader.ProcessProposalCalls)
+
+	// The redirect target must be a discovered peer
+	clientContext.Discovery = fcmocks.NewMockDiscoveryService(nil, []fab.Peer{follower})
+	requestContext = prepareRequestContext(request, opts, t)
+	NewQueryHandler().Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error)
+
+	// Without an interpreter the redirect is an unsuccessful response
+	clientContext.Discovery = fcmocks.NewMockDiscoveryService(nil, []fab.Peer{follower, leader})
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{follower}}, t)
+	NewQueryHandler().Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error)
+	assert.Equal(t, 1, leader.ProcessProposalCalls)
+}
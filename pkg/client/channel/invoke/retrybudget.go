@@ -0,0 +1,51 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+// RetryBudget limits the total number of retries performed by the handlers of an invoke so that a misconfigured
+// handler chain can't retry forever. Each handler that retries (including the handler chain itself) must consume
+// the budget before retrying and give up if it's exhausted. A nil budget is unlimited.
+type RetryBudget struct {
+	max       int32
+	remaining int32
+}
+
+// NewRetryBudget returns a retry budget that allows the given number of retries
+func NewRetryBudget(max int) *RetryBudget {
+	return &RetryBudget{max: int32(max), remaining: int32(max)}
+}
+
+// Consume consumes one retry from the budget. If the budget is exhausted then an error with status
+// RetryBudgetExhausted is returned, which includes the error that caused the retry.
+func (b *RetryBudget) Consume(cause error) error {
+	if b == nil {
+		return nil
+	}
+	if atomic.AddInt32(&b.remaining, -1) < 0 {
+		return status.New(status.ClientStatus, status.RetryBudgetExhausted.ToInt32(),
+			fmt.Sprintf("retry budget of %d exhausted - last error: %v", b.max, cause), nil)
+	}
+	return nil
+}
+
+// Remaining returns the number of retries left in the budget (-1 if unlimited)
+func (b *RetryBudget) Remaining() int {
+	if b == nil {
+		return -1
+	}
+	if remaining := atomic.LoadInt32(&b.remaining); remaining > 0 {
+		return int(remaining)
+	}
+	return 0
+}
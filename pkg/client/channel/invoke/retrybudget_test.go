@@ -0,0 +1,36 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+func TestRetryBudget(t *testing.T) {
+	cause := errors.New("connection failed")
+
+	budget := NewRetryBudget(2)
+	assert.Nil(t, budget.Consume(cause))
+	assert.Nil(t, budget.Consume(cause))
+	assert.Equal(t, 0, budget.Remaining())
+
+	err := budget.Consume(cause)
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.RetryBudgetExhausted.ToInt32(), s.Code)
+	assert.Contains(t, s.Message, "connection failed")
+	assert.Equal(t, 0, budget.Remaining())
+
+	var unlimited *RetryBudget
+	assert.Nil(t, unlimited.Consume(cause))
+	assert.Equal(t, -1, unlimited.Remaining())
+}
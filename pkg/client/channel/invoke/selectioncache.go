@@ -0,0 +1,124 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+)
+
+// SelectionCacheKey identifies a selection result: the chaincode (and private data collections) and the version
+// of the channel topology (the peers returned by the discovery service) from which the endorsers were selected
+type SelectionCacheKey struct {
+	ChaincodeID string
+	Collections []string
+	Version     string
+}
+
+// id returns the identifier of the chaincode and collections of the key
+func (k SelectionCacheKey) id() string {
+	if len(k.Collections) == 0 {
+		return k.ChaincodeID
+	}
+	return k.ChaincodeID + ":" + strings.Join(k.Collections, ",")
+}
+
+// SelectionCache caches the endorsers selected for a chaincode so that the selection service
+// doesn't have to be called on every invoke
+type SelectionCache interface {
+	// Get returns the cached endorsers for the given key (false if none are cached or they expired)
+	Get(key SelectionCacheKey) ([]fab.Peer, bool)
+	// Put caches the endorsers for the given key
+	Put(key SelectionCacheKey, endorsers []fab.Peer)
+}
+
+// TTLSelectionCache is a SelectionCache whose entries expire after a TTL. Since the key includes
+// the version of the channel topology, the cached entries are invalidated as soon as a discovery
+// refresh reports a different set of peers.
+type TTLSelectionCache struct {
+	ttl time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]ttlSelectionEntry
+}
+
+type ttlSelectionEntry struct {
+	version   string
+	endorsers []fab.Peer
+	expiry    time.Time
+}
+
+// NewTTLSelectionCache returns a new selection cache whose entries expire after the given TTL
+func NewTTLSelectionCache(ttl time.Duration) *TTLSelectionCache {
+	return &TTLSelectionCache{ttl: ttl, entries: make(map[string]ttlSelectionEntry)}
+}
+
+// Get returns the cached endorsers for the given key
+func (c *TTLSelectionCache) Get(key SelectionCacheKey) ([]fab.Peer, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key.id()]
+	if !ok || entry.version != key.Version || !time.Now().Before(entry.expiry) {
+		return nil, false
+	}
+	return entry.endorsers, true
+}
+
+// Put caches the endorsers for the given key. Only the entry of the latest version is kept for a chaincode
+// (and collections).
+func (c *TTLSelectionCache) Put(key SelectionCacheKey, endorsers []fab.Peer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[key.id()] = ttlSelectionEntry{version: key.Version, endorsers: endorsers, expiry: time.Now().Add(c.ttl)}
+}
+
+// topologyVersion returns the version of the channel topology, derived from the addresses of the discovered peers
+func topologyVersion(discovery fab.DiscoveryService) (string, error) {
+	peers, err := discovery.GetPeers()
+	if err != nil {
+		return "", err
+	}
+	addresses := make([]string, len(peers))
+	for i, peer := range peers {
+		addresses[i] = endpoint.ToAddress(peer.URL())
+	}
+	sort.Strings(addresses)
+	return strings.Join(addresses, ","), nil
+}
+
+// getCachedEndorsers returns the cached endorsers for the chaincode (and collections) of the key if they're all
+// accepted by the filter. Otherwise the selection function is invoked and its result is cached.
+func getCachedEndorsers(fields logFields, cache SelectionCache, discovery fab.DiscoveryService, key SelectionCacheKey, selectEndorsers func() ([]fab.Peer, error), filter selectopts.PeerFilter) ([]fab.Peer, error) {
+	version, err := topologyVersion(discovery)
+	if err != nil {
+		fields.debugf("unable to determine the channel topology - not using the selection cache: %s", err)
+		return selectEndorsers()
+	}
+
+	key.Version = version
+	if endorsers, ok := cache.Get(key); ok && len(filterPeers(endorsers, filter)) == len(endorsers) {
+		fields.debugf("using cached endorsers for [%s]", key.id())
+		return endorsers, nil
+	}
+
+	endorsers, err := selectEndorsers()
+	if err != nil {
+		return nil, err
+	}
+	if len(endorsers) > 0 {
+		cache.Put(key, endorsers)
+	}
+	return endorsers, nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestSelectionCache(t *testing.T) {
+	ttl := 200 * time.Millisecond
+	peer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	peer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	discovery := &txnmocks.MockStaticDiscoveryService{Peers: []fab.Peer{peer1, peer2}}
+
+	calls := 0
+	selectEndorsers := func() ([]fab.Peer, error) {
+		calls++
+		return []fab.Peer{peer1}, nil
+	}
+
+	cache := NewTTLSelectionCache(ttl)
+
+	for i := 0; i < 2; i++ {
+		endorsers, err := getCachedEndorsers(logFields{}, cache, discovery, SelectionCacheKey{ChaincodeID: "testCC"}, selectEndorsers, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, []fab.Peer{peer1}, endorsers)
+	}
+	assert.Equal(t, 1, calls, "expecting the cached endorsers to be used")
+
+	// Cached endorsers that are filtered out aren't used
+	_, err := getCachedEndorsers(logFields{}, cache, discovery, SelectionCacheKey{ChaincodeID: "testCC"}, selectEndorsers, func(peer fab.Peer) bool { return false })
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+
+	// Other chaincodes aren't affected
+	_, err = getCachedEndorsers(logFields{}, cache, discovery, SelectionCacheKey{ChaincodeID: "otherCC"}, selectEndorsers, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+	_, err = getCachedEndorsers(logFields{}, cache, discovery, SelectionCacheKey{ChaincodeID: "testCC", Collections: []string{"coll1"}}, selectEndorsers, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, calls, "expecting the endorsers of collections to be cached separately")
+
+	// A change in the topology invalidates the cache
+	discovery.Peers = []fab.Peer{peer1}
+	_, err = getCachedEndorsers(logFields{}, cache, discovery, SelectionCacheKey{ChaincodeID: "testCC"}, selectEndorsers, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, calls, "expecting the selection service to be called after a topology change")
+	_, err = getCachedEndorsers(logFields{}, cache, discovery, SelectionCacheKey{ChaincodeID: "testCC"}, selectEndorsers, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, calls)
+
+	time.Sleep(ttl)
+	_, err = getCachedEndorsers(logFields{}, cache, discovery, SelectionCacheKey{ChaincodeID: "testCC"}, selectEndorsers, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, calls, "expecting the selection service to be called after the TTL expires")
+}
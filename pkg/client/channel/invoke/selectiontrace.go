@@ -0,0 +1,89 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// The strategies with which ProposalProcessorHandler selects endorsers, in the order in which they're attempted
+const (
+	// SelectionCollectionOwner selects the peers of the org owning the collections (Opts.CollectionOwnerMSPID)
+	SelectionCollectionOwner = "collection-owner"
+	// SelectionPreferredLabels selects the peers matching the preferred labels (Opts.PreferredLabels)
+	SelectionPreferredLabels = "preferred-labels"
+	// SelectionAllPeers selects from all of the peers accepted by the selection filter of the request
+	SelectionAllPeers = "all-peers"
+)
+
+// SelectionTrace records how ProposalProcessorHandler selected the endorsers of an invoke. The selection service
+// doesn't expose the layouts of the endorsement plan, so the trace records each selection attempt instead: the
+// strategy, the endorsers it selected and the discovered peers that its filters excluded.
+type SelectionTrace struct {
+	Attempts []SelectionAttempt
+	Chosen   string //strategy of the attempt whose endorsers were used (empty if selection failed)
+}
+
+// SelectionAttempt is a call to the selection service with the filter of a selection strategy
+type SelectionAttempt struct {
+	Strategy  string
+	Endorsers []string //URLs of the selected endorsers
+	Excluded  []string //URLs of the discovered peers that were rejected by the filter
+	Error     string
+}
+
+// selectWithStrategy selects the endorsers with the given filter and, if Opts.TraceSelection is set, records
+// the attempt in Response.SelectionTrace
+func selectWithStrategy(requestContext *RequestContext, clientContext *ClientContext, strategy string, filter selectopts.PeerFilter) ([]fab.Peer, error) {
+	endorsers, err := getEndorsers(requestContext, clientContext, filter)
+	if !requestContext.Opts.TraceSelection {
+		return endorsers, err
+	}
+
+	attempt := SelectionAttempt{Strategy: strategy, Excluded: excludedPeers(requestContext, clientContext, filter)}
+	for _, endorser := range endorsers {
+		attempt.Endorsers = append(attempt.Endorsers, endorser.URL())
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if requestContext.Response.SelectionTrace == nil {
+		requestContext.Response.SelectionTrace = &SelectionTrace{}
+	}
+	requestContext.Response.SelectionTrace.Attempts = append(requestContext.Response.SelectionTrace.Attempts, attempt)
+	return endorsers, err
+}
+
+// excludedPeers returns the URLs of the discovered peers that are rejected by the selection filter
+func excludedPeers(requestContext *RequestContext, clientContext *ClientContext, filter selectopts.PeerFilter) []string {
+	filter = selectionFilter(requestContext, clientContext, filter)
+	if filter == nil || clientContext.Discovery == nil {
+		return nil
+	}
+	peers, err := clientContext.Discovery.GetPeers()
+	if err != nil {
+		newLogFields(requestContext).debugf("unable to get the discovered peers for the selection trace: %s", err)
+		return nil
+	}
+	var excluded []string
+	for _, peer := range peers {
+		if !filter(peer) {
+			excluded = append(excluded, peer.URL())
+		}
+	}
+	return excluded
+}
+
+// chooseSelectionAttempt records the strategy of the last selection attempt as the chosen one
+func chooseSelectionAttempt(requestContext *RequestContext) {
+	trace := requestContext.Response.SelectionTrace
+	if trace == nil || len(trace.Attempts) == 0 {
+		return
+	}
+	trace.Chosen = trace.Attempts[len(trace.Attempts)-1].Strategy
+}
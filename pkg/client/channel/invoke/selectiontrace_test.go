@@ -0,0 +1,86 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestSelectionTrace(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer1.MockBlockHeight = 10
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+	peer2.MockBlockHeight = 8
+	discoveryPeers := []fab.Peer{peer1, peer2}
+
+	handler := NewProposalProcessorHandler()
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	newClientContext := func(selectionErr error) *ClientContext {
+		clientContext := setupChannelClientContext(nil, selectionErr, discoveryPeers, t)
+		clientContext.Discovery = fcmocks.NewMockDiscoveryService(nil, discoveryPeers)
+		return clientContext
+	}
+
+	// The endorser that failed a previous attempt is excluded
+	requestContext := prepareRequestContext(request, Opts{TraceSelection: true}, t)
+	requestContext.FailedEndorsers = []string{"peer1:7051"}
+	handler.Handle(requestContext, newClientContext(nil))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	trace := requestContext.Response.SelectionTrace
+	if trace == nil {
+		t.Fatal("Expecting the selection to be traced")
+	}
+	assert.Equal(t, SelectionAllPeers, trace.Chosen)
+	assert.Equal(t, []SelectionAttempt{{Strategy: SelectionAllPeers, Endorsers: []string{"peer2:7051"}, Excluded: []string{"peer1:7051"}}}, trace.Attempts)
+
+	// Each selection retried while waiting for a peer to reach the minimum block height is recorded
+	go func() {
+		time.Sleep(2 * blockHeightPollInterval)
+		peer2.RWLock.Lock()
+		peer2.MockBlockHeight = 12
+		peer2.RWLock.Unlock()
+	}()
+	requestContext = prepareRequestContext(request, Opts{MinBlockHeight: 11, BlockHeightWait: 5 * time.Second, TraceSelection: true}, t)
+	handler.Handle(requestContext, newClientContext(nil))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	trace = requestContext.Response.SelectionTrace
+	if trace == nil {
+		t.Fatal("Expecting the selection to be traced")
+	}
+	assert.Equal(t, SelectionAllPeers, trace.Chosen)
+	if assert.True(t, len(trace.Attempts) > 1, "expecting the retried selections to be recorded") {
+		first, last := trace.Attempts[0], trace.Attempts[len(trace.Attempts)-1]
+		assert.Empty(t, first.Endorsers)
+		assert.Equal(t, []string{"peer1:7051", "peer2:7051"}, first.Excluded)
+		assert.Equal(t, []string{"peer2:7051"}, last.Endorsers)
+		assert.Equal(t, []string{"peer1:7051"}, last.Excluded)
+	}
+
+	// A failed selection is recorded with its error and no attempt is chosen
+	requestContext = prepareRequestContext(request, Opts{TraceSelection: true}, t)
+	handler.Handle(requestContext, newClientContext(errors.New("selection failed")))
+	assert.NotNil(t, requestContext.Error)
+	trace = requestContext.Response.SelectionTrace
+	if trace == nil {
+		t.Fatal("Expecting the failed selection to be traced")
+	}
+	assert.Empty(t, trace.Chosen)
+	if assert.Equal(t, 1, len(trace.Attempts)) {
+		assert.Contains(t, trace.Attempts[0].Error, "selection failed")
+	}
+}
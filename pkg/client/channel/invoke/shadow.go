@@ -0,0 +1,120 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+// shadowResponseTimeout is how long the shadow endorsements are waited for once the request has been handled
+const shadowResponseTimeout = time.Second
+
+//NewShadowEndorsementHandler returns a handler that sends a shadow proposal to Opts.ShadowTargets
+func NewShadowEndorsementHandler(next ...Handler) *ShadowEndorsementHandler {
+	return &ShadowEndorsementHandler{next: getNext(next)}
+}
+
+//ShadowEndorsementHandler sends a separate (shadow) transaction proposal to Opts.ShadowTargets (for example, canary
+//peers running a new version of the chaincode) concurrently with the primary endorsement. The shadow endorsements are
+//never committed and don't affect the outcome of the request. Once the request has been handled, the shadow endorsers
+//whose response diverges from the primary endorsement are returned in Response.DivergentShadows. The shadow
+//endorsements are waited for at most shadowResponseTimeout after the request has been handled so that slow shadow
+//endorsers don't delay the request; the shadow endorsers that haven't responded by then are reported as divergent.
+type ShadowEndorsementHandler struct {
+	next Handler
+}
+
+//Handle sends the shadow proposal and compares the shadow endorsements with the primary endorsement
+func (h *ShadowEndorsementHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	targets := requestContext.Opts.ShadowTargets
+	if len(targets) == 0 {
+		if h.next != nil {
+			h.next.Handle(requestContext, clientContext)
+		}
+		return
+	}
+
+	shadowContext := &RequestContext{
+		Request: requestContext.Request,
+		Opts:    shadowOpts(requestContext.Opts),
+		Ctx:     requestContext.Ctx,
+	}
+	shadowResponses := make(chan []*fab.TransactionProposalResponse, 1)
+	go func() {
+		responses, _, err := createAndSendTransactionProposal(shadowContext, clientContext, peer.PeersToTxnProcessors(targets))
+		if err != nil {
+			newLogFields(shadowContext).debugf("shadow endorsement failed: %s", err)
+		}
+		shadowResponses <- responses
+	}()
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+
+	if requestContext.Error != nil || len(requestContext.Response.Responses) == 0 {
+		return
+	}
+	var responses []*fab.TransactionProposalResponse
+	select {
+	case responses = <-shadowResponses:
+	case <-time.After(shadowResponseTimeout):
+		newLogFields(requestContext).infof("shadow endorsements not received within %s", shadowResponseTimeout)
+	}
+	requestContext.Response.DivergentShadows = divergentShadows(requestContext, requestContext.Response.Responses[0], targets, responses)
+	for _, endorser := range requestContext.Response.DivergentShadows {
+		newLogFields(requestContext).with("endorser", endorser).warnf("shadow endorser diverged from the primary endorsement")
+	}
+}
+
+// shadowOpts returns the options of the shadow proposal, which only targets the shadow endorsers
+// and isn't observed
+func shadowOpts(opts Opts) Opts {
+	shadow := opts
+	shadow.Targets = opts.ShadowTargets
+	shadow.ShadowTargets = nil
+	shadow.TransientMapOverrides = nil
+	shadow.ProposalObserver = nil
+	return shadow
+}
+
+// divergentShadows returns the URLs of the shadow targets that failed to endorse or whose endorsement
+// doesn't match the primary endorsement
+func divergentShadows(requestContext *RequestContext, primary *fab.TransactionProposalResponse, targets []fab.Peer, responses []*fab.TransactionProposalResponse) []string {
+	opts := requestContext.Opts
+	expected, err := comparisonValue(opts, primary)
+	if err != nil {
+		newLogFields(requestContext).debugf("unable to compare shadow endorsements: %s", err)
+		return nil
+	}
+
+	byAddress := make(map[string]*fab.TransactionProposalResponse, len(responses))
+	for _, r := range responses {
+		byAddress[endpoint.ToAddress(r.Endorser)] = r
+	}
+
+	var divergent []string
+	for _, target := range targets {
+		r, ok := byAddress[endpoint.ToAddress(target.URL())]
+		if !ok || r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+			divergent = append(divergent, target.URL())
+			continue
+		}
+		value, err := comparisonValue(opts, r)
+		if err != nil || !bytes.Equal(value, expected) {
+			divergent = append(divergent, target.URL())
+		}
+	}
+	return divergent
+}
@@ -0,0 +1,77 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestShadowEndorsementHandler(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	canary1 := &fcmocks.MockPeer{MockName: "Canary1", MockURL: "http://canary1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	canary2 := &fcmocks.MockPeer{MockName: "Canary2", MockURL: "http://canary2.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("other")}
+	canary3 := &fcmocks.MockPeer{MockName: "Canary3", MockURL: "http://canary3.com", MockMSP: "Org1MSP", Status: 500}
+
+	requestContext := prepareRequestContext(request, Opts{ShadowTargets: []fab.Peer{canary1, canary2, canary3}}, t)
+	NewQueryHandler().Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	assert.Len(t, requestContext.Response.Responses, 2, "shadow endorsements should not be included in the response")
+	assert.Equal(t, []string{"http://canary2.com", "http://canary3.com"}, requestContext.Response.DivergentShadows)
+	assert.Equal(t, 1, mockPeer1.ProcessProposalCalls)
+	assert.Equal(t, 1, canary1.ProcessProposalCalls)
+
+	// A divergent shadow doesn't affect the primary endorsement
+	requestContext = prepareRequestContext(request, Opts{ShadowTargets: []fab.Peer{canary2}}, t)
+	NewQueryHandler().Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+
+	// Not enabled
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	NewQueryHandler().Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Empty(t, requestContext.Response.DivergentShadows)
+}
+
+// hungPeer is a peer that doesn't respond until it's released
+type hungPeer struct {
+	*fcmocks.MockPeer
+	release chan struct{}
+}
+
+func (p *hungPeer) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	<-p.release
+	return p.MockPeer.ProcessTransactionProposal(ctx, request)
+}
+
+func TestShadowEndorsementHandlerSlowShadow(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	canary := &hungPeer{
+		MockPeer: &fcmocks.MockPeer{MockName: "Canary1", MockURL: "http://canary1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")},
+		release:  make(chan struct{}),
+	}
+	defer close(canary.release)
+
+	requestContext := prepareRequestContext(request, Opts{ShadowTargets: []fab.Peer{canary}}, t)
+	start := time.Now()
+	NewQueryHandler().Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	assert.True(t, time.Since(start) < shadowResponseTimeout+time.Second, "expecting the shadow endorsement not to be waited for")
+	assert.Equal(t, []string{"http://canary1.com"}, requestContext.Response.DivergentShadows, "expecting the unresponsive shadow to be reported")
+}
@@ -0,0 +1,50 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+//NewRequestShapingHandler returns a handler that shapes the request before the proposal is created
+func NewRequestShapingHandler(next ...Handler) *RequestShapingHandler {
+	return &RequestShapingHandler{next: getNext(next)}
+}
+
+//RequestShapingHandler applies the request-shaping options (see shapeRequest) and, once the request has been
+//handled, flags the stale endorsers and records the response encoding in the same way as CorrelationIDHandler
+//and ResponseEncodingHandler
+type RequestShapingHandler struct {
+	next Handler
+}
+
+//Handle shapes the request and checks the responses against the shaped request
+func (h *RequestShapingHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	correlationID, err := shapeRequest(requestContext)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+
+	checkCorrelationID(requestContext, correlationID)
+	recordResponseEncoding(requestContext)
+}
+
+// shapeRequest applies the options that change the request before the proposal is created (Opts.NormalizeArgs,
+// Opts.CorrelationIDKey and Opts.ResponseEncoding) to the request of the given request context, so that a proposal
+// built without invoking the handlers is the same as the proposal of Query and Execute. The generated correlation ID
+// (if any) is returned.
+func shapeRequest(requestContext *RequestContext) (string, error) {
+	normalizeArgs(requestContext)
+	correlationID, err := injectCorrelationID(requestContext)
+	if err != nil {
+		return "", err
+	}
+	injectResponseEncoding(requestContext)
+	return correlationID, nil
+}
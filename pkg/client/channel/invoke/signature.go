@@ -12,7 +12,6 @@ import (
 
 	"github.com/pkg/errors"
 
-	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -21,29 +20,39 @@ func NewSignatureValidationHandler(next ...Handler) *SignatureValidationHandler
 	return &SignatureValidationHandler{next: getNext(next)}
 }
 
-//SignatureValidationHandler for transaction proposal response filtering
+//SignatureValidationHandler for transaction proposal response filtering. If Opts.CRLProvider is set, the invoke is
+//also aborted if the identity of any endorser is revoked.
 type SignatureValidationHandler struct {
 	next Handler
 }
 
 //Handle for Filtering proposal response
 func (f *SignatureValidationHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	defer startSpan(requestContext, clientContext, SpanSignatureValidation)()
+
 	//Filter tx proposal responses
-	err := f.validate(requestContext.Response.Responses, clientContext)
+	err := f.validate(requestContext.Opts, requestContext.Response.Responses, clientContext)
 	if err != nil {
 		requestContext.Error = errors.WithMessage(err, "endorsement validation failed")
 		return
 	}
 
+	if provider := requestContext.Opts.CRLProvider; provider != nil {
+		if err := checkRevocation(provider, requestContext.Response.Responses); err != nil {
+			requestContext.Error = err
+			return
+		}
+	}
+
 	// Delegate to next step if any
 	if f.next != nil {
 		f.next.Handle(requestContext, clientContext)
 	}
 }
 
-func (f *SignatureValidationHandler) validate(txProposalResponse []*fab.TransactionProposalResponse, ctx *ClientContext) error {
+func (f *SignatureValidationHandler) validate(opts Opts, txProposalResponse []*fab.TransactionProposalResponse, ctx *ClientContext) error {
 	for _, r := range txProposalResponse {
-		if r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+		if !isSuccessStatus(opts, r.ProposalResponse.GetResponse().Status) {
 			return status.NewFromProposalResponse(r.ProposalResponse, r.Endorser)
 		}
 
@@ -0,0 +1,247 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// Trace is a serializable record of an invoke, captured by TraceHandlers placed
+// between the steps of a handler chain
+type Trace struct {
+	Request Request      `json:"request"`
+	Stages  []TraceStage `json:"stages"`
+
+	mutex sync.Mutex
+}
+
+// TraceStage contains the state of the request context when a TraceHandler was invoked
+type TraceStage struct {
+	Name             string                             `json:"name"`
+	Targets          []string                           `json:"targets,omitempty"`
+	TransactionID    fab.TransactionID                  `json:"transactionId,omitempty"`
+	Payload          []byte                             `json:"payload,omitempty"`
+	Responses        []*fab.TransactionProposalResponse `json:"responses,omitempty"`
+	TxValidationCode pb.TxValidationCode                `json:"txValidationCode"`
+	Committed        bool                               `json:"committed,omitempty"`
+	Error            string                             `json:"error,omitempty"`
+}
+
+// Marshal serializes the trace
+func (t *Trace) Marshal() ([]byte, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of trace failed")
+	}
+	return data, nil
+}
+
+// UnmarshalTrace deserializes a trace
+func UnmarshalTrace(data []byte) (*Trace, error) {
+	trace := &Trace{}
+	if err := json.Unmarshal(data, trace); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of trace failed")
+	}
+	return trace, nil
+}
+
+func (t *Trace) record(name string, requestContext *RequestContext, committed bool) {
+	stage := TraceStage{
+		Name:             name,
+		Committed:        committed,
+		TransactionID:    requestContext.Response.TransactionID,
+		Payload:          requestContext.Response.Payload,
+		Responses:        requestContext.Response.Responses,
+		TxValidationCode: requestContext.Response.TxValidationCode,
+	}
+	for _, target := range requestContext.Opts.Targets {
+		stage.Targets = append(stage.Targets, target.URL())
+	}
+	if requestContext.Error != nil {
+		stage.Error = requestContext.Error.Error()
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.Request = requestContext.Request
+	t.Stages = append(t.Stages, stage)
+}
+
+// lastStage returns the last recorded stage that satisfies the given condition
+func (t *Trace) lastStage(cond func(stage *TraceStage) bool) (*TraceStage, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for i := len(t.Stages) - 1; i >= 0; i-- {
+		if cond(&t.Stages[i]) {
+			return &t.Stages[i], true
+		}
+	}
+	return nil, false
+}
+
+//TraceHandler records the state of the request context in a trace
+type TraceHandler struct {
+	trace *Trace
+	stage string
+	next  Handler
+}
+
+//Handle records the state of the request context
+func (h *TraceHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	h.trace.record(h.stage, requestContext, false)
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+//NewTraceHandler returns a handler that records the state of the request context in the given trace under the given stage name
+func NewTraceHandler(trace *Trace, stage string, next ...Handler) *TraceHandler {
+	return &TraceHandler{trace: trace, stage: stage, next: getNext(next)}
+}
+
+//CommitTraceHandler records the outcome of a commit in a trace. Unlike a TraceHandler placed after a
+//CommitTxHandler (which is only invoked if the transaction is committed successfully), the outcome is
+//recorded whether or not the commit succeeds, including the validation code and the error.
+type CommitTraceHandler struct {
+	trace  *Trace
+	stage  string
+	commit Handler
+}
+
+//Handle invokes the commit handler and records the outcome of the commit
+func (h *CommitTraceHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	h.commit.Handle(requestContext, clientContext)
+	h.trace.record(h.stage, requestContext, true)
+}
+
+//NewCommitTraceHandler returns a handler that invokes the given commit handler (for example, a CommitTxHandler)
+//and records the outcome of the commit in the given trace under the given stage name
+func NewCommitTraceHandler(trace *Trace, stage string, commit Handler) *CommitTraceHandler {
+	return &CommitTraceHandler{trace: trace, stage: stage, commit: commit}
+}
+
+// ReplayTransactor is a transactor that returns the endorsements recorded in a trace instead of
+// sending the proposal to the endorsers. The proposal is created with the recorded transaction ID
+// so that the recorded transaction status can be replayed by a ReplayEventService.
+type ReplayTransactor struct {
+	trace *Trace
+}
+
+// NewReplayTransactor returns a transactor that replays the given trace
+func NewReplayTransactor(trace *Trace) *ReplayTransactor {
+	return &ReplayTransactor{trace: trace}
+}
+
+// CreateTransactionHeader returns a transaction header with the recorded transaction ID
+func (t *ReplayTransactor) CreateTransactionHeader(opts ...fab.TxnHeaderOpt) (fab.TransactionHeader, error) {
+	stage, ok := t.trace.lastStage(func(stage *TraceStage) bool { return stage.TransactionID != "" })
+	if !ok {
+		return nil, errors.New("no transaction ID recorded in trace")
+	}
+	return &replayHeader{txnID: stage.TransactionID}, nil
+}
+
+// SendTransactionProposal returns the recorded endorsements
+func (t *ReplayTransactor) SendTransactionProposal(proposal *fab.TransactionProposal, targets []fab.ProposalProcessor, opts ...fab.SendProposalOpt) ([]*fab.TransactionProposalResponse, error) {
+	stage, ok := t.trace.lastStage(func(stage *TraceStage) bool { return len(stage.Responses) > 0 })
+	if !ok {
+		return nil, errors.New("no endorsements recorded in trace")
+	}
+	return append([]*fab.TransactionProposalResponse{}, stage.Responses...), nil
+}
+
+// CreateTransaction creates a transaction with the recorded endorsements
+func (t *ReplayTransactor) CreateTransaction(request fab.TransactionRequest) (*fab.Transaction, error) {
+	return txn.New(request)
+}
+
+// SendTransaction pretends to send the transaction to the orderer
+func (t *ReplayTransactor) SendTransaction(tx *fab.Transaction, opts ...fab.SendTxnOpt) (*fab.TransactionResponse, error) {
+	return &fab.TransactionResponse{Orderer: "replay"}, nil
+}
+
+type replayHeader struct {
+	txnID fab.TransactionID
+}
+
+func (h *replayHeader) TransactionID() fab.TransactionID {
+	return h.txnID
+}
+
+func (h *replayHeader) Creator() []byte {
+	return nil
+}
+
+func (h *replayHeader) Nonce() []byte {
+	return nil
+}
+
+func (h *replayHeader) ChannelID() string {
+	return ""
+}
+
+// ReplayEventService is an event service that reports the transaction status recorded in a trace
+type ReplayEventService struct {
+	trace *Trace
+}
+
+// NewReplayEventService returns an event service that replays the given trace
+func NewReplayEventService(trace *Trace) *ReplayEventService {
+	return &ReplayEventService{trace: trace}
+}
+
+// RegisterBlockEvent is not supported
+func (s *ReplayEventService) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	return nil, nil, errors.New("block events are not supported by the replay event service")
+}
+
+// RegisterFilteredBlockEvent is not supported
+func (s *ReplayEventService) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	return nil, nil, errors.New("filtered block events are not supported by the replay event service")
+}
+
+// RegisterChaincodeEvent is not supported
+func (s *ReplayEventService) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+	return nil, nil, errors.New("chaincode events are not supported by the replay event service")
+}
+
+// RegisterTxStatusEvent registers for the transaction status, which is the validation code recorded by a
+// CommitTraceHandler. An error is returned if no commit of the transaction was recorded, or if the commit
+// failed without a validation code (for example, if the transaction wasn't accepted by the orderer).
+func (s *ReplayEventService) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+	stage, ok := s.trace.lastStage(func(stage *TraceStage) bool { return stage.Committed && string(stage.TransactionID) == txID })
+	if !ok {
+		return nil, nil, errors.Errorf("no commit of transaction %s recorded in trace", txID)
+	}
+	if stage.Error != "" && stage.TxValidationCode == pb.TxValidationCode_VALID {
+		return nil, nil, errors.Errorf("commit of transaction %s failed in trace: %s", txID, stage.Error)
+	}
+
+	eventCh := make(chan *fab.TxStatusEvent, 1)
+	eventCh <- &fab.TxStatusEvent{TxID: txID, TxValidationCode: stage.TxValidationCode}
+	return &dispatcher.TxStatusReg{Eventch: eventCh, TxID: txID}, eventCh, nil
+}
+
+// Unregister removes the given registration
+func (s *ReplayEventService) Unregister(reg fab.Registration) {
+	// Nothing to do
+}
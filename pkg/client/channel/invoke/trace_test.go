@@ -0,0 +1,143 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func newTracedExecuteHandler(trace *Trace) Handler {
+	return NewProposalProcessorHandler(
+		NewTraceHandler(trace, "selection",
+			NewEndorsementHandler(
+				NewTraceHandler(trace, "endorsement",
+					NewEndorsementValidationHandler(
+						NewSignatureValidationHandler(
+							NewCommitTraceHandler(trace, "commit", NewCommitHandler())))))))
+}
+
+func TestTraceCaptureAndReplay(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	// Capture
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go func() {
+		select {
+		case txStatusReg := <-mockEventService.TxStatusRegCh:
+			txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+		case <-time.After(requestContext.Opts.Timeouts[core.Execute]):
+			panic("Execute handler : time out not expected")
+		}
+	}()
+
+	trace := &Trace{}
+	newTracedExecuteHandler(trace).Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	txnID := requestContext.Response.TransactionID
+
+	if assert.Equal(t, 3, len(trace.Stages)) {
+		assert.Equal(t, request, trace.Request)
+		assert.Equal(t, "selection", trace.Stages[0].Name)
+		assert.Equal(t, []string{mockPeer1.URL(), mockPeer2.URL()}, trace.Stages[0].Targets)
+		assert.Equal(t, 2, len(trace.Stages[1].Responses))
+		assert.Equal(t, txnID, trace.Stages[2].TransactionID)
+		assert.True(t, trace.Stages[2].Committed)
+	}
+
+	data, err := trace.Marshal()
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	recorded, err := UnmarshalTrace(data)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+
+	// Replay without endorsers or event service
+	requestContext = prepareRequestContext(recorded.Request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("replay", "")}}, t)
+	clientContext = setupChannelClientContext(nil, nil, nil, t)
+	clientContext.Transactor = NewReplayTransactor(recorded)
+	clientContext.EventService = NewReplayEventService(recorded)
+
+	replayed := &Trace{}
+	newTracedExecuteHandler(replayed).Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.Equal(t, txnID, requestContext.Response.TransactionID)
+	assert.Equal(t, pb.TxValidationCode_VALID, requestContext.Response.TxValidationCode)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	assert.Equal(t, 2, len(requestContext.Response.Responses))
+}
+
+func TestTraceReplayInvalidCommit(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	// Capture a transaction that is invalidated
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go func() {
+		select {
+		case txStatusReg := <-mockEventService.TxStatusRegCh:
+			txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_MVCC_READ_CONFLICT}
+		case <-time.After(requestContext.Opts.Timeouts[core.Execute]):
+			panic("Execute handler : time out not expected")
+		}
+	}()
+
+	trace := &Trace{}
+	newTracedExecuteHandler(trace).Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error, "expected error for invalid transaction")
+	if assert.Equal(t, 3, len(trace.Stages)) {
+		assert.Equal(t, "commit", trace.Stages[2].Name)
+		assert.True(t, trace.Stages[2].Committed)
+		assert.Equal(t, pb.TxValidationCode_MVCC_READ_CONFLICT, trace.Stages[2].TxValidationCode)
+		assert.NotEmpty(t, trace.Stages[2].Error)
+	}
+
+	// The replay reports the recorded validation code
+	requestContext = prepareRequestContext(trace.Request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("replay", "")}}, t)
+	clientContext = setupChannelClientContext(nil, nil, nil, t)
+	clientContext.Transactor = NewReplayTransactor(trace)
+	clientContext.EventService = NewReplayEventService(trace)
+	newTracedExecuteHandler(&Trace{}).Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error, Received error: %v", requestContext.Error)
+	}
+	assert.EqualValues(t, pb.TxValidationCode_MVCC_READ_CONFLICT, s.Code)
+
+	// A trace without a commit stage can't be replayed as a commit
+	trace.Stages = trace.Stages[:2]
+	requestContext = prepareRequestContext(trace.Request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("replay", "")}}, t)
+	clientContext.EventService = NewReplayEventService(trace)
+	newTracedExecuteHandler(&Trace{}).Handle(requestContext, clientContext)
+	if assert.NotNil(t, requestContext.Error, "expected error for trace without commit") {
+		assert.Contains(t, requestContext.Error.Error(), "no commit of transaction")
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+)
+
+// The names of the spans started by the built-in handlers
+const (
+	SpanProposal            = "fabsdk.invoke.proposal"
+	SpanEndorsement         = "fabsdk.invoke.endorsement"
+	SpanValidation          = "fabsdk.invoke.validation"
+	SpanSignatureValidation = "fabsdk.invoke.signature_validation"
+	SpanCommit              = "fabsdk.invoke.commit"
+)
+
+// SpanEnd ends a span. The error is the error of the request context when the handler
+// (and the handlers after it) returned, or nil if the invoke succeeded so far.
+type SpanEnd func(err error)
+
+// Tracer starts the spans of the built-in handlers, for example backed by OpenTelemetry
+type Tracer interface {
+	// StartSpan starts a span with the given name as a child of the span carried by the given context (if any)
+	// and returns the context carrying the new span
+	StartSpan(ctx reqContext.Context, name string) (reqContext.Context, SpanEnd)
+}
+
+// startSpan starts a span for a handler if the client context has a Tracer. The context carrying the span
+// replaces the context of the request until the returned function is called, so the spans of the handlers
+// after it (which the handler invokes) are its children and an invoke shows as a span tree.
+func startSpan(requestContext *RequestContext, clientContext *ClientContext, name string) func() {
+	tracer := clientContext.Tracer
+	if tracer == nil {
+		return func() {}
+	}
+
+	parent := requestContext.Ctx
+	ctx := parent
+	if ctx == nil {
+		ctx = reqContext.Background()
+	}
+	ctx, end := tracer.StartSpan(ctx, name)
+	requestContext.Ctx = ctx
+	return func() {
+		end(requestContext.Error)
+		requestContext.Ctx = parent
+	}
+}
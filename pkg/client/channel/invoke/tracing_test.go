@@ -0,0 +1,102 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+type spanKey struct{}
+
+// recordedSpan is a span started by the recordingTracer
+type recordedSpan struct {
+	name   string
+	parent string
+	ended  bool
+	err    error
+}
+
+// recordingTracer records the spans that are started, with the name of their parent span
+type recordingTracer struct {
+	mutex sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) StartSpan(ctx reqContext.Context, name string) (reqContext.Context, SpanEnd) {
+	span := &recordedSpan{name: name}
+	if parent, ok := ctx.Value(spanKey{}).(string); ok {
+		span.parent = parent
+	}
+
+	t.mutex.Lock()
+	t.spans = append(t.spans, span)
+	t.mutex.Unlock()
+
+	return reqContext.WithValue(ctx, spanKey{}, name), func(err error) {
+		span.ended = true
+		span.err = err
+	}
+}
+
+func TestTracer(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	tracer := &recordingTracer{}
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	requestContext.Ctx = reqContext.WithValue(reqContext.Background(), spanKey{}, "invoke")
+	parentCtx := requestContext.Ctx
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	clientContext.Tracer = tracer
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_VALID)
+
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, parentCtx, requestContext.Ctx, "expecting the context of the request to be restored")
+
+	var tree [][2]string
+	for _, span := range tracer.spans {
+		assert.True(t, span.ended, "expecting span %s to be ended", span.name)
+		assert.Nil(t, span.err)
+		tree = append(tree, [2]string{span.parent, span.name})
+	}
+	assert.Equal(t, [][2]string{
+		{"invoke", SpanProposal},
+		{SpanProposal, SpanEndorsement},
+		{SpanEndorsement, SpanValidation},
+		{SpanValidation, SpanSignatureValidation},
+		{SpanSignatureValidation, SpanCommit},
+	}, tree)
+}
+
+func TestTracerWithError(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	tracer := &recordingTracer{}
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	clientContext.Tracer = tracer
+
+	NewQueryHandler().Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error)
+	if assert.Equal(t, 2, len(tracer.spans)) {
+		assert.Equal(t, SpanEndorsement, tracer.spans[1].name)
+		assert.Equal(t, requestContext.Error, tracer.spans[1].err)
+		assert.Equal(t, requestContext.Error, tracer.spans[0].err)
+	}
+}
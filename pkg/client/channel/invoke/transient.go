@@ -0,0 +1,107 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/multi"
+)
+
+// processorWrapper is implemented by the proposal processors that wrap another processor
+type processorWrapper interface {
+	unwrap() fab.ProposalProcessor
+}
+
+// targetPeer returns the peer to which the given processor sends the proposal (if known)
+func targetPeer(processor fab.ProposalProcessor) (fab.Peer, bool) {
+	for {
+		wrapper, ok := processor.(processorWrapper)
+		if !ok {
+			break
+		}
+		processor = wrapper.unwrap()
+	}
+	peer, ok := processor.(fab.Peer)
+	return peer, ok
+}
+
+// transientMapOverride returns the key of the transient map override that applies to the given
+// processor: the URL of the peer if there's an override for it, otherwise the MSP ID of the peer
+// if there's an override for it, otherwise an empty string
+func transientMapOverride(overrides map[string]map[string][]byte, processor fab.ProposalProcessor) string {
+	peer, ok := targetPeer(processor)
+	if !ok {
+		return ""
+	}
+	if _, ok := overrides[peer.URL()]; ok {
+		return peer.URL()
+	}
+	if _, ok := overrides[peer.MSPID()]; ok {
+		return peer.MSPID()
+	}
+	return ""
+}
+
+// sendProposalVariants sends the proposal to the targets that don't have a transient map override and
+// a variant of the proposal with the overriding transient map to each group of targets that have one.
+// The variants share the transaction header (and ID) of the proposal and differ only in the transient
+// map, which isn't part of the transaction.
+func sendProposalVariants(sender fab.ProposalSender, txh fab.TransactionHeader, request fab.ChaincodeInvokeRequest, proposal *fab.TransactionProposal,
+	overrides map[string]map[string][]byte, targets []fab.ProposalProcessor, opts ...fab.SendProposalOpt) ([]*fab.TransactionProposalResponse, error) {
+
+	groups := make(map[string][]fab.ProposalProcessor)
+	for _, target := range targets {
+		key := transientMapOverride(overrides, target)
+		groups[key] = append(groups[key], target)
+	}
+
+	variants := make(map[string]*fab.TransactionProposal)
+	for key := range groups {
+		if key == "" {
+			variants[key] = proposal
+			continue
+		}
+		variantRequest := request
+		variantRequest.TransientMap = overrides[key]
+		variant, err := txn.CreateChaincodeInvokeProposal(txh, variantRequest)
+		if err != nil {
+			return nil, errors.WithMessage(err, "creating transaction proposal variant failed")
+		}
+		variants[key] = variant
+	}
+
+	var mutex sync.Mutex
+	var responses []*fab.TransactionProposalResponse
+	var errs error
+	var wg sync.WaitGroup
+	for key, group := range groups {
+		wg.Add(1)
+		go func(variant *fab.TransactionProposal, group []fab.ProposalProcessor) {
+			defer wg.Done()
+			resps, err := sender.SendTransactionProposal(variant, group, opts...)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			responses = append(responses, resps...)
+			if endorserErrs, ok := err.(multi.Errors); ok {
+				for _, e := range endorserErrs {
+					errs = multi.Append(errs, e)
+				}
+			} else if err != nil {
+				errs = multi.Append(errs, err)
+			}
+		}(variants[key], group)
+	}
+	wg.Wait()
+
+	return responses, errs
+}
@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+)
+
+// transientCapturingPeer records the transaction ID and transient map of the proposal that it receives
+type transientCapturingPeer struct {
+	*fcmocks.MockPeer
+	txID         string
+	transientMap map[string][]byte
+}
+
+func (p *transientCapturingPeer) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	proposal := &pb.Proposal{}
+	if err := proto.Unmarshal(request.SignedProposal.ProposalBytes, proposal); err != nil {
+		return nil, err
+	}
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		return nil, err
+	}
+	chdr, err := protos_utils.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := protos_utils.GetChaincodeProposalPayload(proposal.Payload)
+	if err != nil {
+		return nil, err
+	}
+	p.txID = chdr.TxId
+	p.transientMap = payload.TransientMap
+	return p.MockPeer.ProcessTransactionProposal(ctx, request)
+}
+
+func TestCreateAndSendTransactionProposalWithTransientMapOverrides(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move")}, TransientMap: map[string][]byte{"data": []byte("default")}}
+
+	peer1 := &transientCapturingPeer{MockPeer: &fcmocks.MockPeer{MockName: "Peer1", MockURL: "peer1:7051", MockMSP: "Org1MSP", Status: 200}}
+	peer2 := &transientCapturingPeer{MockPeer: &fcmocks.MockPeer{MockName: "Peer2", MockURL: "peer2:7051", MockMSP: "Org1MSP", Status: 200}}
+	peer3 := &transientCapturingPeer{MockPeer: &fcmocks.MockPeer{MockName: "Peer3", MockURL: "peer3:7051", MockMSP: "Org2MSP", Status: 200}}
+
+	opts := Opts{
+		Targets: []fab.Peer{peer1, peer2, peer3},
+		TransientMapOverrides: map[string]map[string][]byte{
+			"Org1MSP":    {"data": []byte("org1")},
+			"peer2:7051": {"data": []byte("peer2")},
+		},
+		EndorserConcurrency: 2,
+	}
+	requestContext := prepareRequestContext(request, opts, t)
+
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	responses, proposal, err := createAndSendTransactionProposal(requestContext, clientContext, proposalProcessors(requestContext, clientContext))
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.Equal(t, 3, len(responses))
+
+	assert.Equal(t, []byte("org1"), peer1.transientMap["data"])
+	assert.Equal(t, []byte("peer2"), peer2.transientMap["data"])
+	assert.Equal(t, []byte("default"), peer3.transientMap["data"])
+	for _, p := range []*transientCapturingPeer{peer1, peer2, peer3} {
+		assert.Equal(t, string(proposal.TxnID), p.txID, "expecting all endorsers to receive the same transaction")
+	}
+}
@@ -8,21 +8,36 @@ package invoke
 
 import (
 	"bytes"
+	reqContext "context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
 	"github.com/pkg/errors"
 
 	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
-	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb_msp "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
 )
 
-var logger = logging.NewLogger("fabsdk/client")
+const loggerModule = "fabsdk/client"
+
+// blockHeightPollInterval is the interval at which selection is retried while
+// waiting for endorsers to reach the minimum block height
+const blockHeightPollInterval = 100 * time.Millisecond
+
+var logger = logging.NewLogger(loggerModule)
 
 //EndorsementHandler for handling endorse transactions
 type EndorsementHandler struct {
@@ -31,23 +46,63 @@ type EndorsementHandler struct {
 
 //Handle for endorsing transactions
 func (e *EndorsementHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	defer startSpan(requestContext, clientContext, SpanEndorsement)()
 
 	if len(requestContext.Opts.Targets) == 0 {
 		requestContext.Error = status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), "targets were not provided", nil)
 		return
 	}
 
+	processors := proposalProcessors(requestContext, clientContext)
+	var early *earlySatisfaction
+	if satisfier := requestContext.Opts.EndorsementSatisfier; satisfier != nil {
+		early = newEarlySatisfaction(requestContext, satisfier)
+		processors = early.wrap(processors)
+	}
+
+	if err := waitForRateLimit(requestContext, clientContext); err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	release, err := acquireEndorsementSlot(requestContext, clientContext)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+
 	// Endorse Tx
-	transactionProposalResponses, proposal, err := createAndSendTransactionProposal(clientContext.Transactor, &requestContext.Request, peer.PeersToTxnProcessors(requestContext.Opts.Targets))
+	transactionProposalResponses, proposal, err := createAndSendTransactionProposal(requestContext, clientContext, processors)
+	if useFallbackChaincode(requestContext.Opts, err, transactionProposalResponses) {
+		newLogFields(requestContext).infof("endorsement failed - retrying with fallback chaincode %s", requestContext.Opts.FallbackChaincodeID)
+		transactionProposalResponses, proposal, early, err = endorseWithFallbackChaincode(requestContext, clientContext)
+	}
+	release()
+	if early != nil {
+		if satisfied := early.responses(); satisfied != nil {
+			// The errors are those of the proposals that were cancelled
+			transactionProposalResponses, err = satisfied, nil
+		}
+	}
 
-	requestContext.Response.Proposal = proposal
-	requestContext.Response.TransactionID = proposal.TxnID // TODO: still needed?
+	if proposal != nil {
+		requestContext.Response.Proposal = proposal
+		requestContext.Response.TransactionID = proposal.TxnID // TODO: still needed?
+	}
 
 	if err != nil {
 		requestContext.Error = err
 		return
 	}
 
+	fields := newLogFields(requestContext)
+	for _, r := range transactionProposalResponses {
+		fields.withEndorser(r).debugf("received endorsement with status %d", r.Status)
+	}
+
+	if requestContext.Opts.SortResponses {
+		sortResponses(transactionProposalResponses)
+	}
 	requestContext.Response.Responses = transactionProposalResponses
 	if len(transactionProposalResponses) > 0 {
 		requestContext.Response.Payload = transactionProposalResponses[0].ProposalResponse.GetResponse().Payload
@@ -59,26 +114,51 @@ func (e *EndorsementHandler) Handle(requestContext *RequestContext, clientContex
 	}
 }
 
-//ProposalProcessorHandler for selecting proposal processors
+//ProposalProcessorHandler for selecting proposal processors. If Opts.Collections is set and no endorsers
+//remain after selecting the members of the collections then the handler fails with a NoCollectionEndorsers
+//status. If Opts.HealthChecker is set then the targets that fail the health check are replaced by healthy
+//peers from Opts.ReserveTargets. If Opts.TraceSelection is set then the selection attempts are recorded in
+//Response.SelectionTrace.
 type ProposalProcessorHandler struct {
 	next Handler
 }
 
 //Handle selects proposal processors
 func (h *ProposalProcessorHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	defer startSpan(requestContext, clientContext, SpanProposal)()
+
+	if err := recordConfigSequence(requestContext, clientContext); err != nil {
+		requestContext.Error = err
+		return
+	}
+
 	//Get proposal processor, if not supplied then use selection service to get available peers as endorser
-	if len(requestContext.Opts.Targets) == 0 {
-		var selectionOpts []options.Opt
-		if requestContext.SelectionFilter != nil {
-			selectionOpts = append(selectionOpts, selectopts.WithPeerFilter(requestContext.SelectionFilter))
-		}
-		endorsers, err := clientContext.Selection.GetEndorsersForChaincode([]string{requestContext.Request.ChaincodeID}, selectionOpts...)
+	requestContext.targetsSelected = len(requestContext.Opts.Targets) == 0
+	if requestContext.targetsSelected {
+		endorsers, err := h.selectEndorsersAtHeight(requestContext, clientContext)
 		if err != nil {
 			requestContext.Error = errors.WithMessage(err, "Failed to get endorsing peers")
 			return
 		}
+		if collections := requestContext.Opts.Collections; len(endorsers) == 0 && len(collections) > 0 {
+			requestContext.Error = status.New(status.ClientStatus, status.NoCollectionEndorsers.ToInt32(),
+				fmt.Sprintf("no available endorsers are members of the collections %v", collections), []interface{}{collections})
+			return
+		}
+		chooseSelectionAttempt(requestContext)
 		requestContext.Opts.Targets = endorsers
 	}
+	if requestContext.Opts.HealthChecker != nil {
+		targets, err := healthyTargets(requestContext, requestContext.Opts.Targets)
+		if err != nil {
+			requestContext.Error = err
+			return
+		}
+		requestContext.Opts.Targets = targets
+	}
+	if mandatory := requestContext.Opts.MandatoryPeers; len(mandatory) > 0 {
+		requestContext.Opts.Targets = mergePeers(mandatory, requestContext.Opts.Targets)
+	}
 
 	//Delegate to next step if any
 	if h.next != nil {
@@ -86,6 +166,173 @@ func (h *ProposalProcessorHandler) Handle(requestContext *RequestContext, client
 	}
 }
 
+// selectEndorsers uses the selection service to get the endorsers for the chaincode. If a collection owner
+// is specified then the peers of the owning org are selected, if possible. Otherwise, if preferred labels
+// are specified then the peers matching the labels are selected, if possible.
+func (h *ProposalProcessorHandler) selectEndorsers(requestContext *RequestContext, clientContext *ClientContext) ([]fab.Peer, error) {
+	if mspID := requestContext.Opts.CollectionOwnerMSPID; mspID != "" {
+		endorsers, err := selectWithStrategy(requestContext, clientContext, SelectionCollectionOwner, mspFilter(requestContext.SelectionFilter, mspID))
+		if err == nil && len(endorsers) > 0 {
+			return endorsers, nil
+		}
+		newLogFields(requestContext).debugf("endorsement policy can't be satisfied by the peers of collection owner %s (error: %v) - selecting from all peers", mspID, err)
+	}
+	if labels := requestContext.Opts.PreferredLabels; len(labels) > 0 {
+		endorsers, err := selectWithStrategy(requestContext, clientContext, SelectionPreferredLabels, labelFilter(requestContext.SelectionFilter, labels))
+		if err == nil && len(endorsers) > 0 {
+			return endorsers, nil
+		}
+		newLogFields(requestContext).debugf("no endorsers found for preferred labels %v (error: %v) - selecting from all peers", labels, err)
+	}
+	return selectWithStrategy(requestContext, clientContext, SelectionAllPeers, requestContext.SelectionFilter)
+}
+
+// selectEndorsersAtHeight selects the endorsers whose ledger height is at least Opts.MinBlockHeight.
+// If no such endorsers are found then selection is retried until Opts.BlockHeightWait expires.
+func (h *ProposalProcessorHandler) selectEndorsersAtHeight(requestContext *RequestContext, clientContext *ClientContext) ([]fab.Peer, error) {
+	minHeight := requestContext.Opts.MinBlockHeight
+	if minHeight == 0 {
+		return h.selectEndorsers(requestContext, clientContext)
+	}
+
+	deadline := time.Now().Add(requestContext.Opts.BlockHeightWait)
+	for {
+		endorsers, err := h.selectEndorsers(requestContext, clientContext)
+		if err == nil && len(endorsers) > 0 {
+			return endorsers, nil
+		}
+		if !time.Now().Before(deadline) {
+			if err == nil {
+				err = errors.Errorf("no endorsers found at block height %d or above", minHeight)
+			}
+			return nil, err
+		}
+		newLogFields(requestContext).debugf("no endorsers found at block height %d or above - waiting for peers to catch up", minHeight)
+		select {
+		case <-time.After(blockHeightPollInterval):
+		case <-requestContext.Ctx.Done():
+			return nil, errors.Errorf("request cancelled while waiting for endorsers at block height %d", minHeight)
+		}
+	}
+}
+
+// selectionFilter adds the exclusion of the failed endorsers and the minimum block height to the given filter
+func selectionFilter(requestContext *RequestContext, clientContext *ClientContext, filter selectopts.PeerFilter) selectopts.PeerFilter {
+	if len(requestContext.FailedEndorsers) > 0 {
+		filter = excludeFilter(filter, requestContext.FailedEndorsers)
+	}
+	if requestContext.Opts.MinBlockHeight > 0 {
+		filter = blockHeightFilter(filter, requestContext.Opts.MinBlockHeight, newLedgerHeights(requestContext, clientContext))
+	}
+	return filter
+}
+
+func getEndorsers(requestContext *RequestContext, clientContext *ClientContext, filter selectopts.PeerFilter) ([]fab.Peer, error) {
+	filter = selectionFilter(requestContext, clientContext, filter)
+	var selectionOpts []options.Opt
+	if filter != nil {
+		selectionOpts = append(selectionOpts, selectopts.WithPeerFilter(filter))
+	}
+	if collections := requestContext.Opts.Collections; len(collections) > 0 {
+		selectionOpts = append(selectionOpts, selectopts.WithCollections(collections...))
+	}
+	if clientContext.SelectionRandom != nil {
+		selectionOpts = append(selectionOpts, selectopts.WithRandom(clientContext.SelectionRandom))
+	}
+	if tracker := clientContext.ErrorRateTracker; tracker != nil {
+		selectionOpts = append(selectionOpts, selectopts.WithPeerWeight(tracker.Weight))
+	}
+	selectEndorsers := func() ([]fab.Peer, error) {
+		return clientContext.Selection.GetEndorsersForChaincode([]string{requestContext.Request.ChaincodeID}, selectionOpts...)
+	}
+	if cache := clientContext.SelectionCache; cache != nil {
+		selectFromService := selectEndorsers
+		key := SelectionCacheKey{ChaincodeID: requestContext.Request.ChaincodeID, Collections: requestContext.Opts.Collections}
+		selectEndorsers = func() ([]fab.Peer, error) {
+			return getCachedEndorsers(newLogFields(requestContext), cache, clientContext.Discovery, key, selectFromService, filter)
+		}
+	}
+	if clientContext.SelectionCircuitBreaker != nil {
+		return clientContext.SelectionCircuitBreaker.getEndorsers(newLogFields(requestContext), selectEndorsers, filter)
+	}
+	return selectEndorsers()
+}
+
+// mergePeers returns the given mandatory peers followed by the other peers
+// that aren't already included
+func mergePeers(mandatory []fab.Peer, peers []fab.Peer) []fab.Peer {
+	merged := append([]fab.Peer{}, mandatory...)
+	included := make(map[string]bool)
+	for _, peer := range mandatory {
+		included[endpoint.ToAddress(peer.URL())] = true
+	}
+	for _, peer := range peers {
+		if !included[endpoint.ToAddress(peer.URL())] {
+			merged = append(merged, peer)
+		}
+	}
+	return merged
+}
+
+// labelFilter returns a peer filter that accepts peers exposing metadata that matches all of the given
+// labels and that are accepted by the given filter (if any)
+func labelFilter(filter selectopts.PeerFilter, labels map[string]string) selectopts.PeerFilter {
+	return func(peer fab.Peer) bool {
+		if filter != nil && !filter(peer) {
+			return false
+		}
+		md, ok := peer.(fab.PeerMetadata)
+		if !ok {
+			return false
+		}
+		metadata := md.Metadata()
+		for key, value := range labels {
+			if metadata[key] != value {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// mspFilter returns a peer filter that accepts the peers of the given MSP that are accepted by the given
+// filter (if any)
+func mspFilter(filter selectopts.PeerFilter, mspID string) selectopts.PeerFilter {
+	return func(peer fab.Peer) bool {
+		if filter != nil && !filter(peer) {
+			return false
+		}
+		return peer.MSPID() == mspID
+	}
+}
+
+// excludeFilter returns a peer filter that rejects the peers with the given URLs, in addition
+// to those rejected by the given filter (if any)
+func excludeFilter(filter selectopts.PeerFilter, urls []string) selectopts.PeerFilter {
+	excluded := make(map[string]bool)
+	for _, url := range urls {
+		excluded[endpoint.ToAddress(url)] = true
+	}
+	return func(peer fab.Peer) bool {
+		if filter != nil && !filter(peer) {
+			return false
+		}
+		return !excluded[endpoint.ToAddress(peer.URL())]
+	}
+}
+
+// blockHeightFilter returns a peer filter that rejects the peers whose ledger height is below
+// the given height (or unknown), in addition to those rejected by the given filter (if any)
+func blockHeightFilter(filter selectopts.PeerFilter, minHeight uint64, heights *ledgerHeights) selectopts.PeerFilter {
+	return func(peer fab.Peer) bool {
+		if filter != nil && !filter(peer) {
+			return false
+		}
+		height, ok := heights.height(peer)
+		return ok && height >= minHeight
+	}
+}
+
 //EndorsementValidationHandler for transaction proposal response filtering
 type EndorsementValidationHandler struct {
 	next Handler
@@ -93,74 +340,546 @@ type EndorsementValidationHandler struct {
 
 //Handle for Filtering proposal response
 func (f *EndorsementValidationHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	defer startSpan(requestContext, clientContext, SpanValidation)()
 
 	//Filter tx proposal responses
-	err := f.validate(requestContext.Response.Responses)
+	var err error
+	if requestContext.Opts.CompareCCVersions {
+		err = validateChaincodeVersions(requestContext.Response.Responses)
+	}
+	if err == nil && requestContext.Opts.SkipPayloadComparison {
+		err = f.validateStatus(requestContext)
+	} else if err == nil && requestContext.Opts.PayloadQuorum > 0 {
+		err = f.validateQuorum(requestContext)
+	} else if err == nil {
+		err = f.validate(requestContext)
+	}
+	if err == nil && requestContext.Opts.MaxEndorsementAge > 0 {
+		err = validateFreshness(requestContext)
+	}
+	if err == nil && requestContext.Opts.RequireStatusAgreement {
+		err = f.validateStatusAgreement(requestContext)
+	}
+	if err == nil && requestContext.Opts.RequireNonEmptyPayload {
+		err = f.validatePayloads(requestContext)
+	}
+	if err == nil {
+		err = f.validateOrgs(requestContext)
+	}
+	if err == nil {
+		err = f.validateMandatoryPeers(requestContext)
+	}
 	if err != nil {
+		newLogFields(requestContext).debugf("endorsement validation failed: %s", err)
 		requestContext.Error = errors.WithMessage(err, "endorsement validation failed")
 		return
 	}
 
+	if requestContext.Opts.ReportReadConflicts {
+		requestContext.Response.ReadConflicts = readVersionConflicts(newLogFields(requestContext), requestContext.Response.Responses)
+		for _, conflict := range requestContext.Response.ReadConflicts {
+			newLogFields(requestContext).warnf("endorsers read key [%s] of [%s] at different versions - the transaction is likely to fail with an MVCC conflict", conflict.Key, conflict.Namespace)
+		}
+	}
+
 	//Delegate to next step if any
 	if f.next != nil {
 		f.next.Handle(requestContext, clientContext)
 	}
 }
 
-func (f *EndorsementValidationHandler) validate(txProposalResponse []*fab.TransactionProposalResponse) error {
+func (f *EndorsementValidationHandler) validate(requestContext *RequestContext) error {
+	// There's nothing to compare a single response with
+	if len(requestContext.Response.Responses) == 1 {
+		return f.validateStatus(requestContext)
+	}
+
 	var a1 []byte
-	for n, r := range txProposalResponse {
-		if r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+	for n, r := range requestContext.Response.Responses {
+		if !isSuccessStatus(requestContext.Opts, r.ProposalResponse.GetResponse().Status) {
 			return status.NewFromProposalResponse(r.ProposalResponse, r.Endorser)
 		}
+		value, err := comparisonValue(requestContext.Opts, r)
+		if err != nil {
+			return err
+		}
 		if n == 0 {
-			a1 = r.ProposalResponse.GetResponse().Payload
+			a1 = value
 			continue
 		}
 
-		if bytes.Compare(a1, r.ProposalResponse.GetResponse().Payload) != 0 {
+		if bytes.Compare(a1, value) != 0 {
 			return status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(),
-				"ProposalResponsePayloads do not match", nil)
+				mismatchMessage(requestContext.Opts), nil)
+		}
+	}
+
+	return nil
+}
+
+// validateStatus checks that all of the responses were successful without comparing them
+func (f *EndorsementValidationHandler) validateStatus(requestContext *RequestContext) error {
+	for _, r := range requestContext.Response.Responses {
+		if !isSuccessStatus(requestContext.Opts, r.ProposalResponse.GetResponse().Status) {
+			return status.NewFromProposalResponse(r.ProposalResponse, r.Endorser)
+		}
+	}
+	return nil
+}
+
+// validateStatusAgreement checks that all of the responses have the same status. If they don't, the error
+// details are the endorsers that disagree with the status returned by the largest number of endorsers (ties
+// are broken in favour of the lowest status so that the outcome doesn't depend on the order of the responses).
+func (f *EndorsementValidationHandler) validateStatusAgreement(requestContext *RequestContext) error {
+	endorsers := make(map[int32][]string)
+	for _, r := range requestContext.Response.Responses {
+		s := r.ProposalResponse.GetResponse().Status
+		endorsers[s] = append(endorsers[s], r.Endorser)
+	}
+	if len(endorsers) <= 1 {
+		return nil
+	}
+
+	var majority int32
+	first := true
+	for s, e := range endorsers {
+		if first || len(e) > len(endorsers[majority]) || (len(e) == len(endorsers[majority]) && s < majority) {
+			majority = s
+			first = false
+		}
+	}
+
+	var dissenters []interface{}
+	for _, r := range requestContext.Response.Responses {
+		if r.ProposalResponse.GetResponse().Status != majority {
+			dissenters = append(dissenters, r.Endorser)
+		}
+	}
+	return status.New(status.EndorserClientStatus, status.StatusDisagreement.ToInt32(),
+		fmt.Sprintf("response status of endorsers %v differs from status %d returned by %v", dissenters, majority, endorsers[majority]),
+		dissenters)
+}
+
+// isSuccessStatus returns true if the given response status is SUCCESS or one of Opts.AcceptedStatuses
+func isSuccessStatus(opts Opts, s int32) bool {
+	if s == int32(common.Status_SUCCESS) {
+		return true
+	}
+	for _, accepted := range opts.AcceptedStatuses {
+		if s == accepted {
+			return true
 		}
 	}
+	return false
+}
 
+// validatePayloads checks that none of the successful responses carries an empty payload
+func (f *EndorsementValidationHandler) validatePayloads(requestContext *RequestContext) error {
+	for _, r := range requestContext.Response.Responses {
+		if len(r.ProposalResponse.GetResponse().GetPayload()) == 0 {
+			return status.New(status.EndorserClientStatus, status.EmptyPayload.ToInt32(),
+				fmt.Sprintf("successful response from [%s] has an empty payload", r.Endorser), []interface{}{r.Endorser})
+		}
+	}
 	return nil
 }
 
-//CommitTxHandler for committing transactions
+// validateQuorum selects the payload returned by the largest number of endorsers and accepts it
+// if at least Opts.PayloadQuorum endorsers agree on it (see majorityGroup for how ties are broken).
+// Responses with a different payload are removed from the response set and recorded as dissenters.
+// If Opts.CompareWriteSets is set then the RW set writes are compared instead of the payloads.
+func (f *EndorsementValidationHandler) validateQuorum(requestContext *RequestContext) error {
+	var groups []responseGroup
+	for _, r := range requestContext.Response.Responses {
+		if !isSuccessStatus(requestContext.Opts, r.ProposalResponse.GetResponse().Status) {
+			return status.NewFromProposalResponse(r.ProposalResponse, r.Endorser)
+		}
+		value, err := comparisonValue(requestContext.Opts, r)
+		if err != nil {
+			return err
+		}
+		groups = groupByValue(groups, value, r)
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	majority := majorityGroup(groups)
+
+	quorum := requestContext.Opts.PayloadQuorum
+	if len(groups[majority].responses) < quorum {
+		return status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(),
+			fmt.Sprintf("%s: %d endorser(s) agree but quorum is %d", mismatchMessage(requestContext.Opts), len(groups[majority].responses), quorum), nil)
+	}
+
+	var dissenters []*fab.TransactionProposalResponse
+	for i, group := range groups {
+		if i != majority {
+			dissenters = append(dissenters, group.responses...)
+		}
+	}
+	fields := newLogFields(requestContext)
+	for _, r := range dissenters {
+		fields.withEndorser(r).warnf("endorser returned a payload that differs from the quorum payload")
+	}
+
+	requestContext.Response.Responses = groups[majority].responses
+	requestContext.Response.Dissenters = dissenters
+	requestContext.Response.Payload = groups[majority].responses[0].ProposalResponse.GetResponse().Payload
+
+	return nil
+}
+
+// validateOrgs checks that the endorsements were received from the orgs
+// required by Opts.MinEndorsingOrgs and Opts.RequiredOrgs
+func (f *EndorsementValidationHandler) validateOrgs(requestContext *RequestContext) error {
+	opts := requestContext.Opts
+	if opts.MinEndorsingOrgs == 0 && len(opts.RequiredOrgs) == 0 {
+		return nil
+	}
+
+	mspIDs, err := endorsingMSPIDs(requestContext.Response.Responses)
+	if err != nil {
+		return err
+	}
+
+	if len(mspIDs) < opts.MinEndorsingOrgs {
+		return errors.Errorf("endorsements received from %d org(s) but at least %d are required", len(mspIDs), opts.MinEndorsingOrgs)
+	}
+
+	var missing []string
+	for _, mspID := range opts.RequiredOrgs {
+		if !mspIDs[mspID] {
+			missing = append(missing, mspID)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("no endorsements received from required org(s) %v", missing)
+	}
+
+	return nil
+}
+
+// validateMandatoryPeers checks that a valid endorsement was received from each of Opts.MandatoryPeers
+func (f *EndorsementValidationHandler) validateMandatoryPeers(requestContext *RequestContext) error {
+	if len(requestContext.Opts.MandatoryPeers) == 0 {
+		return nil
+	}
+
+	endorsers := make(map[string]bool)
+	for _, r := range requestContext.Response.Responses {
+		endorsers[endpoint.ToAddress(r.Endorser)] = true
+	}
+
+	var missing []string
+	for _, peer := range requestContext.Opts.MandatoryPeers {
+		if !endorsers[endpoint.ToAddress(peer.URL())] {
+			missing = append(missing, peer.URL())
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("no valid endorsements received from mandatory peer(s) %v", missing)
+	}
+
+	return nil
+}
+
+// endorsingMSPIDs returns the distinct MSP IDs of the endorsers of the given responses
+func endorsingMSPIDs(responses []*fab.TransactionProposalResponse) (map[string]bool, error) {
+	mspIDs := make(map[string]bool)
+	for _, r := range responses {
+		sID, err := endorserIdentity(r)
+		if err != nil {
+			return nil, err
+		}
+		mspIDs[sID.Mspid] = true
+	}
+	return mspIDs, nil
+}
+
+// endorserIdentity returns the identity that signed the endorsement in the given response
+func endorserIdentity(r *fab.TransactionProposalResponse) (*pb_msp.SerializedIdentity, error) {
+	endorsement := r.ProposalResponse.GetEndorsement()
+	if endorsement == nil {
+		return nil, errors.Errorf("missing endorsement in proposal response from [%s]", r.Endorser)
+	}
+	sID := &pb_msp.SerializedIdentity{}
+	if err := proto.Unmarshal(endorsement.Endorser, sID); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal of endorser identity from [%s] failed", r.Endorser)
+	}
+	return sID, nil
+}
+
+// sortResponses sorts the given responses by the MSP ID of the endorser and then by the endorser URL.
+// A response whose endorser identity can't be read sorts by URL ahead of the others.
+func sortResponses(responses []*fab.TransactionProposalResponse) {
+	mspIDs := make(map[*fab.TransactionProposalResponse]string, len(responses))
+	for _, r := range responses {
+		if sID, err := endorserIdentity(r); err == nil {
+			mspIDs[r] = sID.Mspid
+		}
+	}
+	sort.SliceStable(responses, func(i, j int) bool {
+		if mspIDs[responses[i]] != mspIDs[responses[j]] {
+			return mspIDs[responses[i]] < mspIDs[responses[j]]
+		}
+		return responses[i].Endorser < responses[j].Endorser
+	})
+}
+
+// comparisonValue returns the value of the given response that must match across endorsers:
+// the RW set writes (in canonical order if Opts.CanonicalWriteSets is set) if Opts.CompareWriteSets
+// is set, otherwise the response payload (normalized with Opts.PayloadNormalizer if set)
+func comparisonValue(opts Opts, r *fab.TransactionProposalResponse) ([]byte, error) {
+	if opts.CompareWriteSets {
+		value, err := writeSet(r, opts.CanonicalWriteSets)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("failed to extract write set from proposal response of [%s]", r.Endorser))
+		}
+		return value, nil
+	}
+	value, err := normalizedPayload(opts, r.ProposalResponse.GetResponse().Payload)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to normalize payload from [%s]", r.Endorser))
+	}
+	return value, nil
+}
+
+// validateCommittable checks that the proposal response payloads of the given endorsements are identical
+// since a transaction can only be created from identical endorsements. The payloads may differ even though
+// the endorsements were validated, for example if the write sets were compared (Opts.CompareWriteSets) and
+// the chaincode response payloads or the reads differ. An error with status EndorsementMismatch is returned
+// whose details are the endorsers that differ from the majority of the endorsements.
+func validateCommittable(responses []*fab.TransactionProposalResponse) error {
+	var groups []responseGroup
+	for _, r := range responses {
+		groups = groupByValue(groups, r.ProposalResponse.GetPayload(), r)
+	}
+	if len(groups) <= 1 {
+		return nil
+	}
+
+	majority := majorityGroup(groups)
+	var endorsers []interface{}
+	for i, group := range groups {
+		if i == majority {
+			continue
+		}
+		for _, r := range group.responses {
+			endorsers = append(endorsers, r.Endorser)
+		}
+	}
+	return status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(),
+		"ProposalResponsePayloads of the endorsements to commit do not match", endorsers)
+}
+
+// mismatchMessage returns the error message used when the endorsements do not match
+func mismatchMessage(opts Opts) string {
+	if opts.CompareWriteSets {
+		return "ProposalResponse write sets do not match"
+	}
+	return "ProposalResponsePayloads do not match"
+}
+
+// responseGroup is a group of responses having the same comparison value
+type responseGroup struct {
+	value     []byte
+	responses []*fab.TransactionProposalResponse
+}
+
+// groupByValue adds the response to the group of responses having the same comparison value
+func groupByValue(groups []responseGroup, value []byte, r *fab.TransactionProposalResponse) []responseGroup {
+	for i, group := range groups {
+		if bytes.Equal(group.value, value) {
+			groups[i].responses = append(group.responses, r)
+			return groups
+		}
+	}
+	return append(groups, responseGroup{value: value, responses: []*fab.TransactionProposalResponse{r}})
+}
+
+// majorityGroup returns the index of the group with the largest number of responses. Ties are broken
+// deterministically in favour of the group with the lexicographically smallest value so that the selected
+// group doesn't depend on the order in which the responses were received.
+func majorityGroup(groups []responseGroup) int {
+	majority := 0
+	for i, group := range groups {
+		if len(group.responses) > len(groups[majority].responses) ||
+			(len(group.responses) == len(groups[majority].responses) && bytes.Compare(group.value, groups[majority].value) < 0) {
+			majority = i
+		}
+	}
+	return majority
+}
+
+//CommitTxHandler for committing transactions. A transaction rejected by the ordering service fails with an
+//OrdererClientStatus/OrdererRejected status whereas a transaction invalidated by the peers fails with an
+//EventServerStatus carrying the validation code. If Opts.SubmitApprover vetoes the transaction then it isn't
+//submitted and the handler fails with a VetoedBeforeSubmit status.
 type CommitTxHandler struct {
 	next Handler
 }
 
 //Handle handles commit tx
 func (c *CommitTxHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	defer startSpan(requestContext, clientContext, SpanCommit)()
+
 	txnID := requestContext.Response.TransactionID
+	fields := newLogFields(requestContext)
+
+	if approver := requestContext.Opts.SubmitApprover; approver != nil {
+		if err := approver(requestContext.Response); err != nil {
+			fields.infof("transaction vetoed before submit: %s", err)
+			requestContext.Error = status.New(status.ClientStatus, status.VetoedBeforeSubmit.ToInt32(),
+				fmt.Sprintf("transaction vetoed before submit: %s", err), []interface{}{txnID})
+			return
+		}
+	}
+
+	if err := validateCommittable(requestContext.Response.Responses); err != nil {
+		fields.infof("transaction not submitted: %s", err)
+		requestContext.Error = err
+		return
+	}
+
+	if requestContext.Opts.MaxEndorsementAge > 0 {
+		if err := validateFreshness(requestContext); err != nil {
+			fields.infof("transaction not submitted: %s", err)
+			requestContext.Error = err
+			return
+		}
+	}
+
+	if err := checkConfigSequence(requestContext, clientContext); err != nil {
+		fields.infof("transaction not submitted: %s", err)
+		requestContext.Error = err
+		return
+	}
+
+	eventService, err := channelEventService(requestContext, clientContext)
+	if err != nil {
+		requestContext.Error = errors.WithMessage(err, "error resolving event service")
+		return
+	}
 
 	//Register Tx event
-	reg, statusNotifier, err := clientContext.EventService.RegisterTxStatusEvent(string(txnID)) // TODO: Change func to use TransactionID instead of string
+	statusNotifier, sources, failover, unregister, err := registerTxStatusEventForCommit(requestContext, clientContext, eventService, string(txnID), fields) // TODO: Change func to use TransactionID instead of string
 	if err != nil {
 		requestContext.Error = errors.Wrap(err, "error registering for TxStatus event")
 		return
 	}
-	defer clientContext.EventService.Unregister(reg)
+	defer func() { unregister() }()
 
-	_, err = createAndSendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses)
+	var ccEventNotifier <-chan *fab.CCEvent
+	if eventFilter := requestContext.Opts.ChaincodeEventFilter; eventFilter != "" {
+		reg, eventch, err := eventService.RegisterChaincodeEvent(requestContext.Request.ChaincodeID, txEventFilter(eventFilter, string(txnID)))
+		if err != nil {
+			requestContext.Error = errors.Wrap(err, "error registering for chaincode event")
+			return
+		}
+		defer eventService.Unregister(reg)
+		ccEventNotifier = eventch
+	}
+
+	quorum := requestContext.Opts.CommitQuorum
+	if quorum == 0 {
+		quorum = 1
+	}
+	if quorum > sources {
+		requestContext.Error = errors.Errorf("commit quorum %d exceeds the number of registered event sources %d", quorum, sources)
+		return
+	}
+
+	var sendOpts []fab.SendTxnOpt
+	if requestContext.Opts.OrdererComparator != nil {
+		sendOpts = append(sendOpts, fab.WithOrdererComparator(requestContext.Opts.OrdererComparator))
+	}
+	if signer := requestContext.Opts.ProposalSigner; signer != nil {
+		sendOpts = append(sendOpts, fab.WithTransactionSigner(signer))
+	}
+	submitted := time.Now()
+	_, err = createAndSendTransaction(commitTransactor(requestContext, clientContext), requestContext.Response.Proposal, requestContext.Response.Responses, sendOpts...)
 	if err != nil {
-		requestContext.Error = errors.Wrap(err, "CreateAndSendTransaction failed")
+		requestContext.Error = ordererError(err)
 		return
 	}
+	fields.debugf("transaction sent, waiting for TxStatus event")
 
-	select {
-	case txStatus := <-statusNotifier:
-		requestContext.Response.TxValidationCode = txStatus.TxValidationCode
+	accepted := false
+	reregistered := false
+	var blockNum uint64
+	failoverTimer := failover.next()
+waitForCommit:
+	for received := 0; received < quorum; {
+		select {
+		case <-failoverTimer:
+			failover.failover()
+			failoverTimer = failover.next()
+		case txStatus, ok := <-statusNotifier:
+			if !ok {
+				// The registration was closed by the BatchCommitListener since its block listener stopped
+				if reregistered {
+					requestContext.Error = errors.New("TxStatus event registration was closed before the event was received")
+					return
+				}
+				reregistered = true
+				fields.infof("TxStatus event registration was closed - registering again")
+				unregister()
+				statusNotifier, _, failover, unregister, err = registerTxStatusEventForCommit(requestContext, clientContext, eventService, string(txnID), fields)
+				if err != nil {
+					unregister = func() {}
+					requestContext.Error = errors.Wrap(err, "error registering for TxStatus event")
+					return
+				}
+				failoverTimer = failover.next()
+
+				// The block of the transaction may have been delivered while the registration was closed
+				if txStatus, ok = queryTxStatus(requestContext, clientContext, string(txnID)); !ok {
+					continue
+				}
+				fields.infof("TxStatus of the transaction was found in the ledger")
+			}
+			requestContext.Response.TxValidationCode = txStatus.TxValidationCode
+			blockNum = txStatus.BlockNumber
+			fields.debugf("received TxStatus event with validation code %s", txStatus.TxValidationCode)
+			if recorder, ok := eventService.(CommitLatencyRecorder); ok && received == 0 {
+				if latency, ok := recorder.CommitLatency(string(txnID), submitted); ok {
+					fields.with("latency", latency.SubmitToNotification).debugf("TxStatus event received after submit")
+					requestContext.Response.CommitLatency = latency
+				}
+			}
 
-		if txStatus.TxValidationCode != pb.TxValidationCode_VALID {
-			requestContext.Error = status.New(status.EventServerStatus, int32(txStatus.TxValidationCode), "received invalid transaction", nil)
+			if txStatus.TxValidationCode != pb.TxValidationCode_VALID {
+				if isAcceptedValidationCode(requestContext.Opts.AcceptedValidationCodes, txStatus.TxValidationCode) {
+					fields.infof("validation code %s is accepted as success", txStatus.TxValidationCode)
+					accepted = true
+					break waitForCommit
+				}
+				requestContext.Error = status.New(status.EventServerStatus, int32(txStatus.TxValidationCode), "received invalid transaction", nil)
+				return
+			}
+			received++
+		case <-requestContext.Ctx.Done():
+			fields.infof("request context done after %d of %d TxStatus event(s) were received", received, quorum)
+			requestContext.Error = errors.New("Execute didn't receive block event")
 			return
 		}
-	case <-requestContext.Ctx.Done():
-		requestContext.Error = errors.New("Execute didn't receive block event")
-		return
+	}
+
+	// A chaincode event is only emitted if the transaction is valid
+	if ccEventNotifier != nil && !accepted {
+		ccEvent, err := waitForChaincodeEvent(requestContext.Ctx, ccEventNotifier, string(txnID))
+		if err != nil {
+			fields.infof("chaincode event not received: %s", err)
+			requestContext.Error = err
+			return
+		}
+		requestContext.Response.ChaincodeEvent = ccEvent
+	}
+
+	if observer := requestContext.Opts.CommitObserver; observer != nil && !accepted {
+		observer(commitOutcome(fields, txnID, blockNum, requestContext.Response.Responses))
 	}
 
 	//Delegate to next step if any
@@ -169,12 +888,56 @@ func (c *CommitTxHandler) Handle(requestContext *RequestContext, clientContext *
 	}
 }
 
+// commitOutcome returns the outcome of the committed transaction. The written keys are
+// taken from the first endorsement since the endorsements have the same RW set.
+func commitOutcome(fields logFields, txnID fab.TransactionID, blockNum uint64, responses []*fab.TransactionProposalResponse) CommitOutcome {
+	outcome := CommitOutcome{TxID: txnID, BlockNumber: blockNum}
+	if len(responses) > 0 {
+		keys, err := writeKeys(responses[0])
+		if err != nil {
+			fields.debugf("unable to decode the write set of the transaction: %s", err)
+		} else {
+			outcome.WriteKeys = keys
+		}
+	}
+	return outcome
+}
+
+// ordererError classifies an error sending the transaction to the ordering service. If the ordering service
+// rejected the transaction (a 4xx broadcast status such as BAD_REQUEST or FORBIDDEN), an OrdererRejected status
+// is returned so that the rejection can be told apart from a transaction invalidated by the peers (which is an
+// EventServerStatus with the validation code). Other errors (for example, the ordering service being unavailable)
+// are returned as is so that they can be retried.
+func ordererError(err error) error {
+	s, ok := status.FromError(err)
+	if !ok || s.Group != status.OrdererServerStatus || s.Code < int32(common.Status_BAD_REQUEST) || s.Code >= int32(common.Status_INTERNAL_SERVER_ERROR) {
+		return errors.Wrap(err, "CreateAndSendTransaction failed")
+	}
+	broadcastStatus := common.Status(s.Code)
+	return status.New(status.OrdererClientStatus, status.OrdererRejected.ToInt32(),
+		fmt.Sprintf("transaction rejected by the ordering service with status %s: %s", broadcastStatus, s.Message), []interface{}{broadcastStatus})
+}
+
+// isAcceptedValidationCode returns true if the given validation code is one of the accepted codes
+func isAcceptedValidationCode(accepted []pb.TxValidationCode, code pb.TxValidationCode) bool {
+	for _, c := range accepted {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
 //NewQueryHandler returns query handler with EndorseTxHandler & EndorsementValidationHandler Chained
 func NewQueryHandler(next ...Handler) Handler {
 	return NewProposalProcessorHandler(
-		NewEndorsementHandler(
-			NewEndorsementValidationHandler(
-				NewSignatureValidationHandler(next...),
+		NewRequestShapingHandler(
+			NewShadowEndorsementHandler(
+				NewEndorsementHandler(
+					NewEndorsementValidationHandler(
+						NewSignatureValidationHandler(next...),
+					),
+				),
 			),
 		),
 	)
@@ -183,9 +946,13 @@ func NewQueryHandler(next ...Handler) Handler {
 //NewExecuteHandler returns query handler with EndorseTxHandler, EndorsementValidationHandler & CommitTxHandler Chained
 func NewExecuteHandler(next ...Handler) Handler {
 	return NewProposalProcessorHandler(
-		NewEndorsementHandler(
-			NewEndorsementValidationHandler(
-				NewSignatureValidationHandler(NewCommitHandler(next...)),
+		NewRequestShapingHandler(
+			NewShadowEndorsementHandler(
+				NewEndorsementHandler(
+					NewEndorsementValidationHandler(
+						NewSignatureValidationHandler(newRequestTransactionSizeLimitHandler(NewConfirmationHandler(NewCommitHandler(next...)))),
+					),
+				),
 			),
 		),
 	)
@@ -218,7 +985,171 @@ func getNext(next []Handler) Handler {
 	return nil
 }
 
-func createAndSendTransaction(sender fab.Sender, proposal *fab.TransactionProposal, resps []*fab.TransactionProposalResponse) (*fab.TransactionResponse, error) {
+// txEventFilter returns an event filter that is equivalent to the given filter but which is unique to the
+// transaction, since the event service allows only one registration per chaincode ID and event filter.
+// The appended optional group always matches the empty string so it doesn't affect the events matched.
+func txEventFilter(eventFilter, txID string) string {
+	return "(?:" + eventFilter + ")(?:" + regexp.QuoteMeta(txID) + ")?"
+}
+
+// waitForChaincodeEvent waits for the chaincode event set by the given transaction
+func waitForChaincodeEvent(ctx reqContext.Context, eventch <-chan *fab.CCEvent, txID string) (*fab.CCEvent, error) {
+	for {
+		select {
+		case ccEvent, ok := <-eventch:
+			if !ok {
+				return nil, errors.New("chaincode event registration was closed")
+			}
+			if ccEvent.TxID == txID {
+				return ccEvent, nil
+			}
+		case <-ctx.Done():
+			return nil, errors.New("Execute didn't receive chaincode event")
+		}
+	}
+}
+
+// transactor returns the transactor supplied in the request options, if any,
+// otherwise the transactor of the client context
+func transactor(requestContext *RequestContext, clientContext *ClientContext) fab.Transactor {
+	if requestContext.Opts.Transactor != nil {
+		return requestContext.Opts.Transactor
+	}
+	return clientContext.Transactor
+}
+
+// commitTransactor returns the transactor with which the transaction is sent to the orderers. Unless the request
+// options override the transactor, the orderers are resolved from the latest channel config when the transaction is
+// sent (if the client context provides a CommitTransactor), so that the orderers added or removed by a config update
+// are taken into account by a commit (or retry) of a request that started before the update.
+func commitTransactor(requestContext *RequestContext, clientContext *ClientContext) fab.Transactor {
+	if requestContext.Opts.Transactor != nil || clientContext.CommitTransactor == nil {
+		return transactor(requestContext, clientContext)
+	}
+	t, err := clientContext.CommitTransactor()
+	if err != nil {
+		newLogFields(requestContext).warnf("unable to resolve the orderers from the latest channel config - using the orderers resolved at the start of the request: %s", err)
+		return clientContext.Transactor
+	}
+	return t
+}
+
+// channelEventService returns the event service of the channel of the transaction proposal if the client
+// context has an event service resolver, otherwise the event service of the client context
+func channelEventService(requestContext *RequestContext, clientContext *ClientContext) (fab.EventService, error) {
+	if clientContext.EventServiceResolver == nil || requestContext.Response.Proposal == nil {
+		return clientContext.EventService, nil
+	}
+
+	hdr, err := protos_utils.GetHeader(requestContext.Response.Proposal.Header)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to extract proposal header")
+	}
+	chdr, err := protos_utils.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to extract channel header")
+	}
+	return clientContext.EventServiceResolver.EventService(chdr.ChannelId)
+}
+
+// registerTxStatusEvent registers for the TxStatus event on the given event service and on each of
+// the additional event services. The events received from all of the event services are delivered on
+// the returned channel. Registration failures on additional event services are logged and ignored.
+// The number of event services on which the registration succeeded is also returned, along with
+// a function that must be called to unregister.
+func registerTxStatusEvent(clientContext *ClientContext, eventService fab.EventService, txID string, fields logFields) (<-chan *fab.TxStatusEvent, int, func(), error) {
+	eventch, unregister, err := registerTxStatus(clientContext, eventService, txID, fields)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if len(clientContext.AdditionalEventServices) == 0 {
+		return eventch, 1, unregister, nil
+	}
+
+	unregisters := []func(){unregister}
+	eventchs := []<-chan *fab.TxStatusEvent{eventch}
+	for _, eventService := range clientContext.AdditionalEventServices {
+		eventch, unregister, err := registerTxStatus(clientContext, eventService, txID, fields)
+		if err != nil {
+			fields.warnf("error registering for TxStatus event on additional event service: %s", err)
+			continue
+		}
+		unregisters = append(unregisters, unregister)
+		eventchs = append(eventchs, eventch)
+	}
+
+	done := make(chan struct{})
+	statusNotifier := make(chan *fab.TxStatusEvent, len(eventchs))
+	for _, eventch := range eventchs {
+		go func(eventch <-chan *fab.TxStatusEvent) {
+			select {
+			case txStatus, ok := <-eventch:
+				if ok {
+					statusNotifier <- txStatus
+				}
+			case <-done:
+			}
+		}(eventch)
+	}
+
+	unregister = func() {
+		close(done)
+		for _, unregister := range unregisters {
+			unregister()
+		}
+	}
+
+	return statusNotifier, len(eventchs), unregister, nil
+}
+
+// registerTxStatus registers for the TxStatus event on the given event service, through the BatchCommitListener
+// of the client context if set. The registration is shared with the other handlers waiting for the transaction
+// (see the TxStatusFanout of the client context). A function that must be called to unregister is also returned.
+func registerTxStatus(clientContext *ClientContext, eventService fab.EventService, txID string, fields logFields) (<-chan *fab.TxStatusEvent, func(), error) {
+	if listener := clientContext.BatchCommitListener; listener != nil {
+		return listener.Register(eventService, txID)
+	}
+	return clientContext.TxStatusFanout.register(eventService, txID, fields)
+}
+
+// registerTxStatusEventWithTimeout registers for the TxStatus event and fails with a timeout error
+// if the registration does not complete within the EventReg timeout. If no EventReg timeout is
+// set then the registration is not bounded.
+func registerTxStatusEventWithTimeout(requestContext *RequestContext, clientContext *ClientContext, eventService fab.EventService, txID string, fields logFields) (<-chan *fab.TxStatusEvent, int, func(), error) {
+	timeout := requestContext.Opts.Timeouts[core.EventReg]
+	if timeout <= 0 {
+		return registerTxStatusEvent(clientContext, eventService, txID, fields)
+	}
+
+	type registration struct {
+		statusNotifier <-chan *fab.TxStatusEvent
+		sources        int
+		unregister     func()
+		err            error
+	}
+
+	regch := make(chan registration, 1)
+	go func() {
+		statusNotifier, sources, unregister, err := registerTxStatusEvent(clientContext, eventService, txID, fields)
+		regch <- registration{statusNotifier: statusNotifier, sources: sources, unregister: unregister, err: err}
+	}()
+
+	select {
+	case reg := <-regch:
+		return reg.statusNotifier, reg.sources, reg.unregister, reg.err
+	case <-time.After(timeout):
+		// Release the registration if it eventually completes
+		go func() {
+			if reg := <-regch; reg.err == nil {
+				reg.unregister()
+			}
+		}()
+		return nil, 0, nil, status.New(status.ClientStatus, status.Timeout.ToInt32(),
+			fmt.Sprintf("timed out after %s waiting for TxStatus event registration", timeout), nil)
+	}
+}
+
+func createAndSendTransaction(sender fab.Sender, proposal *fab.TransactionProposal, resps []*fab.TransactionProposalResponse, opts ...fab.SendTxnOpt) (*fab.TransactionResponse, error) {
 
 	txnRequest := fab.TransactionRequest{
 		Proposal:          proposal,
@@ -230,7 +1161,7 @@ func createAndSendTransaction(sender fab.Sender, proposal *fab.TransactionPropos
 		return nil, errors.WithMessage(err, "CreateTransaction failed")
 	}
 
-	transactionResponse, err := sender.SendTransaction(tx)
+	transactionResponse, err := sender.SendTransaction(tx, opts...)
 	if err != nil {
 		return nil, errors.WithMessage(err, "SendTransaction failed")
 
@@ -239,7 +1170,72 @@ func createAndSendTransaction(sender fab.Sender, proposal *fab.TransactionPropos
 	return transactionResponse, nil
 }
 
-func createAndSendTransactionProposal(transactor fab.Transactor, chrequest *Request, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, *fab.TransactionProposal, error) {
+func createAndSendTransactionProposal(requestContext *RequestContext, clientContext *ClientContext, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, *fab.TransactionProposal, error) {
+	transactor := transactor(requestContext, clientContext)
+
+	txh, request, proposal, err := buildChaincodeProposal(requestContext, clientContext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if observer := requestContext.Opts.ProposalObserver; observer != nil {
+		proposalBytes, err := proto.Marshal(proposal.Proposal)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "marshal of transaction proposal failed")
+		}
+		observer(proposal.TxnID, proposalBytes)
+	}
+
+	var sendOpts []fab.SendProposalOpt
+	if signer := requestContext.Opts.ProposalSigner; signer != nil {
+		sendOpts = append(sendOpts, fab.WithProposalSigner(signer))
+	}
+	if requestContext.Opts.CompressProposal {
+		sendOpts = append(sendOpts, fab.WithProposalCompression())
+	}
+	if md := requestContext.Opts.ProposalMetadata; len(md) > 0 {
+		sendOpts = append(sendOpts, fab.WithProposalMetadata(md))
+	}
+	if callOpts := requestContext.Opts.ProposalCallOptions; len(callOpts) > 0 {
+		sendOpts = append(sendOpts, fab.WithProposalCallOptions(callOpts...))
+	}
+
+	if overrides := requestContext.Opts.TransientMapOverrides; len(overrides) > 0 {
+		transactionProposalResponses, err := sendProposalVariants(transactor, txh, request, proposal, overrides, targets, sendOpts...)
+		return transactionProposalResponses, proposal, err
+	}
+
+	transactionProposalResponses, err := transactor.SendTransactionProposal(proposal, targets, sendOpts...)
+	return transactionProposalResponses, proposal, err
+}
+
+// SelectEndorsers selects the endorsers for the request of the given request context in the same way as
+// ProposalProcessorHandler, without creating or sending a proposal. Only the chaincode ID (and the collections
+// and selection options) of the request are used, so it may be used to populate discovery and selection (and the
+// selection cache of the client, if any) ahead of the first invoke.
+func SelectEndorsers(requestContext *RequestContext, clientContext *ClientContext) ([]fab.Peer, error) {
+	return (&ProposalProcessorHandler{}).selectEndorsersAtHeight(requestContext, clientContext)
+}
+
+// BuildChaincodeProposal creates the transaction proposal for the request of the given request context without
+// sending it. The proposal is created in the same way as by the handlers: the request is shaped by the request
+// options (see RequestShapingHandler), and the request transformer of the client and the nonce generator of the
+// request options are applied. This allows the proposal to be signed offline, for
+// example, and submitted later.
+func BuildChaincodeProposal(requestContext *RequestContext, clientContext *ClientContext) (*fab.TransactionProposal, error) {
+	if _, err := shapeRequest(requestContext); err != nil {
+		return nil, err
+	}
+	_, _, proposal, err := buildChaincodeProposal(requestContext, clientContext)
+	return proposal, err
+}
+
+// buildChaincodeProposal creates the transaction header and the transaction proposal for the request of the given
+// request context. The (transformed) chaincode invoke request is also returned so that variants can be created.
+func buildChaincodeProposal(requestContext *RequestContext, clientContext *ClientContext) (fab.TransactionHeader, fab.ChaincodeInvokeRequest, *fab.TransactionProposal, error) {
+	transactor := transactor(requestContext, clientContext)
+	chrequest := &requestContext.Request
+
 	request := fab.ChaincodeInvokeRequest{
 		ChaincodeID:  chrequest.ChaincodeID,
 		Fcn:          chrequest.Fcn,
@@ -247,16 +1243,30 @@ func createAndSendTransactionProposal(transactor fab.Transactor, chrequest *Requ
 		TransientMap: chrequest.TransientMap,
 	}
 
-	txh, err := transactor.CreateTransactionHeader()
+	if transform := clientContext.RequestTransformer; transform != nil {
+		if err := transform(&request); err != nil {
+			return nil, request, nil, errors.WithMessage(err, "transforming chaincode invoke request failed")
+		}
+	}
+
+	var opts []fab.TxnHeaderOpt
+	if nonceGenerator := requestContext.Opts.NonceGenerator; nonceGenerator != nil {
+		nonce, err := nonceGenerator()
+		if err != nil {
+			return nil, request, nil, errors.WithMessage(err, "generating nonce failed")
+		}
+		opts = append(opts, fab.WithNonce(nonce))
+	}
+
+	txh, err := transactor.CreateTransactionHeader(opts...)
 	if err != nil {
-		return nil, nil, errors.WithMessage(err, "creating transaction header failed")
+		return nil, request, nil, errors.WithMessage(err, "creating transaction header failed")
 	}
 
 	proposal, err := txn.CreateChaincodeInvokeProposal(txh, request)
 	if err != nil {
-		return nil, nil, errors.WithMessage(err, "creating transaction proposal failed")
+		return nil, request, nil, errors.WithMessage(err, "creating transaction proposal failed")
 	}
 
-	transactionProposalResponses, err := transactor.SendTransactionProposal(proposal, targets)
-	return transactionProposalResponses, proposal, err
+	return txh, request, proposal, nil
 }
@@ -8,20 +8,30 @@ package invoke
 
 import (
 	reqContext "context"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
 
 	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
+	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb_msp "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
 )
 
 const (
@@ -88,74 +98,874 @@ func TestExecuteTxHandlerSuccess(t *testing.T) {
 	assert.Nil(t, requestContext.Error)
 }
 
+func TestExecuteTxHandlerWithCommitTransactor(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	executeHandler := NewExecuteHandler()
+
+	// The orderer resolved at the start of the request was removed from the channel
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	removedOrderer := fcmocks.NewMockOrderer("removed:7050", nil)
+	clientContext.Transactor.(*txnmocks.MockTransactor).Orderers = []fab.Orderer{removedOrderer}
+	latestTransactor := &txnmocks.MockTransactor{Ctx: setupTestContext(), ChannelID: "testChannel", Orderers: []fab.Orderer{fcmocks.NewMockOrderer("added:7050", nil)}}
+	resolved := 0
+	clientContext.CommitTransactor = func() (fab.Transactor, error) {
+		resolved++
+		return latestTransactor, nil
+	}
+
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_VALID)
+	removedOrderer.BroadcastErrors <- errors.New("orderer removed")
+
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, 1, resolved)
+
+	// The orderers resolved at the start of the request are used if the latest orderers can't be resolved
+	clientContext.CommitTransactor = func() (fab.Transactor, error) {
+		return nil, errors.New("channel config unavailable")
+	}
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	executeHandler.Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error, "expecting the transaction to be sent to the removed orderer")
+}
+
+func TestExecuteTxHandlerWithAdditionalEventServices(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	executeHandler := NewExecuteHandler()
+
+	// The client's event service is lagging but the additional event service reports the commit
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t)
+	clientContext.EventService = fcmocks.NewMockEventService()
+	additionalEventService := fcmocks.NewMockEventService()
+	clientContext.AdditionalEventServices = []fab.EventService{additionalEventService}
+	go sendTxStatusEvent(additionalEventService, pb.TxValidationCode_VALID)
+
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+
+	// Both event services must report the commit
+	requestContext = prepareRequestContext(request, Opts{CommitQuorum: 2}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	additionalEventService = fcmocks.NewMockEventService()
+	clientContext.AdditionalEventServices = []fab.EventService{additionalEventService}
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_VALID)
+	go sendTxStatusEvent(additionalEventService, pb.TxValidationCode_VALID)
+
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, pb.TxValidationCode_VALID, requestContext.Response.TxValidationCode)
+
+	// Quorum exceeds the number of event services
+	requestContext = prepareRequestContext(request, Opts{CommitQuorum: 3}, t)
+	clientContext.EventService = fcmocks.NewMockEventService()
+	clientContext.AdditionalEventServices = []fab.EventService{fcmocks.NewMockEventService()}
+
+	executeHandler.Handle(requestContext, clientContext)
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "exceeds the number of registered event sources") {
+		t.Fatal("Expected commit quorum error, Received error:", requestContext.Error)
+	}
+}
+
+func TestExecuteTxHandlerWithChaincodeEvent(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	requestContext := prepareRequestContext(request, Opts{ChaincodeEventFilter: "^transfer$"}, t)
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+
+	go func() {
+		ccReg := <-mockEventService.ChaincodeRegCh
+		txStatusReg := <-mockEventService.TxStatusRegCh
+		txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+		ccReg.Eventch <- &fab.CCEvent{TxID: "otherTxID", ChaincodeID: "test", EventName: "transfer", Payload: []byte("other")}
+		ccReg.Eventch <- &fab.CCEvent{TxID: txStatusReg.TxID, ChaincodeID: "test", EventName: "transfer", Payload: []byte("payload")}
+	}()
+
+	executeHandler := NewExecuteHandler()
+	executeHandler.Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	if assert.NotNil(t, requestContext.Response.ChaincodeEvent) {
+		assert.Equal(t, []byte("payload"), requestContext.Response.ChaincodeEvent.Payload)
+	}
+}
+
+func TestExecuteTxHandlerWithEventServiceResolver(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	defaultEventService := fcmocks.NewMockEventService()
+	channelEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = defaultEventService
+	clientContext.EventServiceResolver = ChannelEventServices{"testChannel": channelEventService}
+
+	go func() {
+		txStatusReg := <-channelEventService.TxStatusRegCh
+		txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+	}()
+
+	executeHandler := NewExecuteHandler()
+	executeHandler.Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.Equal(t, 0, len(defaultEventService.TxStatusRegCh), "expecting the registration to be routed to the channel's event service")
+
+	// No event service for the channel
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	clientContext.EventServiceResolver = ChannelEventServices{}
+	executeHandler.Handle(requestContext, clientContext)
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "no event service for channel [testChannel]") {
+		t.Fatal("Expected event service resolution error, Received error:", requestContext.Error)
+	}
+}
+
+func TestExecuteTxHandlerWithAcceptedValidationCodes(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+
+	executeHandler := NewExecuteHandler()
+
+	// The duplicate is accepted and the chaincode event is not waited for
+	requestContext := prepareRequestContext(request, Opts{AcceptedValidationCodes: []pb.TxValidationCode{pb.TxValidationCode_DUPLICATE_TXID}, ChaincodeEventFilter: "transfer"}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_DUPLICATE_TXID)
+
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, pb.TxValidationCode_DUPLICATE_TXID, requestContext.Response.TxValidationCode)
+	assert.Nil(t, requestContext.Response.ChaincodeEvent)
+
+	// Other codes are still errors
+	requestContext = prepareRequestContext(request, Opts{AcceptedValidationCodes: []pb.TxValidationCode{pb.TxValidationCode_DUPLICATE_TXID}}, t)
+	mockEventService = fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_MVCC_READ_CONFLICT)
+
+	executeHandler.Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error, Received error: %v", requestContext.Error)
+	}
+	assert.EqualValues(t, pb.TxValidationCode_MVCC_READ_CONFLICT, s.Code)
+}
+
+func TestExecuteTxHandlerWithCommitObserver(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+
+	var outcomes []CommitOutcome
+	observer := func(outcome CommitOutcome) {
+		outcomes = append(outcomes, outcome)
+	}
+
+	requestContext := prepareRequestContext(request, Opts{CommitObserver: observer}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go func() {
+		txStatusReg := <-mockEventService.TxStatusRegCh
+		txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID, BlockNumber: 12}
+	}()
+
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	if assert.Equal(t, 1, len(outcomes)) {
+		assert.Equal(t, requestContext.Response.TransactionID, outcomes[0].TxID)
+		assert.EqualValues(t, 12, outcomes[0].BlockNumber)
+		assert.Empty(t, outcomes[0].WriteKeys, "the mock endorsement has no writes")
+	}
+
+	// The observer isn't invoked for an accepted validation code
+	requestContext = prepareRequestContext(request, Opts{CommitObserver: observer, AcceptedValidationCodes: []pb.TxValidationCode{pb.TxValidationCode_DUPLICATE_TXID}}, t)
+	mockEventService = fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+	go sendTxStatusEvent(mockEventService, pb.TxValidationCode_DUPLICATE_TXID)
+
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, 1, len(outcomes))
+}
+
+func TestExecuteTxHandlerOrdererRejection(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+
+	orderer := fcmocks.NewMockOrderer("", nil)
+	clientContext.Transactor = &txnmocks.MockTransactor{Ctx: setupTestContext(), ChannelID: "testChannel", Orderers: []fab.Orderer{orderer}}
+
+	// Rejected by the ordering service
+	orderer.BroadcastErrors <- status.New(status.OrdererServerStatus, int32(common.Status_FORBIDDEN), "policy not satisfied", nil)
+	clientContext.EventService = fcmocks.NewMockEventService()
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error, Received error: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.OrdererClientStatus, s.Group)
+	assert.EqualValues(t, status.OrdererRejected, s.Code)
+	assert.Equal(t, []interface{}{common.Status_FORBIDDEN}, s.Details)
+
+	// The ordering service being unavailable is not a rejection
+	orderer.BroadcastErrors <- status.New(status.OrdererServerStatus, int32(common.Status_SERVICE_UNAVAILABLE), "unavailable", nil)
+	clientContext.EventService = fcmocks.NewMockEventService()
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	s, ok = status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error, Received error: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.OrdererServerStatus, s.Group)
+	assert.EqualValues(t, common.Status_SERVICE_UNAVAILABLE, s.Code)
+}
+
+func TestExecuteTxHandlerSubmitApprover(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	clientContext.EventService = fcmocks.NewMockEventService()
+
+	// The orderer fails the broadcast if the transaction is submitted
+	orderer := fcmocks.NewMockOrderer("", nil)
+	orderer.BroadcastErrors <- status.New(status.OrdererServerStatus, int32(common.Status_SERVICE_UNAVAILABLE), "unavailable", nil)
+	clientContext.Transactor = &txnmocks.MockTransactor{Ctx: setupTestContext(), ChannelID: "testChannel", Orderers: []fab.Orderer{orderer}}
+
+	var approved Response
+	approver := func(response Response) error {
+		approved = response
+		return errors.New("policy check failed")
+	}
+	requestContext := prepareRequestContext(request, Opts{SubmitApprover: approver}, t)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error, Received error: %v", requestContext.Error)
+	}
+	assert.EqualValues(t, status.VetoedBeforeSubmit, s.Code)
+	assert.Contains(t, s.Message, "policy check failed")
+	assert.Equal(t, []byte("value"), approved.Payload, "expecting the approver to receive the endorsement response")
+	assert.Len(t, orderer.BroadcastErrors, 1, "expecting the vetoed transaction not to be submitted")
+}
+
+func TestExecuteTxHandlerEventRegistrationTimeout(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	requestContext.Opts.Timeouts[core.EventReg] = 100 * time.Millisecond
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+
+	// Fill the registration channel so that the registration blocks
+	mockEventService := fcmocks.NewMockEventService()
+	mockEventService.TxStatusRegCh <- &dispatcher.TxStatusReg{}
+	clientContext.EventService = mockEventService
+
+	executeHandler := NewExecuteHandler()
+	executeHandler.Handle(requestContext, clientContext)
+
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error, Received error: %v", requestContext.Error)
+	}
+	assert.EqualValues(t, status.Timeout.ToInt32(), s.Code)
+	assert.Contains(t, s.Message, "TxStatus event registration")
+
+	// Unblock the pending registration
+	<-mockEventService.TxStatusRegCh
+	<-mockEventService.TxStatusRegCh
+}
+
+// duplicateRegEventService rejects the TxStatus registrations as duplicates
+type duplicateRegEventService struct {
+	*fcmocks.MockEventService
+}
+
+func (s *duplicateRegEventService) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+	return nil, nil, errors.WithMessage(&dispatcher.TxRegistrationExistsError{TxID: txID}, "registration failed")
+}
+
+func TestExecuteTxHandlerDuplicateRegistration(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+
+	// The transaction was registered on the event service outside of the handlers, so the registration can't be shared
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	clientContext.EventService = &duplicateRegEventService{MockEventService: fcmocks.NewMockEventService()}
+
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "registration already exists") {
+		t.Fatal("Expected duplicate registration error, Received error:", requestContext.Error)
+	}
+}
+
+func TestTxEventFilter(t *testing.T) {
+	regExp := regexp.MustCompile(txEventFilter("^transfer$", "txid"))
+	assert.True(t, regExp.MatchString("transfer"))
+	assert.False(t, regExp.MatchString("transfer2"))
+	assert.False(t, regExp.MatchString("other"))
+	assert.NotEqual(t, txEventFilter("^transfer$", "txid1"), txEventFilter("^transfer$", "txid2"))
+}
+
+func sendTxStatusEvent(eventService *fcmocks.MockEventService, code pb.TxValidationCode) {
+	txStatusReg := <-eventService.TxStatusRegCh
+	txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: code}
+}
+
 func TestQueryHandlerErrors(t *testing.T) {
 
-	//Error Scenario 1
+	//Error Scenario 1
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	//Prepare context objects for handler
+	requestContext := prepareRequestContext(request, Opts{}, t)
+
+	//Get query handler
+	queryHandler := NewQueryHandler()
+
+	//Error Scenario 1
+	clientContext := setupChannelClientContext(nil, errors.New(selectionServiceError), nil, t)
+
+	//Perform action through handler
+	queryHandler.Handle(requestContext, clientContext)
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), selectionServiceError) {
+		t.Fatal("Expected error: ", selectionServiceError, ", Received error:", requestContext.Error.Error())
+	}
+
+	//Error Scenario 2 different payload return
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200,
+		Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200,
+		Payload: []byte("value1")}
+
+	clientContext = setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t)
+
+	//Perform action through handler
+	queryHandler.Handle(requestContext, clientContext)
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), endorsementMisMatchError) {
+		t.Fatal("Expected error: ", endorsementMisMatchError, ", Received error:", requestContext.Error.Error())
+	}
+}
+
+func TestExecuteTxHandlerErrors(t *testing.T) {
+
+	//Sample request
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	//Prepare context objects for handler
+	requestContext := prepareRequestContext(request, Opts{}, t)
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP",
+		Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP",
+		Status: 200, Payload: []byte("value1")}
+
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t)
+
+	//Get query handler
+	executeHandler := NewExecuteHandler()
+	//Perform action through handler
+	executeHandler.Handle(requestContext, clientContext)
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), endorsementMisMatchError) {
+		t.Fatal("Expected error: ", endorsementMisMatchError, ", Received error:", requestContext.Error.Error())
+	}
+}
+
+func TestEndorsementHandler(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("p2", "")}}, t)
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+
+	handler := NewEndorsementHandler()
+	handler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+}
+
+func TestEndorsementHandlerWithTransactor(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	transactor := &txnmocks.MockTransactor{
+		Ctx:       setupTestContext(),
+		ChannelID: "testChannel",
+	}
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("p2", "")}, Transactor: transactor}, t)
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	clientContext.Transactor = nil
+
+	handler := NewEndorsementHandler()
+	handler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.NotNil(t, requestContext.Response.Proposal)
+}
+
+func TestEndorsementHandlerWithNonceGenerator(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	nonceGenerator := func() ([]byte, error) {
+		return []byte("nonce"), nil
+	}
+
+	handler := NewEndorsementHandler()
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("p2", "")}, NonceGenerator: nonceGenerator}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+	txnID := requestContext.Response.TransactionID
+
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("p2", "")}, NonceGenerator: nonceGenerator}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, txnID, requestContext.Response.TransactionID, "expecting the same transaction ID for the same nonce")
+
+	nonceErr := errors.New("nonce error")
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("p2", "")}, NonceGenerator: func() ([]byte, error) { return nil, nonceErr }}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), nonceErr.Error()) {
+		t.Fatal("Expected error: ", nonceErr, ", Received error:", requestContext.Error)
+	}
+}
+
+// capturingProcessor records the signed proposal that it receives
+type capturingProcessor struct {
+	signedProposal *pb.SignedProposal
+	compress       bool
+	metadata       map[string]string
+	callOptions    []grpc.CallOption
+}
+
+func (p *capturingProcessor) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.signedProposal = request.SignedProposal
+	p.compress = request.Compress
+	p.metadata = request.Metadata
+	p.callOptions = request.CallOptions
+	return &fab.TransactionProposalResponse{Endorser: "capturing", Status: 200, ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{Status: 200}}}, nil
+}
+
+func TestCreateAndSendTransactionProposalWithObserver(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	var observedTxnID fab.TransactionID
+	var observedProposal []byte
+	observer := func(txnID fab.TransactionID, proposal []byte) {
+		observedTxnID = txnID
+		observedProposal = proposal
+	}
+
+	processor := &capturingProcessor{}
+	requestContext := prepareRequestContext(request, Opts{ProposalObserver: observer}, t)
+	_, proposal, err := createAndSendTransactionProposal(requestContext, setupChannelClientContext(nil, nil, nil, t), []fab.ProposalProcessor{processor})
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+
+	assert.Equal(t, proposal.TxnID, observedTxnID)
+	if assert.NotNil(t, processor.signedProposal) {
+		assert.Equal(t, processor.signedProposal.ProposalBytes, observedProposal, "expecting the observed proposal to be the signed proposal")
+	}
+}
+
+func TestCreateAndSendTransactionProposalWithSigner(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	var signedBytes []byte
+	signer := func(proposal []byte) ([]byte, error) {
+		signedBytes = proposal
+		return []byte("hsm-signature"), nil
+	}
+
+	processor := &capturingProcessor{}
+	requestContext := prepareRequestContext(request, Opts{ProposalSigner: signer}, t)
+	_, _, err := createAndSendTransactionProposal(requestContext, setupChannelClientContext(nil, nil, nil, t), []fab.ProposalProcessor{processor})
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	if assert.NotNil(t, processor.signedProposal) {
+		assert.Equal(t, []byte("hsm-signature"), processor.signedProposal.Signature)
+		assert.Equal(t, processor.signedProposal.ProposalBytes, signedBytes, "expecting the signer to sign the proposal bytes")
+	}
+
+	failingSigner := func(proposal []byte) ([]byte, error) {
+		return nil, errors.New("HSM unavailable")
+	}
+	requestContext = prepareRequestContext(request, Opts{ProposalSigner: failingSigner}, t)
+	_, _, err = createAndSendTransactionProposal(requestContext, setupChannelClientContext(nil, nil, nil, t), []fab.ProposalProcessor{&capturingProcessor{}})
+	if err == nil || !strings.Contains(err.Error(), "HSM unavailable") {
+		t.Fatal("Expected signer error, Received error:", err)
+	}
+}
+
+func TestCreateAndSendTransactionProposalWithCompression(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	processor := &capturingProcessor{}
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	_, _, err := createAndSendTransactionProposal(requestContext, setupChannelClientContext(nil, nil, nil, t), []fab.ProposalProcessor{processor})
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.False(t, processor.compress, "expecting the proposal not to be compressed by default")
+
+	processor = &capturingProcessor{}
+	requestContext = prepareRequestContext(request, Opts{CompressProposal: true}, t)
+	_, _, err = createAndSendTransactionProposal(requestContext, setupChannelClientContext(nil, nil, nil, t), []fab.ProposalProcessor{processor})
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.True(t, processor.compress, "expecting compression to be requested")
+}
+
+func TestCreateAndSendTransactionProposalWithMetadata(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	processor := &capturingProcessor{}
+	opts := Opts{
+		ProposalMetadata:    map[string]string{"x-tenant-id": "tenant1"},
+		ProposalCallOptions: []grpc.CallOption{grpc.MaxCallSendMsgSize(1024)},
+	}
+	requestContext := prepareRequestContext(request, opts, t)
+	_, _, err := createAndSendTransactionProposal(requestContext, setupChannelClientContext(nil, nil, nil, t), []fab.ProposalProcessor{processor})
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.Equal(t, map[string]string{"x-tenant-id": "tenant1"}, processor.metadata)
+	assert.Equal(t, 1, len(processor.callOptions))
+}
+
+func TestEndorsementHandlerWithRequestTransformer(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("p2", "")}}, t)
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	clientContext.RequestTransformer = func(request *fab.ChaincodeInvokeRequest) error {
+		request.Args = append([][]byte{[]byte("tenant1")}, request.Args...)
+		return nil
+	}
+
+	handler := NewEndorsementHandler()
+	handler.Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+
+	ccProposalPayload, err := protos_utils.GetChaincodeProposalPayload(requestContext.Response.Proposal.Payload)
+	if err != nil {
+		t.Fatalf("Failed to get chaincode proposal payload: %s", err)
+	}
+	cis := &pb.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(ccProposalPayload.Input, cis); err != nil {
+		t.Fatalf("Failed to unmarshal chaincode invocation spec: %s", err)
+	}
+	args := cis.ChaincodeSpec.Input.Args
+	if assert.Equal(t, 6, len(args)) {
+		assert.Equal(t, "invoke", string(args[0]))
+		assert.Equal(t, "tenant1", string(args[1]))
+	}
+
+	transformErr := errors.New("transform error")
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("p2", "")}}, t)
+	clientContext.RequestTransformer = func(request *fab.ChaincodeInvokeRequest) error {
+		return transformErr
+	}
+	handler.Handle(requestContext, clientContext)
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), transformErr.Error()) {
+		t.Fatal("Expected error: ", transformErr, ", Received error:", requestContext.Error)
+	}
+}
+
+func TestBuildChaincodeProposal(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	peer := fcmocks.NewMockPeer("p2", "")
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peer}}, t)
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	clientContext.RequestTransformer = func(request *fab.ChaincodeInvokeRequest) error {
+		request.Args = append([][]byte{[]byte("tenant1")}, request.Args...)
+		return nil
+	}
+
+	proposal, err := BuildChaincodeProposal(requestContext, clientContext)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.NotEmpty(t, proposal.TxnID)
+	assert.Equal(t, 0, peer.ProcessProposalCalls, "expected proposal not to be sent")
+
+	ccProposalPayload, err := protos_utils.GetChaincodeProposalPayload(proposal.Payload)
+	if err != nil {
+		t.Fatalf("Failed to get chaincode proposal payload: %s", err)
+	}
+	cis := &pb.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(ccProposalPayload.Input, cis); err != nil {
+		t.Fatalf("Failed to unmarshal chaincode invocation spec: %s", err)
+	}
+	if args := cis.ChaincodeSpec.Input.Args; assert.Equal(t, 6, len(args)) {
+		assert.Equal(t, "tenant1", string(args[1]))
+	}
+}
+
+func TestEndorsementValidationHandlerWithPayloadQuorum(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value1")}
+	mockPeer3 := &fcmocks.MockPeer{MockName: "Peer3", MockURL: "http://peer3.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peers := []fab.Peer{mockPeer1, mockPeer2, mockPeer3}
+
+	queryHandler := NewQueryHandler()
+
+	requestContext := prepareRequestContext(request, Opts{PayloadQuorum: 2}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	assert.Equal(t, 2, len(requestContext.Response.Responses))
+	if assert.Equal(t, 1, len(requestContext.Response.Dissenters)) {
+		assert.Equal(t, mockPeer2.MockURL, requestContext.Response.Dissenters[0].Endorser)
+	}
+
+	requestContext = prepareRequestContext(request, Opts{PayloadQuorum: 3}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), endorsementMisMatchError) {
+		t.Fatal("Expected error: ", endorsementMisMatchError, ", Received error:", requestContext.Error)
+	}
+}
+
+func TestEndorsementValidationHandlerQuorumTieBreak(t *testing.T) {
 	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
 
-	//Prepare context objects for handler
-	requestContext := prepareRequestContext(request, Opts{}, t)
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value2")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value1")}
+
+	// The smallest payload wins the tie, regardless of the order of the responses
+	for _, peers := range [][]fab.Peer{{mockPeer1, mockPeer2}, {mockPeer2, mockPeer1}} {
+		requestContext := prepareRequestContext(request, Opts{PayloadQuorum: 1, Targets: peers}, t)
+		NewQueryHandler().Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+		if requestContext.Error != nil {
+			t.Fatalf("Got error: %s", requestContext.Error)
+		}
+		assert.Equal(t, []byte("value1"), requestContext.Response.Payload)
+		if assert.Equal(t, 1, len(requestContext.Response.Dissenters)) {
+			assert.Equal(t, mockPeer1.MockURL, requestContext.Response.Dissenters[0].Endorser)
+		}
+	}
+}
+
+func TestEndorsementValidationHandlerAcceptedStatuses(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 201, Payload: []byte("value")}
+	peers := []fab.Peer{mockPeer1, mockPeer2}
 
-	//Get query handler
 	queryHandler := NewQueryHandler()
 
-	//Error Scenario 1
-	clientContext := setupChannelClientContext(nil, errors.New(selectionServiceError), nil, t)
+	// 201 isn't accepted by default
+	requestContext := prepareRequestContext(request, Opts{Targets: peers}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.NotNil(t, requestContext.Error)
 
-	//Perform action through handler
-	queryHandler.Handle(requestContext, clientContext)
-	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), selectionServiceError) {
-		t.Fatal("Expected error: ", selectionServiceError, ", Received error:", requestContext.Error.Error())
+	requestContext = prepareRequestContext(request, Opts{Targets: peers, AcceptedStatuses: []int32{201}}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+
+	// The endorsers must agree on the status in strict mode
+	requestContext = prepareRequestContext(request, Opts{Targets: peers, AcceptedStatuses: []int32{201}, RequireStatusAgreement: true}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
 	}
+	assert.Equal(t, status.StatusDisagreement.ToInt32(), s.Code)
 
-	//Error Scenario 2 different payload return
-	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200,
-		Payload: []byte("value")}
-	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200,
-		Payload: []byte("value1")}
+	// The details are the endorsers that disagree with the majority status
+	mockPeer3 := &fcmocks.MockPeer{MockName: "Peer3", MockURL: "http://peer3.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 201, Payload: []byte("value")}
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer1, mockPeer2, mockPeer3}, AcceptedStatuses: []int32{201}, RequireStatusAgreement: true}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	s, ok = status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.StatusDisagreement.ToInt32(), s.Code)
+	assert.Equal(t, []interface{}{mockPeer1.MockURL}, s.Details)
 
-	clientContext = setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t)
+	mockPeer1.Status = 201
+	requestContext = prepareRequestContext(request, Opts{Targets: peers, AcceptedStatuses: []int32{201}, RequireStatusAgreement: true}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+}
 
-	//Perform action through handler
-	queryHandler.Handle(requestContext, clientContext)
-	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), endorsementMisMatchError) {
-		t.Fatal("Expected error: ", endorsementMisMatchError, ", Received error:", requestContext.Error.Error())
-	}
+func TestEndorsementValidationHandlerSkipComparison(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value1")}
+	mockPeer3 := &fcmocks.MockPeer{MockName: "Peer3", MockURL: "http://peer3.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 500, Payload: []byte("value")}
+
+	queryHandler := NewQueryHandler()
+
+	// A single response isn't compared (the mock response has no write set to extract)
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer1}, CompareWriteSets: true}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer1, mockPeer2}, SkipPayloadComparison: true}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
+
+	// The status is still checked
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer3}}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.NotNil(t, requestContext.Error)
+
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer1, mockPeer3}, SkipPayloadComparison: true}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.NotNil(t, requestContext.Error)
 }
 
-func TestExecuteTxHandlerErrors(t *testing.T) {
+func TestEndorsementValidationHandlerRequireNonEmptyPayload(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
 
-	//Sample request
-	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP", Status: 200}
 
-	//Prepare context objects for handler
-	requestContext := prepareRequestContext(request, Opts{}, t)
+	queryHandler := NewQueryHandler()
 
-	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP",
-		Status: 200, Payload: []byte("value")}
-	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP",
-		Status: 200, Payload: []byte("value1")}
+	// An empty payload is accepted by default
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer2}}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
 
-	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t)
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer1}, RequireNonEmptyPayload: true}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	assert.Nil(t, requestContext.Error)
 
-	//Get query handler
-	executeHandler := NewExecuteHandler()
-	//Perform action through handler
-	executeHandler.Handle(requestContext, clientContext)
-	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), endorsementMisMatchError) {
-		t.Fatal("Expected error: ", endorsementMisMatchError, ", Received error:", requestContext.Error.Error())
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{mockPeer2}, RequireNonEmptyPayload: true}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.EmptyPayload.ToInt32(), s.Code)
+	assert.Equal(t, []interface{}{"http://peer2.com"}, s.Details)
+}
+
+func TestEndorsementValidationHandlerWithOrgs(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value"), Endorser: serializedIdentity("Org1MSP", t)}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value"), Endorser: serializedIdentity("Org1MSP", t)}
+	mockPeer3 := &fcmocks.MockPeer{MockName: "Peer3", MockURL: "http://peer3.com", MockMSP: "Org2MSP", Status: 200, Payload: []byte("value"), Endorser: serializedIdentity("Org2MSP", t)}
+
+	queryHandler := NewQueryHandler()
+
+	// Two endorsements from the same org
+	requestContext := prepareRequestContext(request, Opts{MinEndorsingOrgs: 2}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t))
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "at least 2 are required") {
+		t.Fatal("Expected min endorsing orgs error, Received error:", requestContext.Error)
+	}
+
+	requestContext = prepareRequestContext(request, Opts{MinEndorsingOrgs: 2}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer3}, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+
+	requestContext = prepareRequestContext(request, Opts{RequiredOrgs: []string{"Org1MSP", "Org2MSP"}}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t))
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "[Org2MSP]") {
+		t.Fatal("Expected required orgs error, Received error:", requestContext.Error)
+	}
+
+	requestContext = prepareRequestContext(request, Opts{RequiredOrgs: []string{"Org1MSP", "Org2MSP"}}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer2, mockPeer3}, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
 	}
 }
 
-func TestEndorsementHandler(t *testing.T) {
-	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+func TestQueryHandlerWithMandatoryPeers(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
 
-	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{fcmocks.NewMockPeer("p2", "")}}, t)
-	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", Status: 200, Payload: []byte("value")}
+	mockPeer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", Status: 200, Payload: []byte("value")}
+	regulator := &fcmocks.MockPeer{MockName: "Regulator", MockURL: "http://regulator.com", Status: 200, Payload: []byte("value")}
 
-	handler := NewEndorsementHandler()
-	handler.Handle(requestContext, clientContext)
-	assert.Nil(t, requestContext.Error)
+	queryHandler := NewQueryHandler()
+
+	// Selection fills the remaining endorsers
+	requestContext := prepareRequestContext(request, Opts{MandatoryPeers: []fab.Peer{regulator}}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, regulator}, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.Equal(t, []fab.Peer{regulator, mockPeer1}, requestContext.Opts.Targets, "expecting the mandatory peer to be contacted once")
+	assert.Equal(t, 2, len(requestContext.Response.Responses))
+
+	// The mandatory peer's endorsement is outvoted by the quorum
+	dissenter := &fcmocks.MockPeer{MockName: "Regulator", MockURL: "http://regulator.com", Status: 200, Payload: []byte("other")}
+	requestContext = prepareRequestContext(request, Opts{MandatoryPeers: []fab.Peer{dissenter}, PayloadQuorum: 2}, t)
+	queryHandler.Handle(requestContext, setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1, mockPeer2}, t))
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "mandatory peer(s) [http://regulator.com]") {
+		t.Fatal("Expected mandatory peers error, Received error:", requestContext.Error)
+	}
+}
+
+func TestQueryHandlerWithSortedResponses(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	peerC := &fcmocks.MockPeer{MockName: "PeerC", MockURL: "http://peerc.com", Status: 200, Payload: []byte("value"), Endorser: serializedIdentity("Org1MSP", t)}
+	peerB := &fcmocks.MockPeer{MockName: "PeerB", MockURL: "http://peerb.com", Status: 200, Payload: []byte("value"), Endorser: serializedIdentity("Org2MSP", t)}
+	peerA := &fcmocks.MockPeer{MockName: "PeerA", MockURL: "http://peera.com", Status: 200, Payload: []byte("value"), Endorser: serializedIdentity("Org2MSP", t)}
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peerB, peerA, peerC}, SortResponses: true}, t)
+	NewQueryHandler().Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+
+	var endorsers []string
+	for _, r := range requestContext.Response.Responses {
+		endorsers = append(endorsers, r.Endorser)
+	}
+	assert.Equal(t, []string{"http://peerc.com", "http://peera.com", "http://peerb.com"}, endorsers)
+}
+
+func serializedIdentity(mspID string, t *testing.T) []byte {
+	identity, err := proto.Marshal(&pb_msp.SerializedIdentity{Mspid: mspID, IdBytes: []byte("cert")})
+	if err != nil {
+		t.Fatalf("Failed to marshal serialized identity: %s", err)
+	}
+	return identity
 }
 
 // Target filter
@@ -222,6 +1032,196 @@ func TestProposalProcessorHandler(t *testing.T) {
 	}
 }
 
+func TestProposalProcessorHandlerWithPreferredLabels(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer1.MockMetadata = map[string]string{"zone": "east"}
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+	peer2.MockMetadata = map[string]string{"zone": "west"}
+	discoveryPeers := []fab.Peer{peer1, peer2}
+
+	handler := NewProposalProcessorHandler()
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	requestContext := prepareRequestContext(request, Opts{PreferredLabels: map[string]string{"zone": "west"}}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	if len(requestContext.Opts.Targets) != 1 || requestContext.Opts.Targets[0] != peer2 {
+		t.Fatalf("Expecting only the peer matching the preferred labels to be selected")
+	}
+
+	// No peer matches the preferred labels so all peers should be selected
+	requestContext = prepareRequestContext(request, Opts{PreferredLabels: map[string]string{"zone": "north"}}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	if len(requestContext.Opts.Targets) != len(discoveryPeers) {
+		t.Fatalf("Expecting %d proposal processors but got %d", len(discoveryPeers), len(requestContext.Opts.Targets))
+	}
+}
+
+func TestProposalProcessorHandlerWithCollectionAffinity(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+	peer2.SetMSPID("Org2MSP")
+	discoveryPeers := []fab.Peer{peer1, peer2}
+
+	handler := NewProposalProcessorHandler()
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	requestContext := prepareRequestContext(request, Opts{CollectionOwnerMSPID: "Org2MSP"}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	if len(requestContext.Opts.Targets) != 1 || requestContext.Opts.Targets[0] != peer2 {
+		t.Fatalf("Expecting only the peer of the collection owner to be selected")
+	}
+
+	// The owner has no peers so all peers should be selected
+	requestContext = prepareRequestContext(request, Opts{CollectionOwnerMSPID: "Org3MSP"}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	if len(requestContext.Opts.Targets) != len(discoveryPeers) {
+		t.Fatalf("Expecting %d proposal processors but got %d", len(discoveryPeers), len(requestContext.Opts.Targets))
+	}
+}
+
+func TestProposalProcessorHandlerWithSelectionTrace(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+	peer2.SetMSPID("Org2MSP")
+	discoveryPeers := []fab.Peer{peer1, peer2}
+
+	handler := NewProposalProcessorHandler()
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	// Nothing is recorded unless tracing is enabled
+	requestContext := prepareRequestContext(request, Opts{CollectionOwnerMSPID: "Org3MSP"}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	assert.Nil(t, requestContext.Error)
+	assert.Nil(t, requestContext.Response.SelectionTrace)
+
+	// The owner has no peers so the selection falls back to all peers
+	requestContext = prepareRequestContext(request, Opts{CollectionOwnerMSPID: "Org3MSP", TraceSelection: true}, t)
+	clientContext := setupChannelClientContext(nil, nil, discoveryPeers, t)
+	clientContext.Discovery = fcmocks.NewMockDiscoveryService(nil, discoveryPeers)
+	handler.Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+
+	trace := requestContext.Response.SelectionTrace
+	if trace == nil {
+		t.Fatal("Expecting the selection to be traced")
+	}
+	assert.Equal(t, SelectionAllPeers, trace.Chosen)
+	if assert.Equal(t, 2, len(trace.Attempts)) {
+		assert.Equal(t, SelectionCollectionOwner, trace.Attempts[0].Strategy)
+		assert.Empty(t, trace.Attempts[0].Endorsers)
+		assert.Equal(t, []string{"peer1:7051", "peer2:7051"}, trace.Attempts[0].Excluded)
+		assert.Equal(t, SelectionAllPeers, trace.Attempts[1].Strategy)
+		assert.Equal(t, []string{"peer1:7051", "peer2:7051"}, trace.Attempts[1].Endorsers)
+		assert.Empty(t, trace.Attempts[1].Excluded)
+	}
+}
+
+func TestProposalProcessorHandlerWithMinBlockHeight(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer1.MockBlockHeight = 10
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+	peer2.MockBlockHeight = 8
+	discoveryPeers := []fab.Peer{peer1, peer2}
+
+	handler := NewProposalProcessorHandler()
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	requestContext := prepareRequestContext(request, Opts{MinBlockHeight: 9}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	if len(requestContext.Opts.Targets) != 1 || requestContext.Opts.Targets[0] != peer1 {
+		t.Fatalf("Expecting only the peer at the minimum block height to be selected")
+	}
+
+	// No peer reaches the minimum block height
+	requestContext = prepareRequestContext(request, Opts{MinBlockHeight: 11}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "block height 11") {
+		t.Fatal("Expected block height error, Received error:", requestContext.Error)
+	}
+
+	// Peer 2 catches up while waiting
+	go func() {
+		time.Sleep(2 * blockHeightPollInterval)
+		peer2.RWLock.Lock()
+		peer2.MockBlockHeight = 12
+		peer2.RWLock.Unlock()
+	}()
+	requestContext = prepareRequestContext(request, Opts{MinBlockHeight: 11, BlockHeightWait: 5 * time.Second}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	if len(requestContext.Opts.Targets) != 1 || requestContext.Opts.Targets[0] != peer2 {
+		t.Fatalf("Expecting the peer that caught up to be selected")
+	}
+}
+
+// statelessPeer is a peer that doesn't report its ledger height
+type statelessPeer struct {
+	fab.Peer
+}
+
+func chainInfoPeer(name, url string, height uint64, t *testing.T) *fcmocks.MockPeer {
+	info, err := proto.Marshal(&common.BlockchainInfo{Height: height})
+	if err != nil {
+		t.Fatalf("Failed to marshal chain info: %s", err)
+	}
+	peer := fcmocks.NewMockPeer(name, url)
+	peer.Payload = info
+	return peer
+}
+
+func TestProposalProcessorHandlerWithMinBlockHeightQueried(t *testing.T) {
+	peer1 := chainInfoPeer("p1", "peer1:7051", 10, t)
+	peer2 := chainInfoPeer("p2", "peer2:7051", 8, t)
+	peer3 := fcmocks.NewMockPeer("p3", "peer3:7051")
+	peer3.Status = 500
+	discoveryPeers := []fab.Peer{&statelessPeer{Peer: peer1}, &statelessPeer{Peer: peer2}, &statelessPeer{Peer: peer3}}
+
+	handler := NewProposalProcessorHandler()
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	requestContext := prepareRequestContext(request, Opts{MinBlockHeight: 9}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	if len(requestContext.Opts.Targets) != 1 || requestContext.Opts.Targets[0].URL() != peer1.URL() {
+		t.Fatalf("Expecting only the peer at the minimum block height to be selected")
+	}
+	assert.Equal(t, 1, peer1.ProcessProposalCalls, "expecting the ledger height to be queried once per selection attempt")
+	assert.Equal(t, 1, peer3.ProcessProposalCalls)
+
+	// No peer reaches the minimum block height
+	requestContext = prepareRequestContext(request, Opts{MinBlockHeight: 11}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "block height 11") {
+		t.Fatal("Expected block height error, Received error:", requestContext.Error)
+	}
+}
+
 //prepareHandlerContexts prepares context objects for handlers
 func prepareRequestContext(request Request, opts Opts, t *testing.T) *RequestContext {
 	requestContext := &RequestContext{Request: request,
@@ -241,6 +1241,43 @@ func prepareRequestContext(request Request, opts Opts, t *testing.T) *RequestCon
 	return requestContext
 }
 
+// collectionSelection is a selection service that records the collections it's asked to select endorsers for
+type collectionSelection struct {
+	peers       []fab.Peer
+	collections []string
+}
+
+func (s *collectionSelection) GetEndorsersForChaincode(chaincodeIDs []string, opts ...options.Opt) ([]fab.Peer, error) {
+	s.collections = selectopts.NewParams(opts).Collections
+	return s.peers, nil
+}
+
+func TestProposalProcessorHandlerWithCollections(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("p1", "")
+	selection := &collectionSelection{peers: []fab.Peer{peer1}}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	clientContext.Selection = selection
+
+	requestContext := prepareRequestContext(Request{ChaincodeID: "test"}, Opts{Collections: []string{"coll1", "coll2"}}, t)
+	NewProposalProcessorHandler().Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	assert.Equal(t, []fab.Peer{peer1}, requestContext.Opts.Targets)
+	assert.Equal(t, []string{"coll1", "coll2"}, selection.collections)
+
+	// None of the peers is a member of the collections
+	selection.peers = nil
+	requestContext = prepareRequestContext(Request{ChaincodeID: "test"}, Opts{Collections: []string{"coll1"}}, t)
+	NewProposalProcessorHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.ClientStatus, s.Group)
+	assert.Equal(t, status.NoCollectionEndorsers.ToInt32(), s.Code)
+}
+
 func setupChannelClientContext(discErr error, selectionErr error, peers []fab.Peer, t *testing.T) *ClientContext {
 	membership := fcmocks.NewMockMembership()
 
@@ -295,3 +1332,39 @@ func setupTestSelection(discErr error, peers []fab.Peer) (*txnmocks.MockSelectio
 
 	return mockSelection.CreateSelectionService("mychannel")
 }
+
+func TestEndorsementValidationHandlerMaxEndorsementAge(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peers := []fab.Peer{mockPeer1}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+
+	requestContext := prepareRequestContext(request, Opts{Targets: peers, MaxEndorsementAge: time.Minute}, t)
+	NewQueryHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+
+	// Endorsements of a proposal created before the window are stale
+	hdr, err := protos_utils.GetHeader(requestContext.Response.Proposal.Header)
+	assert.Nil(t, err)
+	chdr, err := protos_utils.UnmarshalChannelHeader(hdr.ChannelHeader)
+	assert.Nil(t, err)
+	chdr.Timestamp.Seconds -= 3600
+	hdr.ChannelHeader, err = proto.Marshal(chdr)
+	assert.Nil(t, err)
+	requestContext.Response.Proposal.Header, err = proto.Marshal(hdr)
+	assert.Nil(t, err)
+
+	NewEndorsementValidationHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.StaleEndorsement.ToInt32(), s.Code)
+
+	// The timestamp isn't checked without a window
+	requestContext.Error = nil
+	requestContext.Opts.MaxEndorsementAge = 0
+	NewEndorsementValidationHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+)
+
+// envelopeSignatureSize is an upper bound of the size that the signature of the client adds to the
+// transaction payload when it's wrapped in the envelope
+const envelopeSignatureSize = 80
+
+// MaxTransactionBytesProvider returns the maximum size of a transaction that the orderer of the channel accepts
+// (the AbsoluteMaxBytes of the batch size in the channel config)
+type MaxTransactionBytesProvider func() (int, error)
+
+//NewTransactionSizeLimitHandler returns a handler that rejects the transaction if the estimated size of its envelope
+//exceeds maxBytes, which saves the round trip to the orderer that would reject it. If maxBytes is 0 then the
+//AbsoluteMaxBytes of the batch size of the channel is used; a negative maxBytes is invalid and fails the request.
+//The handler must be chained after the endorsement validation handler and before the commit handler.
+func NewTransactionSizeLimitHandler(maxBytes int, next ...Handler) *TransactionSizeLimitHandler {
+	return &TransactionSizeLimitHandler{maxBytes: maxBytes, next: getNext(next)}
+}
+
+//newRequestTransactionSizeLimitHandler returns a handler that checks the transaction size only if enabled by
+//Opts.CheckTransactionSize, against the limit in Opts.MaxTransactionBytes
+func newRequestTransactionSizeLimitHandler(next ...Handler) *TransactionSizeLimitHandler {
+	return &TransactionSizeLimitHandler{fromOpts: true, next: getNext(next)}
+}
+
+//TransactionSizeLimitHandler for enforcing a maximum transaction size before submission
+type TransactionSizeLimitHandler struct {
+	maxBytes int
+	fromOpts bool
+	next     Handler
+}
+
+//Handle checks the estimated size of the transaction envelope
+func (h *TransactionSizeLimitHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	if !h.fromOpts || requestContext.Opts.CheckTransactionSize {
+		if err := h.checkSize(requestContext, clientContext); err != nil {
+			requestContext.Error = err
+			return
+		}
+	}
+
+	// Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+// checkSize returns an error with status TransactionTooLarge if the estimated size of the transaction envelope
+// exceeds the limit
+func (h *TransactionSizeLimitHandler) checkSize(requestContext *RequestContext, clientContext *ClientContext) error {
+	maxBytes := h.maxBytes
+	if h.fromOpts {
+		maxBytes = requestContext.Opts.MaxTransactionBytes
+	}
+	maxBytes, err := resolveMaxTransactionBytes(maxBytes, clientContext)
+	if err != nil {
+		return err
+	}
+
+	size, err := transactionSize(requestContext.Response.Proposal, requestContext.Response.Responses)
+	if err != nil {
+		return err
+	}
+	if size > maxBytes {
+		newLogFields(requestContext).warnf("transaction of %d bytes exceeds the limit of %d bytes", size, maxBytes)
+		return status.New(status.ClientStatus, status.TransactionTooLarge.ToInt32(),
+			fmt.Sprintf("transaction of %d bytes exceeds the limit of %d bytes", size, maxBytes), nil)
+	}
+	return nil
+}
+
+// resolveMaxTransactionBytes validates the given limit and returns it, or the AbsoluteMaxBytes of the channel if
+// the limit is 0
+func resolveMaxTransactionBytes(maxBytes int, clientContext *ClientContext) (int, error) {
+	if maxBytes < 0 {
+		return 0, errors.Errorf("invalid transaction size limit %d", maxBytes)
+	}
+	if maxBytes > 0 {
+		return maxBytes, nil
+	}
+	if clientContext.MaxTransactionBytes == nil {
+		return 0, errors.New("no transaction size limit and no channel config to get it from")
+	}
+	maxBytes, err := clientContext.MaxTransactionBytes()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to retrieve the maximum transaction size of the channel")
+	}
+	return maxBytes, nil
+}
+
+// transactionSize returns the estimated size of the envelope of the transaction that is assembled from
+// the given proposal and endorsements
+func transactionSize(proposal *fab.TransactionProposal, responses []*fab.TransactionProposalResponse) (int, error) {
+	if proposal == nil {
+		return 0, errors.New("no transaction proposal to estimate the transaction size from")
+	}
+	tx, err := txn.New(fab.TransactionRequest{Proposal: proposal, ProposalResponses: responses})
+	if err != nil {
+		return 0, errors.WithMessage(err, "assembling transaction failed")
+	}
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		return 0, errors.Wrap(err, "unmarshal proposal header failed")
+	}
+	txBytes, err := protos_utils.GetBytesTransaction(tx.Transaction)
+	if err != nil {
+		return 0, err
+	}
+	payload := &common.Payload{Header: hdr, Data: txBytes}
+	return proto.Size(payload) + envelopeSignatureSize, nil
+}
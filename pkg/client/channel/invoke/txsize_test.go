@@ -0,0 +1,76 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+)
+
+func TestTransactionSizeLimitHandler(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	mockPeer := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peers := []fab.Peer{mockPeer}
+
+	handler := NewProposalProcessorHandler(NewEndorsementHandler(NewTransactionSizeLimitHandler(1024 * 1024)))
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+	assert.Nil(t, requestContext.Error)
+
+	size, err := transactionSize(requestContext.Response.Proposal, requestContext.Response.Responses)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.True(t, size > envelopeSignatureSize)
+
+	handler = NewProposalProcessorHandler(NewEndorsementHandler(NewTransactionSizeLimitHandler(size - 1)))
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, peers, t))
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error but got: %v", requestContext.Error)
+	}
+	assert.Equal(t, status.ClientStatus, s.Group)
+	assert.Equal(t, status.TransactionTooLarge.ToInt32(), s.Code)
+}
+
+func TestTransactionSizeLimitHandlerChannelLimit(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	mockPeer := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peers := []fab.Peer{mockPeer}
+
+	// The AbsoluteMaxBytes of the channel is used if no limit is specified
+	clientContext := setupChannelClientContext(nil, nil, peers, t)
+	clientContext.MaxTransactionBytes = func() (int, error) { return envelopeSignatureSize, nil }
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	NewProposalProcessorHandler(NewEndorsementHandler(NewTransactionSizeLimitHandler(0))).Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	if assert.True(t, ok, "expected status error but got: %v", requestContext.Error) {
+		assert.Equal(t, status.TransactionTooLarge.ToInt32(), s.Code)
+	}
+
+	// A negative limit is invalid
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	NewProposalProcessorHandler(NewEndorsementHandler(NewTransactionSizeLimitHandler(-1))).Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error)
+	_, ok = status.FromError(requestContext.Error)
+	assert.False(t, ok, "expecting a validation error")
+
+	// The execute handler checks the size if enabled by the request options
+	requestContext = prepareRequestContext(request, Opts{CheckTransactionSize: true}, t)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	s, ok = status.FromError(requestContext.Error)
+	if assert.True(t, ok, "expected status error but got: %v", requestContext.Error) {
+		assert.Equal(t, status.TransactionTooLarge.ToInt32(), s.Code)
+	}
+}
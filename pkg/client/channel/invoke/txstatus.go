@@ -0,0 +1,179 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// TxStatusFanout shares a single TxStatus registration per transaction and event service among the handlers
+// of a client that wait for the transaction (for example, a retried commit whose previous attempt hasn't released
+// its registration yet), since an event service only allows one TxStatus registration per transaction. The
+// TxStatus event is delivered to each of the handlers. The registrations on an event service that isn't a
+// pointer can't be told apart and aren't shared.
+type TxStatusFanout struct {
+	mutex         sync.Mutex
+	registrations map[txStatusKey]*sharedTxStatusReg
+}
+
+// txStatusKey identifies the TxStatus registration of a transaction on an event service (by its address)
+type txStatusKey struct {
+	eventService uintptr
+	txID         string
+}
+
+// sharedTxStatusReg is a TxStatus registration on an event service and the channels of the handlers sharing it
+type sharedTxStatusReg struct {
+	eventService fab.EventService
+	ready        chan struct{} // closed once the registration with the event service completed
+	released     chan struct{} // closed once the registration is unregistered from the event service
+	reg          fab.Registration
+	err          error
+	event        *fab.TxStatusEvent
+	subscribers  []chan *fab.TxStatusEvent
+	closed       bool
+	releasing    bool
+}
+
+// NewTxStatusFanout returns a new TxStatusFanout
+func NewTxStatusFanout() *TxStatusFanout {
+	return &TxStatusFanout{registrations: make(map[txStatusKey]*sharedTxStatusReg)}
+}
+
+// eventServiceID returns the address of the given event service, or false if it isn't a pointer
+func eventServiceID(eventService fab.EventService) (uintptr, bool) {
+	v := reflect.ValueOf(eventService)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return 0, false
+	}
+	return v.Pointer(), true
+}
+
+// register registers for the TxStatus event of the given transaction on the given event service. If the
+// transaction is already registered on the event service then the existing registration is shared; if the
+// existing registration is being released then its release is awaited first. The TxStatus event is delivered
+// on the returned channel, which is closed without an event if the event service closes the registration.
+// The returned function must be called to release the registration. If the fanout is nil or the event service
+// can't be identified then the transaction is registered with the event service without sharing the registration.
+func (f *TxStatusFanout) register(eventService fab.EventService, txID string, fields logFields) (<-chan *fab.TxStatusEvent, func(), error) {
+	id, ok := eventServiceID(eventService)
+	if f == nil || !ok {
+		reg, eventch, err := eventService.RegisterTxStatusEvent(txID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return eventch, func() { eventService.Unregister(reg) }, nil
+	}
+
+	key := txStatusKey{eventService: id, txID: txID}
+	eventch := make(chan *fab.TxStatusEvent, 1)
+
+	f.mutex.Lock()
+	shared, exists := f.registrations[key]
+	for exists && shared.releasing {
+		f.mutex.Unlock()
+		<-shared.released
+		f.mutex.Lock()
+		shared, exists = f.registrations[key]
+	}
+	if exists {
+		fields.debugf("sharing the existing TxStatus registration")
+	} else {
+		shared = &sharedTxStatusReg{eventService: eventService, ready: make(chan struct{}), released: make(chan struct{})}
+		f.registrations[key] = shared
+	}
+	shared.subscribers = append(shared.subscribers, eventch)
+	if shared.event != nil {
+		eventch <- shared.event
+	}
+	f.mutex.Unlock()
+
+	if !exists {
+		reg, regch, err := eventService.RegisterTxStatusEvent(txID)
+
+		f.mutex.Lock()
+		shared.reg, shared.err = reg, err
+		if err != nil {
+			f.remove(key, shared)
+		}
+		f.mutex.Unlock()
+		close(shared.ready)
+
+		if err == nil {
+			go f.forward(key, shared, regch)
+		}
+	}
+
+	<-shared.ready
+	if shared.err != nil {
+		return nil, nil, shared.err
+	}
+	return eventch, func() { f.release(key, shared, eventch) }, nil
+}
+
+// forward delivers the TxStatus event of the registration to the handlers sharing it. If the event service
+// closes the registration without an event then the channels of the handlers are closed and the registration
+// is removed so that the transaction can be registered again.
+func (f *TxStatusFanout) forward(key txStatusKey, shared *sharedTxStatusReg, regch <-chan *fab.TxStatusEvent) {
+	for event := range regch {
+		f.mutex.Lock()
+		if shared.event == nil {
+			shared.event = event
+			for _, eventch := range shared.subscribers {
+				eventch <- event
+			}
+		}
+		f.mutex.Unlock()
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if shared.event == nil && !shared.releasing {
+		for _, eventch := range shared.subscribers {
+			close(eventch)
+		}
+		shared.subscribers = nil
+		shared.closed = true
+		f.remove(key, shared)
+	}
+}
+
+// release removes the given channel from the handlers sharing the registration. The registration is
+// unregistered from the event service once it is no longer shared.
+func (f *TxStatusFanout) release(key txStatusKey, shared *sharedTxStatusReg, eventch chan *fab.TxStatusEvent) {
+	f.mutex.Lock()
+	for i, ch := range shared.subscribers {
+		if ch == eventch {
+			shared.subscribers = append(shared.subscribers[:i], shared.subscribers[i+1:]...)
+			break
+		}
+	}
+	if len(shared.subscribers) > 0 || shared.closed || shared.releasing {
+		f.mutex.Unlock()
+		return
+	}
+	shared.releasing = true
+	f.mutex.Unlock()
+
+	shared.eventService.Unregister(shared.reg)
+
+	f.mutex.Lock()
+	f.remove(key, shared)
+	f.mutex.Unlock()
+	close(shared.released)
+}
+
+// remove removes the given registration unless it has been replaced. The mutex must be held.
+func (f *TxStatusFanout) remove(key txStatusKey, shared *sharedTxStatusReg) {
+	if f.registrations[key] == shared {
+		delete(f.registrations, key)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// unregisteringEventService is an event service that records the unregistrations and closes the
+// channel of the unregistered registration, as the event service does
+type unregisteringEventService struct {
+	*fcmocks.MockEventService
+	unregistered chan fab.Registration
+}
+
+func newUnregisteringEventService() *unregisteringEventService {
+	return &unregisteringEventService{MockEventService: fcmocks.NewMockEventService(), unregistered: make(chan fab.Registration, 10)}
+}
+
+func (s *unregisteringEventService) Unregister(reg fab.Registration) {
+	s.unregistered <- reg
+}
+
+func TestTxStatusFanout(t *testing.T) {
+	fanout := NewTxStatusFanout()
+	eventService := newUnregisteringEventService()
+	fields := logFields{}
+
+	eventch1, release1, err := fanout.register(eventService, "tx1", fields)
+	assert.Nil(t, err)
+	txStatusReg := <-eventService.TxStatusRegCh
+
+	// The existing registration is shared
+	eventch2, release2, err := fanout.register(eventService, "tx1", fields)
+	assert.Nil(t, err)
+	assert.Empty(t, eventService.TxStatusRegCh, "expecting the existing registration to be shared")
+
+	txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: "tx1", TxValidationCode: pb.TxValidationCode_VALID}
+	for _, eventch := range []<-chan *fab.TxStatusEvent{eventch1, eventch2} {
+		select {
+		case txStatus := <-eventch:
+			assert.Equal(t, pb.TxValidationCode_VALID, txStatus.TxValidationCode)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for TxStatus event")
+		}
+	}
+
+	// A handler that shares the registration after the event was received also receives it
+	eventch3, release3, err := fanout.register(eventService, "tx1", fields)
+	assert.Nil(t, err)
+	if assert.Len(t, eventch3, 1) {
+		assert.Equal(t, pb.TxValidationCode_VALID, (<-eventch3).TxValidationCode)
+	}
+
+	// The registration is only unregistered once it's no longer shared
+	release1()
+	release2()
+	assert.Empty(t, eventService.unregistered)
+	release3()
+	assert.Equal(t, txStatusReg, <-eventService.unregistered)
+
+	// A released transaction is registered again
+	_, release4, err := fanout.register(eventService, "tx1", fields)
+	assert.Nil(t, err)
+	assert.Len(t, eventService.TxStatusRegCh, 1, "expecting a new registration")
+	<-eventService.TxStatusRegCh
+	release4()
+	<-eventService.unregistered
+}
+
+func TestTxStatusFanoutClosedRegistration(t *testing.T) {
+	fanout := NewTxStatusFanout()
+	eventService := newUnregisteringEventService()
+	fields := logFields{}
+
+	eventch, release, err := fanout.register(eventService, "tx1", fields)
+	assert.Nil(t, err)
+	defer release()
+	txStatusReg := <-eventService.TxStatusRegCh
+
+	// The event service closes the registration without an event
+	close(txStatusReg.Eventch)
+	select {
+	case _, ok := <-eventch:
+		assert.False(t, ok, "expecting the channel to be closed")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the channel to be closed")
+	}
+
+	_, release2, err := fanout.register(eventService, "tx1", fields)
+	assert.Nil(t, err)
+	defer release2()
+	assert.Len(t, eventService.TxStatusRegCh, 1, "expecting the transaction to be registered again")
+}
+
+// valueEventService is an event service that isn't comparable and is used by value
+type valueEventService struct {
+	*fcmocks.MockEventService
+	peers []string
+}
+
+func TestTxStatusFanoutNonComparableEventService(t *testing.T) {
+	fanout := NewTxStatusFanout()
+	eventService := valueEventService{MockEventService: fcmocks.NewMockEventService(), peers: []string{"peer1"}}
+
+	// The registration of an event service that can't be identified isn't shared
+	_, release1, err := fanout.register(eventService, "tx1", logFields{})
+	assert.Nil(t, err)
+	reg1 := <-eventService.TxStatusRegCh
+	_, release2, err := fanout.register(eventService, "tx1", logFields{})
+	assert.Nil(t, err)
+	reg2 := <-eventService.TxStatusRegCh
+	assert.False(t, reg1 == reg2, "expecting a registration per handler")
+	assert.Empty(t, fanout.registrations)
+	release1()
+	release2()
+}
+
+func TestTxStatusFanoutPerClient(t *testing.T) {
+	eventService := newUnregisteringEventService()
+
+	_, release1, err := NewTxStatusFanout().register(eventService, "tx1", logFields{})
+	assert.Nil(t, err)
+	reg1 := <-eventService.TxStatusRegCh
+	_, release2, err := NewTxStatusFanout().register(eventService, "tx1", logFields{})
+	assert.Nil(t, err)
+	reg2 := <-eventService.TxStatusRegCh
+	assert.False(t, reg1 == reg2, "expecting the registrations of different clients not to be shared")
+	release1()
+	release2()
+	<-eventService.unregistered
+	<-eventService.unregistered
+}
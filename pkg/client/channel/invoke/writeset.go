@@ -0,0 +1,87 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	rwsetutil "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+)
+
+// txRwSet returns the RW set in the given proposal response
+func txRwSet(r *fab.TransactionProposalResponse) (*rwsetutil.TxRwSet, error) {
+	prp, err := protos_utils.GetProposalResponsePayload(r.ProposalResponse.GetPayload())
+	if err != nil {
+		return nil, errors.WithMessage(err, "unmarshal of proposal response payload failed")
+	}
+	ccAction, err := protos_utils.GetChaincodeAction(prp.Extension)
+	if err != nil {
+		return nil, errors.WithMessage(err, "unmarshal of chaincode action failed")
+	}
+
+	rwSet := &rwsetutil.TxRwSet{}
+	if err := rwSet.FromProtoBytes(ccAction.Results); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of RW set failed")
+	}
+	return rwSet, nil
+}
+
+// writeSet returns the serialized writes of the RW set in the given proposal response. Reads
+// and namespaces without writes are omitted so that the result only reflects the state changes
+// made by the endorser. If canonical is set then the namespaces are sorted by name and the writes
+// of each namespace by key, so that write sets differing only in the order of their entries match.
+func writeSet(r *fab.TransactionProposalResponse, canonical bool) ([]byte, error) {
+	rwSet, err := txRwSet(r)
+	if err != nil {
+		return nil, err
+	}
+
+	writes := &rwsetutil.TxRwSet{}
+	for _, nsRwSet := range rwSet.NsRwSets {
+		if nsRwSet.KvRwSet == nil || len(nsRwSet.KvRwSet.Writes) == 0 {
+			continue
+		}
+		kvWrites := nsRwSet.KvRwSet.Writes
+		if canonical {
+			kvWrites = append([]*kvrwset.KVWrite(nil), kvWrites...)
+			sort.SliceStable(kvWrites, func(i, j int) bool { return kvWrites[i].Key < kvWrites[j].Key })
+		}
+		writes.NsRwSets = append(writes.NsRwSets, &rwsetutil.NsRwSet{
+			NameSpace: nsRwSet.NameSpace,
+			KvRwSet:   &kvrwset.KVRWSet{Writes: kvWrites},
+		})
+	}
+	if canonical {
+		sort.SliceStable(writes.NsRwSets, func(i, j int) bool { return writes.NsRwSets[i].NameSpace < writes.NsRwSets[j].NameSpace })
+	}
+
+	return writes.ToProtoBytes()
+}
+
+// writeKeys returns the keys written in the RW set of the given proposal response, by namespace
+func writeKeys(r *fab.TransactionProposalResponse) (map[string][]string, error) {
+	rwSet, err := txRwSet(r)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]string)
+	for _, nsRwSet := range rwSet.NsRwSets {
+		if nsRwSet.KvRwSet == nil {
+			continue
+		}
+		for _, write := range nsRwSet.KvRwSet.Writes {
+			keys[nsRwSet.NameSpace] = append(keys[nsRwSet.NameSpace], write.Key)
+		}
+	}
+	return keys, nil
+}
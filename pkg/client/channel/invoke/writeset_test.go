@@ -0,0 +1,171 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/util/errors/status"
+	rwsetutil "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestWriteSet(t *testing.T) {
+	r1 := rwSetResponse("peer1", []byte("payload1"), &kvrwset.KVRWSet{
+		Reads:  []*kvrwset.KVRead{{Key: "key1", Version: &kvrwset.Version{BlockNum: 1}}},
+		Writes: []*kvrwset.KVWrite{{Key: "key2", Value: []byte("value2")}},
+	}, t)
+	r2 := rwSetResponse("peer2", []byte("payload2"), &kvrwset.KVRWSet{
+		Reads:  []*kvrwset.KVRead{{Key: "key1", Version: &kvrwset.Version{BlockNum: 2}}},
+		Writes: []*kvrwset.KVWrite{{Key: "key2", Value: []byte("value2")}},
+	}, t)
+	r3 := rwSetResponse("peer3", []byte("payload1"), &kvrwset.KVRWSet{
+		Writes: []*kvrwset.KVWrite{{Key: "key2", Value: []byte("other")}},
+	}, t)
+
+	ws1, err := writeSet(r1, false)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	ws2, err := writeSet(r2, false)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	ws3, err := writeSet(r3, false)
+	if err != nil {
+		t.Fatalf("Got error: %s", err)
+	}
+	assert.Equal(t, ws1, ws2, "expected write sets to match when only the reads differ")
+	assert.NotEqual(t, ws1, ws3, "expected write sets to differ")
+
+	_, err = writeSet(&fab.TransactionProposalResponse{Endorser: "peer4", ProposalResponse: &pb.ProposalResponse{Payload: []byte("invalid")}}, false)
+	assert.NotNil(t, err, "expected error for invalid proposal response payload")
+}
+
+func TestCanonicalWriteSet(t *testing.T) {
+	r1 := rwSetResponse("peer1", []byte("payload"), &kvrwset.KVRWSet{
+		Writes: []*kvrwset.KVWrite{{Key: "key1", Value: []byte("value1")}, {Key: "key2", Value: []byte("value2")}},
+	}, t)
+	r2 := rwSetResponse("peer2", []byte("payload"), &kvrwset.KVRWSet{
+		Writes: []*kvrwset.KVWrite{{Key: "key2", Value: []byte("value2")}, {Key: "key1", Value: []byte("value1")}},
+	}, t)
+
+	ws1, err := writeSet(r1, false)
+	assert.Nil(t, err)
+	ws2, err := writeSet(r2, false)
+	assert.Nil(t, err)
+	assert.NotEqual(t, ws1, ws2, "expected write sets in a different order to differ")
+
+	ws1, err = writeSet(r1, true)
+	assert.Nil(t, err)
+	ws2, err = writeSet(r2, true)
+	assert.Nil(t, err)
+	assert.Equal(t, ws1, ws2, "expected canonical write sets to match")
+
+	requestContext := &RequestContext{Opts: Opts{CompareWriteSets: true}, Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r2}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	assert.NotNil(t, requestContext.Error)
+
+	requestContext = &RequestContext{Opts: Opts{CompareWriteSets: true, CanonicalWriteSets: true}, Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r2}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	assert.Nil(t, requestContext.Error)
+}
+
+func TestCommitOutcome(t *testing.T) {
+	r := rwSetResponse("peer1", []byte("payload"), &kvrwset.KVRWSet{
+		Reads:  []*kvrwset.KVRead{{Key: "key1", Version: &kvrwset.Version{BlockNum: 1}}},
+		Writes: []*kvrwset.KVWrite{{Key: "key2", Value: []byte("value2")}, {Key: "key3", IsDelete: true}},
+	}, t)
+
+	outcome := commitOutcome(logFields{}, "txid", 5, []*fab.TransactionProposalResponse{r})
+	assert.Equal(t, fab.TransactionID("txid"), outcome.TxID)
+	assert.EqualValues(t, 5, outcome.BlockNumber)
+	assert.Equal(t, map[string][]string{"testCC": {"key2", "key3"}}, outcome.WriteKeys)
+}
+
+func TestEndorsementValidationHandlerWithWriteSets(t *testing.T) {
+	writes := &kvrwset.KVRWSet{Writes: []*kvrwset.KVWrite{{Key: "key1", Value: []byte("value1")}}}
+	r1 := rwSetResponse("peer1", []byte("nonce1"), writes, t)
+	r2 := rwSetResponse("peer2", []byte("nonce2"), writes, t)
+
+	requestContext := &RequestContext{Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r2}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), endorsementMisMatchError) {
+		t.Fatal("Expected error: ", endorsementMisMatchError, ", Received error:", requestContext.Error)
+	}
+
+	requestContext = &RequestContext{Opts: Opts{CompareWriteSets: true}, Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r2}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	assert.Nil(t, requestContext.Error)
+
+	r3 := rwSetResponse("peer3", []byte("nonce1"), &kvrwset.KVRWSet{Writes: []*kvrwset.KVWrite{{Key: "key1", Value: []byte("value2")}}}, t)
+	requestContext = &RequestContext{Opts: Opts{CompareWriteSets: true}, Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r3}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	if requestContext.Error == nil || !strings.Contains(requestContext.Error.Error(), "write sets do not match") {
+		t.Fatal("Expected write set mismatch error, Received error:", requestContext.Error)
+	}
+
+	requestContext = &RequestContext{Opts: Opts{CompareWriteSets: true, PayloadQuorum: 2}, Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r2, r3}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, 2, len(requestContext.Response.Responses))
+	if assert.Equal(t, 1, len(requestContext.Response.Dissenters)) {
+		assert.Equal(t, "peer3", requestContext.Response.Dissenters[0].Endorser)
+	}
+}
+
+func TestCommitTxHandlerWithWriteSets(t *testing.T) {
+	writes := &kvrwset.KVRWSet{Writes: []*kvrwset.KVWrite{{Key: "key1", Value: []byte("value1")}}}
+	r1 := rwSetResponse("peer1", []byte("nonce1"), writes, t)
+	r2 := rwSetResponse("peer2", []byte("nonce1"), writes, t)
+	r3 := rwSetResponse("peer3", []byte("nonce2"), writes, t)
+
+	// The write sets match but the proposal response payloads differ, so the transaction can't be committed
+	requestContext := &RequestContext{Opts: Opts{CompareWriteSets: true}, Response: Response{Responses: []*fab.TransactionProposalResponse{r1, r2, r3}}}
+	NewEndorsementValidationHandler().Handle(requestContext, &ClientContext{})
+	assert.Nil(t, requestContext.Error)
+
+	NewCommitHandler().Handle(requestContext, &ClientContext{})
+	s, ok := status.FromError(requestContext.Error)
+	if !ok {
+		t.Fatalf("Expected status error, Received error: %v", requestContext.Error)
+	}
+	assert.EqualValues(t, status.EndorsementMismatch, s.Code)
+	assert.Equal(t, []interface{}{"peer3"}, s.Details, "expecting the endorser that differs from the majority")
+
+	assert.Nil(t, validateCommittable([]*fab.TransactionProposalResponse{r1, r2}))
+}
+
+func rwSetResponse(endorser string, payload []byte, kvRwSet *kvrwset.KVRWSet, t *testing.T) *fab.TransactionProposalResponse {
+	txRwSet := &rwsetutil.TxRwSet{NsRwSets: []*rwsetutil.NsRwSet{{NameSpace: "testCC", KvRwSet: kvRwSet}}}
+	results, err := txRwSet.ToProtoBytes()
+	if err != nil {
+		t.Fatalf("Failed to marshal RW set: %s", err)
+	}
+	ccAction, err := proto.Marshal(&pb.ChaincodeAction{Results: results, Response: &pb.Response{Status: 200, Payload: payload}})
+	if err != nil {
+		t.Fatalf("Failed to marshal chaincode action: %s", err)
+	}
+	prp, err := proto.Marshal(&pb.ProposalResponsePayload{Extension: ccAction})
+	if err != nil {
+		t.Fatalf("Failed to marshal proposal response payload: %s", err)
+	}
+	return &fab.TransactionProposalResponse{
+		Endorser: endorser,
+		Status:   200,
+		ProposalResponse: &pb.ProposalResponse{
+			Response: &pb.Response{Status: 200, Payload: payload},
+			Payload:  prp,
+		},
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package penaltybox
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+)
+
+var logger = logging.NewLogger("fabsdk/client")
+
+// Filter is a target filter that rejects peers that are in the penalty box. A peer
+// is put in the penalty box when it fails endorsement and is re-admitted after the
+// configured TTL.
+type Filter struct {
+	// penalties contains a map of peer URLs as keys and the times at which the
+	// peers are re-admitted as values
+	penalties sync.Map
+	ttl       time.Duration
+}
+
+// New creates a new penalty box filter with the given TTL
+func New(ttl time.Duration) *Filter {
+	return &Filter{ttl: ttl}
+}
+
+// Accept returns false if the peer is in the penalty box
+func (f *Filter) Accept(peer fab.Peer) bool {
+	peerAddress := endpoint.ToAddress(peer.URL())
+	value, ok := f.penalties.Load(peerAddress)
+	if ok {
+		readmitTime, ok := value.(time.Time)
+		if ok && time.Now().Before(readmitTime) {
+			logger.Debugf("Rejecting peer %s which is in the penalty box", peer.URL())
+			return false
+		}
+		f.penalties.Delete(peerAddress)
+	}
+
+	return true
+}
+
+// Penalize puts the peer with the given URL in the penalty box for the configured TTL
+func (f *Filter) Penalize(peerURL string) {
+	logger.Infof("Putting peer %s in the penalty box for %s", peerURL, f.ttl)
+	f.penalties.Store(endpoint.ToAddress(peerURL), time.Now().Add(f.ttl))
+}
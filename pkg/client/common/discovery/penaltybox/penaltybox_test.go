@@ -0,0 +1,32 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package penaltybox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPenaltyBoxFilter(t *testing.T) {
+	ttl := 500 * time.Millisecond
+	goodPeer := mocks.NewMockPeer("goodPeer", "grpcs://peer1.org:7051")
+	badPeer := mocks.NewMockPeer("badPeer", "grpcs://peer2.org:7051")
+
+	f := New(ttl)
+	assert.True(t, f.Accept(badPeer), "Expected peer to be accepted before it is penalized")
+
+	f.Penalize("peer2.org:7051")
+	assert.False(t, f.Accept(badPeer), "Expected penalized peer to be rejected")
+	assert.True(t, f.Accept(goodPeer), "Expected good peer to be accepted")
+
+	time.Sleep(ttl)
+	assert.True(t, f.Accept(badPeer), "Expected penalized peer to be accepted after the TTL")
+	assert.True(t, f.Accept(goodPeer), "Expected good peer to be accepted")
+}
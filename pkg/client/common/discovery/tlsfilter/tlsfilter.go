@@ -0,0 +1,52 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package tlsfilter
+
+import (
+	"crypto/x509"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+var logger = logging.NewLogger("fabsdk/client")
+
+// Filter is a target filter that accepts only the peers whose TLS CA certificate (as configured in the
+// endpoint config) is trusted by the given pool of root certificates. Peers that aren't configured or
+// whose TLS CA certificate can't be loaded are rejected.
+type Filter struct {
+	config core.Config
+	roots  *x509.CertPool
+}
+
+// New creates a new TLS root filter which resolves the peer TLS config from the given config
+func New(config core.Config, roots *x509.CertPool) *Filter {
+	return &Filter{config: config, roots: roots}
+}
+
+// Accept returns true if the TLS CA certificate of the peer chains to a certificate in the pool
+func (f *Filter) Accept(peer fab.Peer) bool {
+	peerConfig, err := f.config.PeerConfigByURL(peer.URL())
+	if err != nil || peerConfig == nil {
+		logger.Debugf("Rejecting peer %s whose config could not be resolved: %v", peer.URL(), err)
+		return false
+	}
+
+	cert, err := peerConfig.TLSCACerts.TLSCert()
+	if err != nil || cert == nil {
+		logger.Debugf("Rejecting peer %s whose TLS CA certificate could not be loaded: %v", peer.URL(), err)
+		return false
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: f.roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+		logger.Debugf("Rejecting peer %s whose TLS CA certificate is not in the trusted pool: %s", peer.URL(), err)
+		return false
+	}
+
+	return true
+}
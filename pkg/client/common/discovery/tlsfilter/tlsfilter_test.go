@@ -0,0 +1,85 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package tlsfilter
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+type peerTLSConfig struct {
+	core.Config
+	pems map[string]string
+}
+
+func (c *peerTLSConfig) PeerConfigByURL(url string) (*core.PeerConfig, error) {
+	certPem, ok := c.pems[url]
+	if !ok {
+		return nil, errors.Errorf("peer %s not found", url)
+	}
+	peerConfig := &core.PeerConfig{URL: url}
+	peerConfig.TLSCACerts.Pem = certPem
+	return peerConfig, nil
+}
+
+func TestTLSRootFilter(t *testing.T) {
+	trustedCert, trustedPem := newTestCert("trusted", t)
+	_, untrustedPem := newTestCert("untrusted", t)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(trustedCert)
+
+	config := &peerTLSConfig{pems: map[string]string{
+		"grpcs://peer1.org:7051": trustedPem,
+		"grpcs://peer2.org:7051": untrustedPem,
+		"grpcs://peer3.org:7051": "",
+	}}
+	f := New(config, roots)
+
+	assert.True(t, f.Accept(mocks.NewMockPeer("peer1", "grpcs://peer1.org:7051")), "Expected peer with trusted TLS root to be accepted")
+	assert.False(t, f.Accept(mocks.NewMockPeer("peer2", "grpcs://peer2.org:7051")), "Expected peer with untrusted TLS root to be rejected")
+	assert.False(t, f.Accept(mocks.NewMockPeer("peer3", "grpcs://peer3.org:7051")), "Expected peer without TLS root to be rejected")
+	assert.False(t, f.Accept(mocks.NewMockPeer("peer4", "grpcs://peer4.org:7051")), "Expected unknown peer to be rejected")
+}
+
+func newTestCert(commonName string, t *testing.T) (*x509.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("error parsing certificate: %s", err)
+	}
+	return cert, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
@@ -24,8 +24,8 @@ type MockTransactor struct {
 }
 
 // CreateTransactionHeader creates a Transaction Header based on the current context.
-func (t *MockTransactor) CreateTransactionHeader() (fab.TransactionHeader, error) {
-	txh, err := txn.NewHeader(t.Ctx, t.ChannelID)
+func (t *MockTransactor) CreateTransactionHeader(opts ...fab.TxnHeaderOpt) (fab.TransactionHeader, error) {
+	txh, err := txn.NewHeader(t.Ctx, t.ChannelID, opts...)
 	if err != nil {
 		return nil, errors.WithMessage(err, "new transaction ID failed")
 	}
@@ -34,10 +34,10 @@ func (t *MockTransactor) CreateTransactionHeader() (fab.TransactionHeader, error
 }
 
 // SendTransactionProposal sends a TransactionProposal to the target peers.
-func (t *MockTransactor) SendTransactionProposal(proposal *fab.TransactionProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+func (t *MockTransactor) SendTransactionProposal(proposal *fab.TransactionProposal, targets []fab.ProposalProcessor, opts ...fab.SendProposalOpt) ([]*fab.TransactionProposalResponse, error) {
 	rqtx, cancel := contextImpl.NewRequest(t.Ctx, contextImpl.WithTimeout(10*time.Second))
 	defer cancel()
-	return txn.SendProposal(rqtx, proposal, targets)
+	return txn.SendProposal(rqtx, proposal, targets, opts...)
 }
 
 // CreateTransaction create a transaction with proposal response.
@@ -46,8 +46,8 @@ func (t *MockTransactor) CreateTransaction(request fab.TransactionRequest) (*fab
 }
 
 // SendTransaction send a transaction to the chain’s orderer service (one or more orderer endpoints) for consensus and committing to the ledger.
-func (t *MockTransactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionResponse, error) {
+func (t *MockTransactor) SendTransaction(tx *fab.Transaction, opts ...fab.SendTxnOpt) (*fab.TransactionResponse, error) {
 	rqtx, cancel := contextImpl.NewRequest(t.Ctx, contextImpl.WithTimeout(10*time.Second))
 	defer cancel()
-	return txn.Send(rqtx, tx, t.Orderers)
+	return txn.Send(rqtx, tx, t.Orderers, opts...)
 }
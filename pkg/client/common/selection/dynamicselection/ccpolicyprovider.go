@@ -69,27 +69,29 @@ func newCCPolicyProvider(providers api.Providers, channelID string, username str
 	}
 
 	cpp := ccPolicyProvider{
-		config:      providers.Config(),
-		providers:   providers,
-		channelID:   channelID,
-		identity:    identity,
-		targetPeers: targetPeers,
-		ccDataMap:   make(map[string]*ccprovider.ChaincodeData),
-		provider:    providers.InfraProvider(),
+		config:        providers.Config(),
+		providers:     providers,
+		channelID:     channelID,
+		identity:      identity,
+		targetPeers:   targetPeers,
+		ccDataMap:     make(map[string]*ccprovider.ChaincodeData),
+		provider:      providers.InfraProvider(),
+		collConfigMap: make(map[string]*common.CollectionConfigPackage),
 	}
 
 	return &cpp, nil
 }
 
 type ccPolicyProvider struct {
-	config      core.Config
-	providers   context.Providers
-	channelID   string
-	identity    msp.SigningIdentity
-	targetPeers []core.ChannelPeer
-	ccDataMap   map[string]*ccprovider.ChaincodeData // TODO: Add expiry and configurable timeout for map entries
-	mutex       sync.RWMutex
-	provider    peerCreator
+	config        core.Config
+	providers     context.Providers
+	channelID     string
+	identity      msp.SigningIdentity
+	targetPeers   []core.ChannelPeer
+	ccDataMap     map[string]*ccprovider.ChaincodeData // TODO: Add expiry and configurable timeout for map entries
+	mutex         sync.RWMutex
+	provider      peerCreator
+	collConfigMap map[string]*common.CollectionConfigPackage
 }
 
 func (dp *ccPolicyProvider) GetChaincodePolicy(chaincodeID string) (*common.SignaturePolicyEnvelope, error) {
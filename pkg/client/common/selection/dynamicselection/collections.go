@@ -0,0 +1,140 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dynamicselection
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+const collectionsConfigFunction = "GetCollectionsConfig"
+
+// CollectionPolicyProvider retrieves the member orgs policy of the private data collections of a chaincode
+type CollectionPolicyProvider interface {
+	GetCollectionPolicy(chaincodeID string, collection string) (*common.SignaturePolicyEnvelope, error)
+}
+
+func (dp *ccPolicyProvider) GetCollectionPolicy(chaincodeID string, collection string) (*common.SignaturePolicyEnvelope, error) {
+	if chaincodeID == "" {
+		return nil, errors.New("Must provide chaincode ID")
+	}
+
+	dp.mutex.Lock()
+	defer dp.mutex.Unlock()
+
+	collConfig, ok := dp.collConfigMap[chaincodeID]
+	if !ok {
+		response, err := dp.queryChaincode(ccDataProviderSCC, collectionsConfigFunction, [][]byte{[]byte(chaincodeID)})
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("error querying collections config for chaincode [%s] on channel [%s]", chaincodeID, dp.channelID))
+		}
+		collConfig = &common.CollectionConfigPackage{}
+		if err := proto.Unmarshal(response, collConfig); err != nil {
+			return nil, errors.Wrap(err, "Error unmarshalling collections config")
+		}
+		dp.collConfigMap[chaincodeID] = collConfig
+	}
+
+	return collectionPolicy(collConfig, collection)
+}
+
+// collectionPolicy returns the member orgs policy of the given collection of the collections config
+func collectionPolicy(collConfig *common.CollectionConfigPackage, collection string) (*common.SignaturePolicyEnvelope, error) {
+	for _, config := range collConfig.Config {
+		staticConfig := config.GetStaticCollectionConfig()
+		if staticConfig == nil || staticConfig.Name != collection {
+			continue
+		}
+		policy := staticConfig.GetMemberOrgsPolicy().GetSignaturePolicy()
+		if policy == nil {
+			return nil, errors.Errorf("collection [%s] has no member orgs signature policy", collection)
+		}
+		return policy, nil
+	}
+	return nil, errors.Errorf("collection [%s] not found", collection)
+}
+
+// collectionMembersFilter returns a peer filter that accepts the peers of the orgs that are members of all of
+// the given collections (according to the member orgs policies of the collections) and that are accepted by
+// the given filter (if any). The peers of the other orgs can't endorse the writes to the collections since
+// they don't have the private data.
+func (s *selectionService) collectionMembersFilter(chaincodeIDs []string, collections []string, filter options.PeerFilter) (options.PeerFilter, error) {
+	provider, ok := s.ccPolicyProvider.(CollectionPolicyProvider)
+	if !ok {
+		return nil, errors.Errorf("selection for collections %v is not supported by the chaincode policy provider", collections)
+	}
+
+	var members []map[string]bool
+	for _, collection := range collections {
+		policy, err := getCollectionPolicy(provider, chaincodeIDs, collection)
+		if err != nil {
+			return nil, err
+		}
+		mspIDs, err := memberMSPIDs(policy)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("error reading member orgs policy of collection [%s]", collection))
+		}
+		members = append(members, mspIDs)
+	}
+
+	return func(peer fab.Peer) bool {
+		if filter != nil && !filter(peer) {
+			return false
+		}
+		for _, mspIDs := range members {
+			if !mspIDs[peer.MSPID()] {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// getCollectionPolicy returns the member orgs policy of the collection of the first of the given chaincodes
+// that defines the collection
+func getCollectionPolicy(provider CollectionPolicyProvider, chaincodeIDs []string, collection string) (*common.SignaturePolicyEnvelope, error) {
+	var errs []error
+	for _, ccID := range chaincodeIDs {
+		policy, err := provider.GetCollectionPolicy(ccID, collection)
+		if err == nil {
+			return policy, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Errorf("error retrieving policy of collection [%s] of chaincodes %v: %v", collection, chaincodeIDs, errs)
+}
+
+// memberMSPIDs returns the MSP IDs of the principals of the given policy
+func memberMSPIDs(policy *common.SignaturePolicyEnvelope) (map[string]bool, error) {
+	mspIDs := make(map[string]bool)
+	for _, principal := range policy.Identities {
+		switch principal.PrincipalClassification {
+		case mb.MSPPrincipal_ROLE:
+			role := &mb.MSPRole{}
+			if err := proto.Unmarshal(principal.Principal, role); err != nil {
+				return nil, errors.Wrap(err, "unmarshal of MSP role failed")
+			}
+			mspIDs[role.MspIdentifier] = true
+		case mb.MSPPrincipal_ORGANIZATION_UNIT:
+			unit := &mb.OrganizationUnit{}
+			if err := proto.Unmarshal(principal.Principal, unit); err != nil {
+				return nil, errors.Wrap(err, "unmarshal of organization unit failed")
+			}
+			mspIDs[unit.MspIdentifier] = true
+		default:
+			return nil, errors.Errorf("unsupported principal classification: %s", principal.PrincipalClassification)
+		}
+	}
+	return mspIDs, nil
+}
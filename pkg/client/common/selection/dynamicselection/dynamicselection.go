@@ -172,12 +172,26 @@ func (s *selectionService) GetEndorsersForChaincode(chaincodeIDs []string, opts
 	}
 
 	params := options.NewParams(opts)
+	filter := params.PeerFilter
+	if len(params.Collections) > 0 {
+		var err error
+		filter, err = s.collectionMembersFilter(chaincodeIDs, params.Collections, filter)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("Error getting members of collections %v on channel [%s]", params.Collections, s.channelID))
+		}
+	}
 
 	resolver, err := s.getPeerGroupResolver(chaincodeIDs)
 	if err != nil {
 		return nil, errors.WithMessage(err, fmt.Sprintf("Error getting peer group resolver for chaincodes [%v] on channel [%s]", chaincodeIDs, s.channelID))
 	}
-	return resolver.Resolve(params.PeerFilter).Peers(), nil
+	if weightedResolver, ok := resolver.(pgresolver.WeightedPeerGroupResolver); ok && params.PeerWeight != nil {
+		return weightedResolver.ResolveWeighted(filter, params.PeerWeight, params.Random).Peers(), nil
+	}
+	if randomResolver, ok := resolver.(pgresolver.RandomPeerGroupResolver); ok && params.Random != nil {
+		return randomResolver.ResolveRandom(filter, params.Random).Peers(), nil
+	}
+	return resolver.Resolve(filter).Peers(), nil
 }
 
 func (s *selectionService) Close() {
@@ -12,7 +12,10 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/dynamicselection/pgresolver"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
@@ -183,6 +186,49 @@ func TestGetEndorsersForChaincodeTwoCCsTwoChannels(t *testing.T) {
 	verify(t, service, expected, channel2, cc1, cc2)
 }
 
+func TestGetEndorsersForChaincodeWithCollections(t *testing.T) {
+	channelPeers := []fab.Peer{p1, p2, p3, p4, p5, p6, p7, p8}
+
+	ccDataProvider := newMockCCDataProvider(channel1).
+		add(cc2, getPolicy2()).
+		addCollection(cc2, "coll1", org1, org3).
+		addCollection(cc2, "coll2", org1, org2, org3)
+	service, err := newMockSelectionService(ccDataProvider, pgresolver.NewRoundRobinLBP(), newMockDiscoveryService(channelPeers...))
+	if err != nil {
+		t.Fatalf("got error creating selection service: %s", err)
+	}
+
+	// Channel1(Policy(cc2)) = 1 of [(2 of [Org1,Org2]),(2 of [Org1,Org3,Org4])] with members of coll1 and coll2 = Org1 and Org3
+	expected := []pgresolver.PeerGroup{
+		pg(p1, p5), pg(p1, p6), pg(p1, p7), pg(p2, p5), pg(p2, p6), pg(p2, p7),
+	}
+	for i := 0; i < len(expected); i++ {
+		peers, err := service.GetEndorsersForChaincode([]string{cc2}, options.WithCollections("coll1", "coll2"))
+		if err != nil {
+			t.Fatalf("error getting endorsers: %s", err)
+		}
+		if !containsPeerGroup(expected, peers) {
+			t.Fatalf("peer group %s is not one of the expected peer groups: %v", toString(peers), expected)
+		}
+	}
+
+	// Unknown collection
+	_, err = service.GetEndorsersForChaincode([]string{cc2}, options.WithCollections("coll3"))
+	if err == nil {
+		t.Fatal("expecting error for unknown collection")
+	}
+
+	// The policy provider doesn't provide collection policies
+	service, err = newMockSelectionService(policyOnlyProvider{CCPolicyProvider: ccDataProvider}, pgresolver.NewRoundRobinLBP(), newMockDiscoveryService(channelPeers...))
+	if err != nil {
+		t.Fatalf("got error creating selection service: %s", err)
+	}
+	_, err = service.GetEndorsersForChaincode([]string{cc2}, options.WithCollections("coll1"))
+	if err == nil {
+		t.Fatal("expecting error for collections that can't be honoured")
+	}
+}
+
 func verify(t *testing.T, service fab.SelectionService, expectedPeerGroups []pgresolver.PeerGroup, channelID string, chaincodeIDs ...string) {
 	// Set the log level to WARNING since the following spits out too much info in DEBUG
 	module := "pg-resolver"
@@ -253,12 +299,51 @@ func newMockSelectionService(ccPolicyProvider CCPolicyProvider, lbp pgresolver.L
 }
 
 type mockCCDataProvider struct {
-	channelID string
-	ccData    map[string]*ccprovider.ChaincodeData
+	channelID   string
+	ccData      map[string]*ccprovider.ChaincodeData
+	collConfigs map[string]*common.CollectionConfigPackage
 }
 
 func newMockCCDataProvider(channelID string) *mockCCDataProvider {
-	return &mockCCDataProvider{channelID: channelID, ccData: make(map[string]*ccprovider.ChaincodeData)}
+	return &mockCCDataProvider{channelID: channelID, ccData: make(map[string]*ccprovider.ChaincodeData), collConfigs: make(map[string]*common.CollectionConfigPackage)}
+}
+
+func (p *mockCCDataProvider) GetCollectionPolicy(chaincodeID string, collection string) (*common.SignaturePolicyEnvelope, error) {
+	collConfig, ok := p.collConfigs[chaincodeID]
+	if !ok {
+		return nil, errors.Errorf("no collections config for chaincode [%s]", chaincodeID)
+	}
+	return collectionPolicy(collConfig, collection)
+}
+
+func (p *mockCCDataProvider) addCollection(chaincodeID string, collection string, memberMSPIDs ...string) *mockCCDataProvider {
+	signedBy, identities, err := pgresolver.GetPolicies(memberMSPIDs...)
+	if err != nil {
+		panic(err)
+	}
+	policy := &common.SignaturePolicyEnvelope{Rule: pgresolver.NewNOutOfPolicy(1, signedBy...), Identities: identities}
+
+	collConfig, ok := p.collConfigs[chaincodeID]
+	if !ok {
+		collConfig = &common.CollectionConfigPackage{}
+		p.collConfigs[chaincodeID] = collConfig
+	}
+	collConfig.Config = append(collConfig.Config, &common.CollectionConfig{
+		Payload: &common.CollectionConfig_StaticCollectionConfig{
+			StaticCollectionConfig: &common.StaticCollectionConfig{
+				Name: collection,
+				MemberOrgsPolicy: &common.CollectionPolicyConfig{
+					Payload: &common.CollectionPolicyConfig_SignaturePolicy{SignaturePolicy: policy},
+				},
+			},
+		},
+	})
+	return p
+}
+
+// policyOnlyProvider provides chaincode policies but no collection policies
+type policyOnlyProvider struct {
+	CCPolicyProvider
 }
 
 func (p *mockCCDataProvider) GetChaincodePolicy(chaincodeID string) (*common.SignaturePolicyEnvelope, error) {
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package pgresolver
 
 import (
+	"math/rand"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	common "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
@@ -33,6 +35,25 @@ type PeerGroupResolver interface {
 	Resolve(filter options.PeerFilter) PeerGroup
 }
 
+// RandomPeerGroupResolver is implemented by a PeerGroupResolver that is able to choose the PeerGroup
+// using a given source of randomness
+type RandomPeerGroupResolver interface {
+	// ResolveRandom is the same as Resolve except that the PeerGroup is chosen randomly using the
+	// given source of randomness instead of by the load-balance policy, so that the choice is
+	// reproducible for a seeded source.
+	ResolveRandom(filter options.PeerFilter, random *rand.Rand) PeerGroup
+}
+
+// WeightedPeerGroupResolver is implemented by a PeerGroupResolver that is able to favour the PeerGroups
+// whose peers have higher weights
+type WeightedPeerGroupResolver interface {
+	// ResolveWeighted is the same as Resolve except that each PeerGroup is only offered to the load-balance
+	// policy with a probability proportional to the product of the weights of its peers (relative to the
+	// PeerGroup with the highest weight, which is always offered). If random is nil then the default source
+	// of randomness is used.
+	ResolveWeighted(filter options.PeerFilter, weight options.PeerWeight, random *rand.Rand) PeerGroup
+}
+
 // LoadBalancePolicy is used to pick a peer group from a given set of peer groups
 type LoadBalancePolicy interface {
 	// Choose returns one of the peer groups from the given set of peer groups.
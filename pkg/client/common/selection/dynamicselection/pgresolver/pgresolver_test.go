@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package pgresolver
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
@@ -323,6 +324,130 @@ func TestPeerGroupResolverPolicy5(t *testing.T) {
 	testPeerGroupResolver(t, sigPolicyEnv, retrievePeersByMSPid, expected, nil)
 }
 
+func TestPeerGroupResolverRandom(t *testing.T) {
+	signedBy, identities, err := GetPolicies(org1, org2)
+	if err != nil {
+		panic(err)
+	}
+
+	sigPolicyEnv := &common.SignaturePolicyEnvelope{
+		Version:    0,
+		Rule:       NewNOutOfPolicy(2, signedBy[o1], signedBy[o2]),
+		Identities: identities,
+	}
+	expected := []PeerGroup{pg(p1, p3), pg(p1, p4), pg(p2, p3), pg(p2, p4)}
+
+	resolver, err := NewRoundRobinPeerGroupResolver(sigPolicyEnv, retrievePeersByMSPid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgResolver, ok := resolver.(RandomPeerGroupResolver)
+	if !ok {
+		t.Fatal("expecting the peer group resolver to resolve random peer groups")
+	}
+
+	resolve := func(seed int64) []PeerGroup {
+		random := rand.New(rand.NewSource(seed))
+		var peerGroups []PeerGroup
+		for i := 0; i < 10; i++ {
+			peerGroup := pgResolver.ResolveRandom(nil, random)
+			if !containsPeerGroup(expected, peerGroup) {
+				t.Fatalf("peer group %s is not one of the expected peer groups: %v", peerGroup, expected)
+			}
+			peerGroups = append(peerGroups, peerGroup)
+		}
+		return peerGroups
+	}
+
+	first := resolve(7)
+	second := resolve(7)
+	for i := range first {
+		if !containsAllPeers(first[i], second[i]) || !containsAllPeers(second[i], first[i]) {
+			t.Fatalf("expecting the same peer groups to be resolved for the same seed but got %s and %s", first[i], second[i])
+		}
+	}
+
+	filter := func(peer fab.Peer) bool { return peer != p1 }
+	for i := 0; i < 10; i++ {
+		peerGroup := pgResolver.ResolveRandom(filter, rand.New(rand.NewSource(int64(i))))
+		if containsPeer(peerGroup.Peers(), p1) {
+			t.Fatalf("peer group %s includes a peer that isn't accepted by the filter", peerGroup)
+		}
+	}
+}
+
+func TestPeerGroupResolverWeighted(t *testing.T) {
+	signedBy, identities, err := GetPolicies(org1, org2)
+	if err != nil {
+		panic(err)
+	}
+
+	sigPolicyEnv := &common.SignaturePolicyEnvelope{
+		Version:    0,
+		Rule:       NewNOutOfPolicy(2, signedBy[o1], signedBy[o2]),
+		Identities: identities,
+	}
+	expected := []PeerGroup{pg(p1, p3), pg(p1, p4), pg(p2, p3), pg(p2, p4)}
+
+	groupHierarchy, err := NewSignaturePolicyCompiler(retrievePeersByMSPid).Compile(sigPolicyEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lbp := &countingLBP{LoadBalancePolicy: NewRoundRobinLBP()}
+	resolver, err := NewPeerGroupResolver(groupHierarchy, lbp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgResolver, ok := resolver.(WeightedPeerGroupResolver)
+	if !ok {
+		t.Fatal("expecting the peer group resolver to resolve weighted peer groups")
+	}
+
+	// The peer groups including p1 have no weight so they're never chosen
+	weight := func(peer fab.Peer) float64 {
+		if peer == p1 {
+			return 0
+		}
+		return 1
+	}
+	random := rand.New(rand.NewSource(7))
+	chosen := make(map[bool]bool)
+	for i := 0; i < 50; i++ {
+		peerGroup := pgResolver.ResolveWeighted(nil, weight, random)
+		if !containsPeerGroup(expected, peerGroup) {
+			t.Fatalf("peer group %s is not one of the expected peer groups: %v", peerGroup, expected)
+		}
+		if containsPeer(peerGroup.Peers(), p1) {
+			t.Fatalf("peer group %s includes a peer without weight", peerGroup)
+		}
+		chosen[containsPeer(peerGroup.Peers(), p3)] = true
+	}
+	if len(chosen) != 2 {
+		t.Fatal("expecting both of the peer groups without p1 to be chosen")
+	}
+	if lbp.count != 50 {
+		t.Fatalf("expecting the load-balance policy to choose each of the peer groups but it was invoked %d times", lbp.count)
+	}
+
+	// If none of the peer groups has a weight then any of them may be chosen
+	noWeight := func(peer fab.Peer) float64 { return 0 }
+	peerGroup := pgResolver.ResolveWeighted(nil, noWeight, nil)
+	if !containsPeerGroup(expected, peerGroup) {
+		t.Fatalf("peer group %s is not one of the expected peer groups: %v", peerGroup, expected)
+	}
+}
+
+// countingLBP counts the peer group choices of a load-balance policy
+type countingLBP struct {
+	LoadBalancePolicy
+	count int
+}
+
+func (lbp *countingLBP) Choose(peerGroups []PeerGroup) PeerGroup {
+	lbp.count++
+	return lbp.LoadBalancePolicy.Choose(peerGroups)
+}
+
 func testPeerGroupResolver(t *testing.T, sigPolicyEnv *common.SignaturePolicyEnvelope, peerRetriever PeerRetriever, expected []PeerGroup, filter options.PeerFilter) {
 
 	pgResolver, err := NewRoundRobinPeerGroupResolver(sigPolicyEnv, peerRetriever)
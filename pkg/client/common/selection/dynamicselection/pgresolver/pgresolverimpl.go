@@ -8,6 +8,7 @@ package pgresolver
 
 import (
 	"fmt"
+	"math/rand"
 	"reflect"
 
 	"github.com/golang/protobuf/proto"
@@ -74,6 +75,60 @@ func NewPeerGroupResolver(groupHierarchy GroupOfGroups, lbp LoadBalancePolicy) (
 }
 
 func (c *peerGroupResolver) Resolve(filter options.PeerFilter) PeerGroup {
+	return c.lbp.Choose(c.getAvailablePeerGroups(filter))
+}
+
+func (c *peerGroupResolver) ResolveRandom(filter options.PeerFilter, random *rand.Rand) PeerGroup {
+	peerGroups := c.getAvailablePeerGroups(filter)
+	if len(peerGroups) == 0 {
+		logger.Warn("No available peer groups\n")
+		// Return an empty PeerGroup
+		return NewPeerGroup()
+	}
+	return peerGroups[random.Intn(len(peerGroups))]
+}
+
+func (c *peerGroupResolver) ResolveWeighted(filter options.PeerFilter, weight options.PeerWeight, random *rand.Rand) PeerGroup {
+	peerGroups := c.getAvailablePeerGroups(filter)
+	if len(peerGroups) == 0 {
+		logger.Warn("No available peer groups\n")
+		// Return an empty PeerGroup
+		return NewPeerGroup()
+	}
+
+	weights := make([]float64, len(peerGroups))
+	var max float64
+	for i, pg := range peerGroups {
+		weights[i] = 1
+		for _, p := range pg.Peers() {
+			weights[i] *= weight(p)
+		}
+		if weights[i] > max {
+			max = weights[i]
+		}
+	}
+
+	if max <= 0 {
+		// None of the peer groups has a weight so they're all offered
+		return c.lbp.Choose(peerGroups)
+	}
+
+	float64n := rand.Float64
+	if random != nil {
+		float64n = random.Float64
+	}
+
+	var offered []PeerGroup
+	for i, pg := range peerGroups {
+		if float64n()*max < weights[i] {
+			offered = append(offered, pg)
+		}
+	}
+	return c.lbp.Choose(offered)
+}
+
+// getAvailablePeerGroups returns the peer groups whose peers are all accepted by the filter (if any)
+func (c *peerGroupResolver) getAvailablePeerGroups(filter options.PeerFilter) []PeerGroup {
 	peerGroups := c.getPeerGroups()
 
 	if logging.IsEnabledFor(loggerModule, logging.DEBUG) {
@@ -112,7 +167,7 @@ func (c *peerGroupResolver) Resolve(filter options.PeerFilter) PeerGroup {
 		peerGroups = pgroups
 	}
 
-	return c.lbp.Choose(peerGroups)
+	return peerGroups
 }
 
 func (c *peerGroupResolver) getPeerGroups() []PeerGroup {
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package options
 
 import (
+	"math/rand"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
 	copts "github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
@@ -17,9 +19,15 @@ var logger = logging.NewLogger("fabsdk/client")
 // PeerFilter filters out unwanted peers
 type PeerFilter func(peer fab.Peer) bool
 
+// PeerWeight returns the relative weight (between 0 and 1) with which a peer should be selected
+type PeerWeight func(peer fab.Peer) float64
+
 // Params defines the parameters of a selection service request
 type Params struct {
-	PeerFilter PeerFilter
+	PeerFilter  PeerFilter
+	Collections []string
+	Random      *rand.Rand
+	PeerWeight  PeerWeight
 }
 
 // NewParams creates new parameters based on the provided options
@@ -47,3 +55,64 @@ func (p *Params) SetPeerFilter(value PeerFilter) {
 	logger.Debugf("PeerFilter: %#v", value)
 	p.PeerFilter = value
 }
+
+// WithCollections sets the private data collections that the chaincode writes to. Dynamic selection
+// selects the peers that satisfy the chaincode policy among the peers of the orgs that are members of
+// all of the collections (according to the member orgs policies of the collections). Selection services
+// that can't honour the collections (for example, static selection) return an error.
+func WithCollections(names ...string) copts.Opt {
+	return func(p copts.Params) {
+		if setter, ok := p.(collectionsSetter); ok {
+			setter.SetCollections(names)
+		}
+	}
+}
+
+type collectionsSetter interface {
+	SetCollections(names []string)
+}
+
+// SetCollections sets the private data collections
+func (p *Params) SetCollections(names []string) {
+	logger.Debugf("Collections: %v", names)
+	p.Collections = names
+}
+
+// WithRandom sets the source of randomness used to choose between equivalent sets of endorsers. A seeded
+// source makes the selection reproducible; by default the selection service uses its own (non-deterministic) source.
+func WithRandom(value *rand.Rand) copts.Opt {
+	return func(p copts.Params) {
+		if setter, ok := p.(randomSetter); ok {
+			setter.SetRandom(value)
+		}
+	}
+}
+
+type randomSetter interface {
+	SetRandom(value *rand.Rand)
+}
+
+// SetRandom sets the source of randomness
+func (p *Params) SetRandom(value *rand.Rand) {
+	p.Random = value
+}
+
+// WithPeerWeight sets the weights with which the peers are selected. A selection service that chooses between
+// equivalent sets of endorsers favours the sets whose peers have higher weights (for example, the peers that
+// haven't failed recently); other selection services ignore it.
+func WithPeerWeight(value PeerWeight) copts.Opt {
+	return func(p copts.Params) {
+		if setter, ok := p.(peerWeightSetter); ok {
+			setter.SetPeerWeight(value)
+		}
+	}
+}
+
+type peerWeightSetter interface {
+	SetPeerWeight(value PeerWeight)
+}
+
+// SetPeerWeight sets the peer weights
+func (p *Params) SetPeerWeight(value PeerWeight) {
+	p.PeerWeight = value
+}
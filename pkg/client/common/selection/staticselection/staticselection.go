@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package staticselection
 
 import (
+	"github.com/pkg/errors"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
 	copts "github.com/hyperledger/fabric-sdk-go/pkg/common/options"
@@ -46,6 +48,9 @@ func (s *selectionService) Initialize(context contextAPI.Channel) error {
 
 func (s *selectionService) GetEndorsersForChaincode(chaincodeIDs []string, opts ...copts.Opt) ([]fab.Peer, error) {
 	params := options.NewParams(opts)
+	if len(params.Collections) > 0 {
+		return nil, errors.Errorf("selection for collections %v is not supported by static selection", params.Collections)
+	}
 
 	channelPeers, err := s.discoveryService.GetPeers()
 	if err != nil {
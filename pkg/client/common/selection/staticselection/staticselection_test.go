@@ -76,4 +76,9 @@ func TestStaticSelection(t *testing.T) {
 	if peers[0].URL() != peer2.URL() {
 		t.Fatalf("Expecting peer %s but got %s", peer2.URL(), peers[0].URL())
 	}
+
+	_, err = selectionService.GetEndorsersForChaincode(nil, options.WithCollections("coll1"))
+	if err == nil {
+		t.Fatal("Expecting error for collections that static selection can't honour")
+	}
 }
@@ -114,6 +114,7 @@ type PeerConfig struct {
 	EventURL    string
 	GRPCOptions map[string]interface{}
 	TLSCACerts  endpoint.TLSConfig
+	Labels      map[string]string
 }
 
 // CAConfig defines a CA configuration
@@ -34,6 +34,7 @@ type ChannelCfg interface {
 	AnchorPeers() []*OrgAnchorPeer
 	Orderers() []string
 	Versions() *Versions
+	Sequence() uint64
 }
 
 // ChannelMembership helps identify a channel's members
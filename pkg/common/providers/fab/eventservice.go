@@ -27,8 +27,12 @@ type FilteredBlockEvent struct {
 type TxStatusEvent struct {
 	// TxID is the ID of the transaction in which the event was set
 	TxID string
-	// TxValidationCode is the status code of the commit
+	// TxValidationCode is the status code of the commit. It is the only validation outcome that the
+	// committing peer records for a transaction (in the transactions filter of the block metadata), so
+	// the rejection reason of a custom validation plugin isn't available beyond this code.
 	TxValidationCode pb.TxValidationCode
+	// BlockNumber is the number of the block that contains the transaction
+	BlockNumber uint64
 }
 
 // CCEvent contains the data for a chaincode event
@@ -18,3 +18,17 @@ type Peer interface {
 
 	// TODO: Roles, Name, EnrollmentCertificate (if needed)
 }
+
+// PeerMetadata is optionally implemented by peers that expose custom
+// metadata (for example, a "region" label) through discovery
+type PeerMetadata interface {
+	// Metadata returns the peer's metadata as key/value pairs
+	Metadata() map[string]string
+}
+
+// PeerState is optionally implemented by peers that expose the state of
+// their ledger (for example, as reported by discovery)
+type PeerState interface {
+	// BlockHeight returns the height of the peer's ledger (the number of blocks)
+	BlockHeight() uint64
+}
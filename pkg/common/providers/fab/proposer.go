@@ -9,6 +9,8 @@ package fab
 import (
 	reqContext "context"
 
+	"google.golang.org/grpc"
+
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -19,8 +21,73 @@ type ProposalProcessor interface {
 
 // ProposalSender provides the ability for a transaction proposal to be created and sent.
 type ProposalSender interface {
-	CreateTransactionHeader() (TransactionHeader, error)
-	SendTransactionProposal(*TransactionProposal, []ProposalProcessor) ([]*TransactionProposalResponse, error)
+	CreateTransactionHeader(opts ...TxnHeaderOpt) (TransactionHeader, error)
+	SendTransactionProposal(proposal *TransactionProposal, targets []ProposalProcessor, opts ...SendProposalOpt) ([]*TransactionProposalResponse, error)
+}
+
+// ProposalSigner signs the serialized transaction proposal and returns the signature
+type ProposalSigner func(proposal []byte) ([]byte, error)
+
+// SendProposalOptions contains options for sending a transaction proposal
+type SendProposalOptions struct {
+	Signer      ProposalSigner
+	Compress    bool
+	Metadata    map[string]string
+	CallOptions []grpc.CallOption
+}
+
+// SendProposalOpt is an option for sending a transaction proposal
+type SendProposalOpt func(*SendProposalOptions)
+
+// WithProposalSigner specifies the function that signs the transaction proposal.
+// By default the proposal is signed with the signing manager of the client context.
+func WithProposalSigner(signer ProposalSigner) SendProposalOpt {
+	return func(options *SendProposalOptions) {
+		options.Signer = signer
+	}
+}
+
+// WithProposalCompression requests that the transaction proposal is gzip-compressed
+// when it is sent to the endorsers.
+func WithProposalCompression() SendProposalOpt {
+	return func(options *SendProposalOptions) {
+		options.Compress = true
+	}
+}
+
+// WithProposalMetadata adds gRPC metadata headers to the calls that send the transaction proposal to the endorsers
+func WithProposalMetadata(metadata map[string]string) SendProposalOpt {
+	return func(options *SendProposalOptions) {
+		if options.Metadata == nil {
+			options.Metadata = make(map[string]string)
+		}
+		for key, value := range metadata {
+			options.Metadata[key] = value
+		}
+	}
+}
+
+// WithProposalCallOptions adds gRPC call options to the calls that send the transaction proposal to the endorsers
+func WithProposalCallOptions(callOptions ...grpc.CallOption) SendProposalOpt {
+	return func(options *SendProposalOptions) {
+		options.CallOptions = append(options.CallOptions, callOptions...)
+	}
+}
+
+// TxnHeaderOptions contains options for creating a Transaction Header
+type TxnHeaderOptions struct {
+	Nonce []byte
+}
+
+// TxnHeaderOpt is a Transaction Header option
+type TxnHeaderOpt func(*TxnHeaderOptions)
+
+// WithNonce specifies the nonce to use when creating the Transaction Header.
+// A random nonce is generated if none is specified.
+func WithNonce(nonce []byte) TxnHeaderOpt {
+	return func(options *TxnHeaderOptions) {
+		options.Nonce = nonce
+	}
 }
 
 // TransactionID provides the identifier of a Fabric transaction proposal.
@@ -57,6 +124,9 @@ type TransactionProposal struct {
 // ProcessProposalRequest requests simulation of a proposed transaction from transaction processors.
 type ProcessProposalRequest struct {
 	SignedProposal *pb.SignedProposal
+	Compress       bool
+	Metadata       map[string]string
+	CallOptions    []grpc.CallOption
 }
 
 // TransactionProposalResponse respresents the result of transaction proposal processing.
@@ -21,7 +21,35 @@ type TransactionRequest struct {
 // TODO: CreateTransaction should be refactored as it is actually a factory method.
 type Sender interface {
 	CreateTransaction(request TransactionRequest) (*Transaction, error)
-	SendTransaction(tx *Transaction) (*TransactionResponse, error)
+	SendTransaction(tx *Transaction, opts ...SendTxnOpt) (*TransactionResponse, error)
+}
+
+// OrdererComparator returns true if orderer o1 is preferred over orderer o2
+type OrdererComparator func(o1, o2 Orderer) bool
+
+// SendTxnOptions contains options for sending a transaction to the orderers
+type SendTxnOptions struct {
+	OrdererComparator OrdererComparator
+	Signer            ProposalSigner
+}
+
+// SendTxnOpt is an option for sending a transaction
+type SendTxnOpt func(*SendTxnOptions)
+
+// WithOrdererComparator specifies the order in which the orderers are tried when the
+// transaction is sent. By default the orderers are tried in random order.
+func WithOrdererComparator(comparator OrdererComparator) SendTxnOpt {
+	return func(options *SendTxnOptions) {
+		options.OrdererComparator = comparator
+	}
+}
+
+// WithTransactionSigner specifies the function that signs the transaction envelope that is
+// sent to the orderers. By default the envelope is signed by the client context.
+func WithTransactionSigner(signer ProposalSigner) SendTxnOpt {
+	return func(options *SendTxnOptions) {
+		options.Signer = signer
+	}
 }
 
 // The Transaction object created from an endorsed proposal.
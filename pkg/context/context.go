@@ -321,6 +321,12 @@ func RequestCommManager(ctx reqContext.Context) (fab.CommManager, bool) {
 	return commManager, ok
 }
 
+// RequestWithCommManager returns a copy of the request-scoped context which uses the given CommManager
+// (for example, a connection pool dedicated to endorsers) instead of the one of the infra provider.
+func RequestWithCommManager(ctx reqContext.Context, commManager fab.CommManager) reqContext.Context {
+	return reqContext.WithValue(ctx, reqContextCommManager, commManager)
+}
+
 // RequestClientContext extracts the Client Context from the request-scoped context.
 func RequestClientContext(ctx reqContext.Context) (context.Client, bool) {
 	clientContext, ok := ctx.Value(reqContextClient).(context.Client)
@@ -111,14 +111,14 @@ func orderersByTarget(ctx context.Client) (map[string]core.OrdererConfig, error)
 }
 
 // CreateTransactionHeader creates a Transaction Header based on the current context.
-func (t *Transactor) CreateTransactionHeader() (fab.TransactionHeader, error) {
+func (t *Transactor) CreateTransactionHeader(opts ...fab.TxnHeaderOpt) (fab.TransactionHeader, error) {
 
 	ctx, ok := contextImpl.RequestClientContext(t.reqCtx)
 	if !ok {
 		return nil, errors.New("failed get client context from reqContext for txn Header")
 	}
 
-	txh, err := txn.NewHeader(ctx, t.ChannelID)
+	txh, err := txn.NewHeader(ctx, t.ChannelID, opts...)
 	if err != nil {
 		return nil, errors.WithMessage(err, "new transaction ID failed")
 	}
@@ -127,7 +127,7 @@ func (t *Transactor) CreateTransactionHeader() (fab.TransactionHeader, error) {
 }
 
 // SendTransactionProposal sends a TransactionProposal to the target peers.
-func (t *Transactor) SendTransactionProposal(proposal *fab.TransactionProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+func (t *Transactor) SendTransactionProposal(proposal *fab.TransactionProposal, targets []fab.ProposalProcessor, opts ...fab.SendProposalOpt) ([]*fab.TransactionProposalResponse, error) {
 	ctx, ok := contextImpl.RequestClientContext(t.reqCtx)
 	if !ok {
 		return nil, errors.New("failed get client context from reqContext for SendTransactionProposal")
@@ -136,7 +136,7 @@ func (t *Transactor) SendTransactionProposal(proposal *fab.TransactionProposal,
 	reqCtx, cancel := contextImpl.NewRequest(ctx, contextImpl.WithTimeoutType(core.PeerResponse), contextImpl.WithParent(t.reqCtx))
 	defer cancel()
 
-	return txn.SendProposal(reqCtx, proposal, targets)
+	return txn.SendProposal(reqCtx, proposal, targets, opts...)
 }
 
 // CreateTransaction create a transaction with proposal response.
@@ -146,7 +146,7 @@ func (t *Transactor) CreateTransaction(request fab.TransactionRequest) (*fab.Tra
 }
 
 // SendTransaction send a transaction to the chain’s orderer service (one or more orderer endpoints) for consensus and committing to the ledger.
-func (t *Transactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionResponse, error) {
+func (t *Transactor) SendTransaction(tx *fab.Transaction, opts ...fab.SendTxnOpt) (*fab.TransactionResponse, error) {
 	ctx, ok := contextImpl.RequestClientContext(t.reqCtx)
 	if !ok {
 		return nil, errors.New("failed get client context from reqContext for SendTransaction")
@@ -155,5 +155,5 @@ func (t *Transactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionRespo
 	reqCtx, cancel := contextImpl.NewRequest(ctx, contextImpl.WithTimeoutType(core.OrdererResponse), contextImpl.WithParent(t.reqCtx))
 	defer cancel()
 
-	return txn.Send(reqCtx, tx, t.orderers)
+	return txn.Send(reqCtx, tx, t.orderers, opts...)
 }
@@ -67,6 +67,8 @@ type ChannelCfg struct {
 	anchorPeers []*fab.OrgAnchorPeer
 	orderers    []string
 	versions    *fab.Versions
+	sequence    uint64
+	maxBytes    uint32
 }
 
 // NewChannelCfg creates channel cfg
@@ -100,6 +102,17 @@ func (cfg *ChannelCfg) Versions() *fab.Versions {
 	return cfg.versions
 }
 
+// Sequence returns the sequence number of the config, which is incremented by each config update
+func (cfg *ChannelCfg) Sequence() uint64 {
+	return cfg.sequence
+}
+
+// AbsoluteMaxBytes returns the AbsoluteMaxBytes of the batch size of the orderer (the maximum size of a
+// transaction that the orderer accepts), or 0 if the config doesn't include the batch size
+func (cfg *ChannelCfg) AbsoluteMaxBytes() uint32 {
+	return cfg.maxBytes
+}
+
 // New channel config implementation
 func New(channelID string, options ...Option) (*ChannelConfig, error) {
 	opts, err := prepareOpts(options...)
@@ -241,6 +254,7 @@ func extractConfig(channelID string, configEnvelope *common.ConfigEnvelope) (*Ch
 		anchorPeers: []*fab.OrgAnchorPeer{},
 		orderers:    []string{},
 		versions:    versions,
+		sequence:    configEnvelope.Config.Sequence,
 	}
 
 	err := loadConfig(config, config.versions.Channel, group, "base", "", true)
@@ -416,7 +430,7 @@ func loadConfigValue(configItems *ChannelCfg, key string, versionsValue *common.
 		logger.Debugf("loadConfigValue - %s   - BatchSize  maxMessageCount :: %d", groupName, batchSize.MaxMessageCount)
 		logger.Debugf("loadConfigValue - %s   - BatchSize  absoluteMaxBytes :: %d", groupName, batchSize.AbsoluteMaxBytes)
 		logger.Debugf("loadConfigValue - %s   - BatchSize  preferredMaxBytes :: %d", groupName, batchSize.PreferredMaxBytes)
-		// TODO: Do something with this value
+		configItems.maxBytes = batchSize.AbsoluteMaxBytes
 		break
 
 	case channelConfig.BatchTimeoutKey:
@@ -48,6 +48,10 @@ func TestChannelConfigWithPeer(t *testing.T) {
 	if cfg.ID() != channelID {
 		t.Fatalf("Channel name error. Expecting %s, got %s ", channelID, cfg.ID())
 	}
+
+	if maxBytes := cfg.(*ChannelCfg).AbsoluteMaxBytes(); maxBytes == 0 {
+		t.Fatalf("Expecting the AbsoluteMaxBytes of the batch size to be loaded")
+	}
 }
 
 func TestChannelConfigWithPeerError(t *testing.T) {
@@ -0,0 +1,218 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ConnectionPool provides a pool of up to maxPerTarget GRPC connections to each target so that
+// requests to frequently contacted peers reuse warm connections. It provides the same "DialContext"
+// and "ReleaseConn" methods as the CachingConnector.
+//
+// DialContext hands out the healthy connection with the fewest users; a new connection is only dialed
+// if all of the pooled connections to the target are in use and the cap hasn't been reached. Connections
+// that are shut down or failing are discarded (liveness check), and connections that haven't been used
+// for longer than "idleTime" are closed by a sweep that runs every "sweepTime". Callers must release
+// connections by calling the "ReleaseConn" method. The Close method closes all of the pooled connections.
+//
+// This component has been designed to be safe for concurrency.
+type ConnectionPool struct {
+	maxPerTarget int
+	idleTime     time.Duration
+
+	lock  sync.Mutex
+	conns map[string][]*pooledConn
+	index map[*grpc.ClientConn]*pooledConn
+	done  chan struct{}
+}
+
+type pooledConn struct {
+	target   string
+	conn     *grpc.ClientConn
+	users    int
+	lastUsed time.Time
+}
+
+// defaultPoolSweepTime is the period of the idle connection sweep if no valid sweep time is given
+const defaultPoolSweepTime = 5 * time.Second
+
+// NewConnectionPool creates a GRPC connection pool that keeps up to maxPerTarget connections
+// to each target (at least one). The idle connections are swept every sweepTime (or every
+// 5 seconds if sweepTime isn't positive).
+func NewConnectionPool(maxPerTarget int, sweepTime time.Duration, idleTime time.Duration) *ConnectionPool {
+	if maxPerTarget < 1 {
+		maxPerTarget = 1
+	}
+	if sweepTime <= 0 {
+		sweepTime = defaultPoolSweepTime
+	}
+	p := &ConnectionPool{
+		maxPerTarget: maxPerTarget,
+		idleTime:     idleTime,
+		conns:        make(map[string][]*pooledConn),
+		index:        make(map[*grpc.ClientConn]*pooledConn),
+		done:         make(chan struct{}),
+	}
+	go p.sweeper(sweepTime, p.done)
+	return p
+}
+
+// Close closes all of the pooled connections. The pool is unusable after calling Close.
+func (p *ConnectionPool) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.done == nil {
+		return
+	}
+	logger.Debug("closing GRPC connection pool")
+	close(p.done)
+	p.done = nil
+
+	for _, conns := range p.conns {
+		for _, c := range conns {
+			closeConn(c.conn)
+		}
+	}
+	p.conns = make(map[string][]*pooledConn)
+	p.index = make(map[*grpc.ClientConn]*pooledConn)
+}
+
+// DialContext returns a pooled connection to the target, dialing a new one if required
+func (p *ConnectionPool) DialContext(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	c, err := p.acquire(ctx, target, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := waitConn(ctx, c.conn, connectivity.Ready); err != nil {
+		p.ReleaseConn(c.conn)
+		return nil, errors.Errorf("dialing connection timed out [%s]", target)
+	}
+	return c.conn, nil
+}
+
+// ReleaseConn notifies the pool that the connection is no longer in use
+func (p *ConnectionPool) ReleaseConn(conn *grpc.ClientConn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	c, ok := p.index[conn]
+	if !ok {
+		logger.Debugf("connection not found in pool [%p]", conn)
+		return
+	}
+	if c.users > 0 {
+		c.users--
+	}
+	c.lastUsed = time.Now()
+}
+
+// acquire returns the healthy connection to the target with the fewest users, or a new connection if
+// all of them are in use and the pool isn't full
+func (p *ConnectionPool) acquire(ctx context.Context, target string, opts ...grpc.DialOption) (*pooledConn, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.done == nil {
+		return nil, errors.New("connection pool is closed")
+	}
+
+	var selected *pooledConn
+	for _, c := range p.prune(target) {
+		if c.conn.GetState() == connectivity.TransientFailure {
+			continue
+		}
+		if selected == nil || c.users < selected.users {
+			selected = c
+		}
+	}
+
+	if selected == nil || (selected.users > 0 && len(p.conns[target]) < p.maxPerTarget) {
+		logger.Debugf("creating pooled connection [%s]", target)
+		conn, err := grpc.DialContext(ctx, target, opts...)
+		if err != nil {
+			return nil, errors.WithMessage(err, "dialing peer failed")
+		}
+		selected = &pooledConn{target: target, conn: conn}
+		p.conns[target] = append(p.conns[target], selected)
+		p.index[conn] = selected
+	}
+
+	selected.users++
+	selected.lastUsed = time.Now()
+	return selected, nil
+}
+
+// prune removes the connections to the target that have been shut down and returns the remaining ones.
+// The lock must be held by the caller.
+func (p *ConnectionPool) prune(target string) []*pooledConn {
+	var alive []*pooledConn
+	for _, c := range p.conns[target] {
+		if c.conn.GetState() == connectivity.Shutdown {
+			logger.Debugf("removing shutdown connection from pool [%s]", target)
+			delete(p.index, c.conn)
+			continue
+		}
+		alive = append(alive, c)
+	}
+	if len(alive) == 0 {
+		delete(p.conns, target)
+	} else {
+		p.conns[target] = alive
+	}
+	return alive
+}
+
+func (p *ConnectionPool) sweeper(sweepTime time.Duration, done chan struct{}) {
+	ticker := time.NewTicker(sweepTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.sweep()
+		case <-done:
+			return
+		}
+	}
+}
+
+// sweep closes the connections that are unused and either idle for longer than the idle time or failing
+func (p *ConnectionPool) sweep() {
+	p.lock.Lock()
+	var idle []*pooledConn
+	now := time.Now()
+	for target := range p.conns {
+		var keep []*pooledConn
+		for _, c := range p.prune(target) {
+			if c.users == 0 && (now.After(c.lastUsed.Add(p.idleTime)) || c.conn.GetState() == connectivity.TransientFailure) {
+				delete(p.index, c.conn)
+				idle = append(idle, c)
+				continue
+			}
+			keep = append(keep, c)
+		}
+		if len(keep) == 0 {
+			delete(p.conns, target)
+		} else {
+			p.conns[target] = keep
+		}
+	}
+	p.lock.Unlock()
+
+	for _, c := range idle {
+		logger.Debugf("connection pool closing idle connection [%s]", c.target)
+		closeConn(c.conn)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"context"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestConnectionPool(t *testing.T) {
+	pool := NewConnectionPool(2, normalSweepTime, normalIdleTime)
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), normalTimeout)
+	defer cancel()
+
+	conn1, err := pool.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	assert.Nil(t, err, "DialContext should have succeeded")
+	assert.Equal(t, connectivity.Ready, conn1.GetState(), "connection should be ready")
+
+	// The first connection is in use so a second one is dialed
+	conn2, err := pool.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	assert.Nil(t, err, "DialContext should have succeeded")
+	assert.NotEqual(t, unsafe.Pointer(conn1), unsafe.Pointer(conn2), "connections should not match")
+
+	// The pool is full so the connection with the fewest users is shared
+	pool.ReleaseConn(conn1)
+	conn3, err := pool.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	assert.Nil(t, err, "DialContext should have succeeded")
+	assert.Equal(t, unsafe.Pointer(conn1), unsafe.Pointer(conn3), "connections should match")
+	conn4, err := pool.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	assert.Nil(t, err, "DialContext should have succeeded")
+	assert.Contains(t, []unsafe.Pointer{unsafe.Pointer(conn1), unsafe.Pointer(conn2)}, unsafe.Pointer(conn4), "pool should not exceed its cap")
+
+	// Connections that are shut down are discarded
+	conn1.Close()
+	conn5, err := pool.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	assert.Nil(t, err, "DialContext should have succeeded")
+	assert.NotEqual(t, unsafe.Pointer(conn1), unsafe.Pointer(conn5), "shutdown connection should not be reused")
+
+	pool.Close()
+	assert.Equal(t, connectivity.Shutdown, conn2.GetState(), "connection should be shutdown")
+	_, err = pool.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	assert.NotNil(t, err, "DialContext should fail once the pool is closed")
+}
+
+func TestConnectionPoolIdleEviction(t *testing.T) {
+	pool := NewConnectionPool(1, shortSweepTime, shortIdleTime)
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), normalTimeout)
+	defer cancel()
+
+	conn1, err := pool.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	assert.Nil(t, err, "DialContext should have succeeded")
+
+	time.Sleep(shortIdleTime + 2*shortSweepTime)
+	assert.Equal(t, connectivity.Ready, conn1.GetState(), "connection in use should not be evicted")
+
+	pool.ReleaseConn(conn1)
+	time.Sleep(shortIdleTime + 2*shortSweepTime)
+	assert.Equal(t, connectivity.Shutdown, conn1.GetState(), "idle connection should be evicted")
+}
+
+func TestConnectionPoolInvalidSweepTime(t *testing.T) {
+	for _, sweepTime := range []time.Duration{0, -time.Second} {
+		pool := NewConnectionPool(1, sweepTime, shortIdleTime)
+
+		ctx, cancel := context.WithTimeout(context.Background(), normalTimeout)
+		conn, err := pool.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+		cancel()
+		assert.Nil(t, err, "DialContext should have succeeded")
+		pool.ReleaseConn(conn)
+		pool.Close()
+	}
+}
@@ -54,8 +54,58 @@ func (e *EventEndpoint) Opts() []options.Opt {
 	return opts
 }
 
+// EndpointSnapshot is a serializable view of an event endpoint
+// and its resolved connection options
+type EndpointSnapshot struct {
+	URL                 string
+	EventURL            string
+	MSPID               string
+	HostOverride        string
+	CertificateSubject  string
+	CertificateNotAfter time.Time
+	KeepAliveTime       time.Duration
+	KeepAliveTimeout    time.Duration
+	KeepAlivePermit     bool
+	FailFast            bool
+	ConnectTimeout      time.Duration
+	AllowInsecure       bool
+}
+
+// Snapshot returns a serializable view of the endpoint
+func (e *EventEndpoint) Snapshot() EndpointSnapshot {
+	snapshot := EndpointSnapshot{
+		URL:              e.URL(),
+		EventURL:         e.EvtURL,
+		MSPID:            e.MSPID(),
+		HostOverride:     e.HostOverride,
+		KeepAliveTime:    e.KeepAliveParams.Time,
+		KeepAliveTimeout: e.KeepAliveParams.Timeout,
+		KeepAlivePermit:  e.KeepAliveParams.PermitWithoutStream,
+		FailFast:         e.FailFast,
+		ConnectTimeout:   e.ConnectTimeout,
+		AllowInsecure:    e.AllowInsecure,
+	}
+	if e.Certificate != nil {
+		snapshot.CertificateSubject = e.Certificate.Subject.String()
+		snapshot.CertificateNotAfter = e.Certificate.NotAfter
+	}
+	return snapshot
+}
+
+// EndpointOpt is an option for the EventEndpoint created by FromPeerConfig
+type EndpointOpt func(e *EventEndpoint)
+
+// WithKeepAlive forces the given GRPC keep-alive policy (time, timeout and permit-without-stream) on the
+// event connection, overriding the keep-alive options in the GRPC options of the peer config. This may be
+// used to keep event connections alive across idle periods on networks that drop idle connections.
+func WithKeepAlive(params keepalive.ClientParameters) EndpointOpt {
+	return func(e *EventEndpoint) {
+		e.KeepAliveParams = params
+	}
+}
+
 // FromPeerConfig creates a new EventEndpoint from the given config
-func FromPeerConfig(config core.Config, peer fab.Peer, peerCfg *core.PeerConfig) (*EventEndpoint, error) {
+func FromPeerConfig(config core.Config, peer fab.Peer, peerCfg *core.PeerConfig, opts ...EndpointOpt) (*EventEndpoint, error) {
 	certificate, err := peerCfg.TLSCACerts.TLSCert()
 	if err != nil {
 		//Ignore empty cert errors,
@@ -65,7 +115,7 @@ func FromPeerConfig(config core.Config, peer fab.Peer, peerCfg *core.PeerConfig)
 		}
 	}
 
-	return &EventEndpoint{
+	eventEndpoint := &EventEndpoint{
 		Peer:            peer,
 		EvtURL:          peerCfg.EventURL,
 		HostOverride:    getServerNameOverride(peerCfg),
@@ -74,7 +124,12 @@ func FromPeerConfig(config core.Config, peer fab.Peer, peerCfg *core.PeerConfig)
 		FailFast:        getFailFast(peerCfg),
 		ConnectTimeout:  config.TimeoutOrDefault(core.EventHubConnection),
 		AllowInsecure:   isInsecureAllowed(peerCfg),
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(eventEndpoint)
+	}
+
+	return eventEndpoint, nil
 }
 
 func getServerNameOverride(peerCfg *core.PeerConfig) string {
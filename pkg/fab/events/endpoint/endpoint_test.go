@@ -7,13 +7,23 @@ SPDX-License-Identifier: Apache-2.0
 package endpoint
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	fabmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/mocks"
+	"google.golang.org/grpc/keepalive"
 )
 
 func TestEndpoint(t *testing.T) {
@@ -67,6 +77,30 @@ func TestEndpoint(t *testing.T) {
 	}
 }
 
+func TestEndpointWithKeepAlive(t *testing.T) {
+	expectedKeepAlive := keepalive.ClientParameters{Time: time.Minute, Timeout: 10 * time.Second, PermitWithoutStream: true}
+
+	peerConfig := &core.PeerConfig{GRPCOptions: map[string]interface{}{"keep-alive-time": time.Second}}
+	endpoint, err := FromPeerConfig(fabmocks.NewMockConfig(), fabmocks.NewMockPeer("p1", "localhost:7051"), peerConfig, WithKeepAlive(expectedKeepAlive))
+	if err != nil {
+		t.Fatalf("unexpected error from peer config: %s", err)
+	}
+	if endpoint.KeepAliveParams != expectedKeepAlive {
+		t.Fatalf("expecting keepAliveParams %#v but got %#v", expectedKeepAlive, endpoint.KeepAliveParams)
+	}
+
+	discoveryProvider := NewDiscoveryProvider(newMockContext(), WithEndpointOpts(WithKeepAlive(expectedKeepAlive)))
+	snapshots, err := discoveryProvider.Snapshot("testchannel")
+	if err != nil {
+		t.Fatalf("error getting snapshot: %s", err)
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.KeepAliveTime != expectedKeepAlive.Time || snapshot.KeepAliveTimeout != expectedKeepAlive.Timeout || !snapshot.KeepAlivePermit {
+			t.Fatalf("expecting keep-alive policy %#v to be applied to endpoint %s", expectedKeepAlive, snapshot.URL)
+		}
+	}
+}
+
 func TestDiscoveryProvider(t *testing.T) {
 	ctx := newMockContext()
 	discoveryProvider := NewDiscoveryProvider(ctx)
@@ -137,3 +171,277 @@ func (f *mockFilter) Accept(peer fab.Peer) bool {
 	*f.numTimesCalled++
 	return true
 }
+
+func TestDiscoveryProviderSnapshot(t *testing.T) {
+	ctx := newMockContext()
+	discoveryProvider := NewDiscoveryProvider(ctx)
+
+	discoveryService, err := discoveryProvider.CreateDiscoveryService("testchannel")
+	if err != nil {
+		t.Fatalf("error creating discovery service: %s", err)
+	}
+	peers, err := discoveryService.GetPeers()
+	if err != nil {
+		t.Fatalf("error getting peers: %s", err)
+	}
+
+	snapshots, err := discoveryProvider.Snapshot("testchannel")
+	if err != nil {
+		t.Fatalf("error getting snapshot: %s", err)
+	}
+	if len(snapshots) != len(peers) {
+		t.Fatalf("expecting %d endpoints in snapshot but got %d", len(peers), len(snapshots))
+	}
+	for i, snapshot := range snapshots {
+		if snapshot.URL != peers[i].URL() {
+			t.Fatalf("expecting URL %s but got %s", peers[i].URL(), snapshot.URL)
+		}
+		if snapshot.ConnectTimeout != ctx.Config().TimeoutOrDefault(core.EventHubConnection) {
+			t.Fatalf("expecting connect timeout %s but got %s", ctx.Config().TimeoutOrDefault(core.EventHubConnection), snapshot.ConnectTimeout)
+		}
+	}
+}
+
+func TestDiscoveryProviderWithCertExpiryWindow(t *testing.T) {
+	ctx := newMockContext()
+	ctx.SetConfig(&certMockConfig{Config: fabmocks.NewMockConfig(), pem: newTestCertPem(time.Now().Add(time.Hour), t)})
+
+	testCases := []struct {
+		window        time.Duration
+		warnOnly      bool
+		expectedPeers int
+	}{
+		{window: 30 * time.Minute, expectedPeers: 1},
+		{window: 2 * time.Hour, expectedPeers: 0},
+		{window: 2 * time.Hour, warnOnly: true, expectedPeers: 1},
+	}
+
+	for _, tc := range testCases {
+		discoveryProvider := NewDiscoveryProvider(ctx, WithCertExpiryWindow(tc.window, tc.warnOnly))
+		discoveryService, err := discoveryProvider.CreateDiscoveryService("testchannel")
+		if err != nil {
+			t.Fatalf("error creating discovery service: %s", err)
+		}
+		peers, err := discoveryService.GetPeers()
+		if err != nil {
+			t.Fatalf("error getting peers: %s", err)
+		}
+		if len(peers) != tc.expectedPeers {
+			t.Fatalf("expecting %d peer(s) for expiry window %s (warnOnly: %t) but got %d", tc.expectedPeers, tc.window, tc.warnOnly, len(peers))
+		}
+	}
+}
+
+type certMockConfig struct {
+	core.Config
+	pem string
+}
+
+func (c *certMockConfig) PeerConfigByURL(url string) (*core.PeerConfig, error) {
+	peerConfig := &core.PeerConfig{}
+	peerConfig.TLSCACerts.Pem = c.pem
+	return peerConfig, nil
+}
+
+func newTestCertPem(notAfter time.Time, t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "peer1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %s", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestDiscoveryProviderWithStaticPeers(t *testing.T) {
+	ctx := newMockContext()
+	ctx.SetConfig(&staticPeersMockConfig{Config: fabmocks.NewMockConfig()})
+
+	discoveryProvider := NewDiscoveryProvider(ctx, WithStaticPeers("grpcs://static:7051", "grpcs://static:7051"))
+
+	discoveryService, err := discoveryProvider.CreateDiscoveryService("testchannel")
+	if err != nil {
+		t.Fatalf("error creating discovery service: %s", err)
+	}
+	peers, err := discoveryService.GetPeers()
+	if err != nil {
+		t.Fatalf("error getting peers: %s", err)
+	}
+
+	var numStatic int
+	for _, peer := range peers {
+		if _, ok := peer.(*EventEndpoint); !ok {
+			t.Fatalf("expecting peer to be an EventEndpoint")
+		}
+		if peer.URL() == "grpcs://static:7051" {
+			numStatic++
+		}
+	}
+	if numStatic != 1 {
+		t.Fatalf("expecting static peer to be included once but was included %d time(s)", numStatic)
+	}
+
+	// A static peer that isn't accepted by the target filter is excluded
+	discoveryProvider = NewDiscoveryProvider(ctx, WithStaticPeers("grpcs://static:7051"), WithTargetFilter(&excludeFilter{url: "grpcs://static:7051"}))
+	discoveryService, err = discoveryProvider.CreateDiscoveryService("testchannel")
+	if err != nil {
+		t.Fatalf("error creating discovery service: %s", err)
+	}
+	peers, err = discoveryService.GetPeers()
+	if err != nil {
+		t.Fatalf("error getting peers: %s", err)
+	}
+	if containsURL(peers, "grpcs://static:7051") {
+		t.Fatalf("expecting static peer to be excluded by the target filter")
+	}
+
+	// An unknown static peer is skipped
+	ctx.SetConfig(&staticPeersMockConfig{Config: fabmocks.NewMockConfig(), notFound: true})
+	discoveryProvider = NewDiscoveryProvider(ctx, WithStaticPeers("grpcs://static:7051"))
+	discoveryService, err = discoveryProvider.CreateDiscoveryService("testchannel")
+	if err != nil {
+		t.Fatalf("error creating discovery service: %s", err)
+	}
+	peers, err = discoveryService.GetPeers()
+	if err != nil {
+		t.Fatalf("error getting peers with unknown static peer: %s", err)
+	}
+	if containsURL(peers, "grpcs://static:7051") {
+		t.Fatalf("expecting unknown static peer to be skipped")
+	}
+}
+
+func containsURL(peers []fab.Peer, url string) bool {
+	for _, peer := range peers {
+		if peer.URL() == url {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDiscoveryProviderWithRefreshObserver(t *testing.T) {
+	peer1 := fabmocks.NewMockPeer("p1", "grpcs://peer1:7051")
+	peer2 := fabmocks.NewMockPeer("p2", "grpcs://peer2:7051")
+	discProvider, err := fabmocks.NewMockDiscoveryProvider(nil, []fab.Peer{peer1, peer2})
+	if err != nil {
+		t.Fatalf("error creating discovery provider: %s", err)
+	}
+	ctx := fabmocks.NewMockContextWithCustomDiscovery(mspmocks.NewMockSigningIdentity("user1", "Org1MSP"), discProvider)
+	ctx.SetConfig(newMockConfig())
+
+	var changes []PeerSetChange
+	filter := &excludeFilter{}
+	discoveryProvider := NewDiscoveryProvider(ctx, WithTargetFilter(filter), WithRefreshObserver(func(change PeerSetChange) {
+		changes = append(changes, change)
+	}))
+
+	discoveryService, err := discoveryProvider.CreateDiscoveryService("testchannel")
+	if err != nil {
+		t.Fatalf("error creating discovery service: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := discoveryService.GetPeers(); err != nil {
+			t.Fatalf("error getting peers: %s", err)
+		}
+	}
+	filter.url = peer2.URL()
+	if _, err := discoveryService.GetPeers(); err != nil {
+		t.Fatalf("error getting peers: %s", err)
+	}
+
+	if len(changes) != 3 {
+		t.Fatalf("expecting the observer to be invoked on each of the 3 refreshes but was invoked %d time(s)", len(changes))
+	}
+	if changes[0].ChannelID != "testchannel" {
+		t.Fatalf("expecting channel testchannel but got %s", changes[0].ChannelID)
+	}
+	expected := []PeerSetChange{
+		{ChannelID: "testchannel", Peers: []string{peer1.URL(), peer2.URL()}, Added: []string{peer1.URL(), peer2.URL()}},
+		{ChannelID: "testchannel", Peers: []string{peer1.URL(), peer2.URL()}},
+		{ChannelID: "testchannel", Peers: []string{peer1.URL()}, Removed: []string{peer2.URL()}},
+	}
+	for i, change := range changes {
+		if !reflect.DeepEqual(expected[i], change) {
+			t.Fatalf("expecting change %d to be %+v but got %+v", i, expected[i], change)
+		}
+	}
+}
+
+// excludeFilter excludes the peer with the given URL
+type excludeFilter struct {
+	url string
+}
+
+func (f *excludeFilter) Accept(peer fab.Peer) bool {
+	return peer.URL() != f.url
+}
+
+type staticPeersMockConfig struct {
+	core.Config
+	notFound bool
+}
+
+func (c *staticPeersMockConfig) PeerConfigByURL(url string) (*core.PeerConfig, error) {
+	if c.notFound && url == "grpcs://static:7051" {
+		return nil, nil
+	}
+	return &core.PeerConfig{URL: url}, nil
+}
+
+func (c *staticPeersMockConfig) NetworkPeers() ([]core.NetworkPeer, error) {
+	return []core.NetworkPeer{{PeerConfig: core.PeerConfig{URL: "grpcs://static:7051"}, MSPID: "Org1MSP"}}, nil
+}
+
+func TestDiscoveryProviderWithTLSCertResolver(t *testing.T) {
+	ctx := newMockContext()
+	ctx.SetConfig(&certMockConfig{Config: fabmocks.NewMockConfig(), pem: newTestCertPem(time.Now().Add(time.Hour), t)})
+
+	block, _ := pem.Decode([]byte(newTestCertPem(time.Now().Add(365*24*time.Hour), t)))
+	rotated, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("error parsing certificate: %s", err)
+	}
+
+	var resolved []string
+	resolver := func(url string) (*x509.Certificate, bool) {
+		resolved = append(resolved, url)
+		return rotated, true
+	}
+
+	// The certificate in the config expires within the window but the overriding certificate doesn't
+	discoveryProvider := NewDiscoveryProvider(ctx, WithCertExpiryWindow(2*time.Hour, false), WithTLSCertResolver(resolver))
+	discoveryService, err := discoveryProvider.CreateDiscoveryService("testchannel")
+	if err != nil {
+		t.Fatalf("error creating discovery service: %s", err)
+	}
+	peers, err := discoveryService.GetPeers()
+	if err != nil {
+		t.Fatalf("error getting peers: %s", err)
+	}
+	if len(peers) != 1 {
+		t.Fatalf("expecting 1 peer but got %d", len(peers))
+	}
+
+	eventEndpoint := peers[0].(*EventEndpoint)
+	if eventEndpoint.Certificate != rotated {
+		t.Fatalf("expecting the certificate of the event endpoint to be overridden")
+	}
+	if !reflect.DeepEqual(resolved, []string{eventEndpoint.URL()}) {
+		t.Fatalf("expecting the resolver to be called with [%s] but was called with %v", eventEndpoint.URL(), resolved)
+	}
+	if _, ok := eventEndpoint.Peer.(*peer.Peer); !ok {
+		t.Fatalf("expecting the peer of the event endpoint to be replaced by a peer with the overriding certificate")
+	}
+	if eventEndpoint.Peer.MSPID() != "Org1MSP" {
+		t.Fatalf("expecting MSP ID Org1MSP but got %s", eventEndpoint.Peer.MSPID())
+	}
+}
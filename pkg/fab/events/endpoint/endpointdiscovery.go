@@ -7,21 +7,53 @@ SPDX-License-Identifier: Apache-2.0
 package endpoint
 
 import (
+	"crypto/x509"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	"github.com/pkg/errors"
 )
 
+var logger = logging.NewLogger("fabsdk/fab")
+
 // DiscoveryProvider is a wrapper around a discovery provider that
 // converts each peer into an EventEndpoint. The EventEndpoint
 // provides additional connection options.
 type DiscoveryProvider struct {
 	fab.DiscoveryProvider
-	ctx    context.Client
-	filter fab.TargetFilter
+	ctx                context.Client
+	filter             fab.TargetFilter
+	certExpiryWindow   time.Duration
+	certExpiryWarnOnly bool
+	endpointOpts       []EndpointOpt
+	staticPeerURLs     []string
+	refreshObserver    RefreshObserver
+	tlsCertResolver    TLSCertResolver
+}
+
+// PeerSetChange describes how the event endpoints of a channel changed on a refresh of the discovery service
+type PeerSetChange struct {
+	ChannelID string
+	Peers     []string
+	Added     []string
+	Removed   []string
 }
 
+// RefreshObserver is invoked with the change of the event endpoints on each refresh of a discovery service
+type RefreshObserver func(change PeerSetChange)
+
+// TLSCertResolver returns the TLS certificate of the peer with the given URL, and true if the certificate
+// overrides the certificate in the peer config
+type TLSCertResolver func(url string) (*x509.Certificate, bool)
+
 // Opt is a discoveryProvider option
 type Opt func(p *DiscoveryProvider)
 
@@ -32,6 +64,54 @@ func WithTargetFilter(filter fab.TargetFilter) Opt {
 	}
 }
 
+// WithCertExpiryWindow excludes the peers whose TLS certificate has expired or expires
+// within the given window, since connections to these peers are likely to fail. If warnOnly
+// is true then a warning is logged for these peers but they are not excluded.
+func WithCertExpiryWindow(window time.Duration, warnOnly bool) Opt {
+	return func(p *DiscoveryProvider) {
+		p.certExpiryWindow = window
+		p.certExpiryWarnOnly = warnOnly
+	}
+}
+
+// WithEndpointOpts applies the given options to each of the event endpoints (for example, WithKeepAlive
+// to force a keep-alive policy on the event connections)
+func WithEndpointOpts(opts ...EndpointOpt) Opt {
+	return func(p *DiscoveryProvider) {
+		p.endpointOpts = append(p.endpointOpts, opts...)
+	}
+}
+
+// WithStaticPeers merges the peers with the given URLs (resolved from the peer config) with
+// the discovered peers. This may be used to always include known event peers which aren't
+// returned by dynamic discovery. Peers are de-duplicated by URL and are subject to the target
+// filter (if any). A URL that isn't in the peer config is skipped.
+func WithStaticPeers(urls ...string) Opt {
+	return func(p *DiscoveryProvider) {
+		p.staticPeerURLs = append(p.staticPeerURLs, urls...)
+	}
+}
+
+// WithRefreshObserver invokes the given observer each time a discovery service refreshes its event endpoints,
+// with the URLs of the endpoints that were added and removed since the previous refresh (all of the endpoints are
+// added on the first refresh). This may be used to alert when peers churn unexpectedly.
+func WithRefreshObserver(observer RefreshObserver) Opt {
+	return func(p *DiscoveryProvider) {
+		p.refreshObserver = observer
+	}
+}
+
+// WithTLSCertResolver overrides the TLS certificate of each peer for which the resolver returns a certificate.
+// The certificate only applies to the event connections to the peer; the peers that endorse requests are
+// resolved separately and keep the certificate of the config.
+// This may be used to connect to peers that serve a new TLS certificate (for example, in the middle of a
+// certificate rotation) without updating the config of the whole network.
+func WithTLSCertResolver(resolver TLSCertResolver) Opt {
+	return func(p *DiscoveryProvider) {
+		p.tlsCertResolver = resolver
+	}
+}
+
 // NewDiscoveryProvider returns a new event endpoint discovery provider
 func NewDiscoveryProvider(ctx context.Client, opts ...Opt) *DiscoveryProvider {
 	p := &DiscoveryProvider{
@@ -58,24 +138,87 @@ func (p *DiscoveryProvider) CreateDiscoveryService(channelID string) (fab.Discov
 	}
 
 	return &discoveryService{
-		DiscoveryService: target,
-		ctx:              p.ctx,
+		DiscoveryService:   target,
+		ctx:                p.ctx,
+		filter:             p.filter,
+		certExpiryWindow:   p.certExpiryWindow,
+		certExpiryWarnOnly: p.certExpiryWarnOnly,
+		endpointOpts:       p.endpointOpts,
+		staticPeerURLs:     p.staticPeerURLs,
+		channelID:          channelID,
+		refreshObserver:    p.refreshObserver,
+		tlsCertResolver:    p.tlsCertResolver,
 	}, nil
 }
 
+// Snapshot returns the event endpoints that are currently resolved for the given
+// channel, along with their connection options. This may be used to debug routing
+// decisions since no event connection is made.
+func (p *DiscoveryProvider) Snapshot(channelID string) ([]EndpointSnapshot, error) {
+	target, err := p.CreateDiscoveryService(channelID)
+	if err != nil {
+		return nil, err
+	}
+	return target.(*discoveryService).Snapshot()
+}
+
 type discoveryService struct {
 	fab.DiscoveryService
-	ctx context.Client
+	ctx                context.Client
+	filter             fab.TargetFilter
+	certExpiryWindow   time.Duration
+	certExpiryWarnOnly bool
+	endpointOpts       []EndpointOpt
+	staticPeerURLs     []string
+	channelID          string
+	refreshObserver    RefreshObserver
+	tlsCertResolver    TLSCertResolver
+	mutex              sync.Mutex
+	lastURLs           map[string]bool
 }
 
 func (s *discoveryService) GetPeers() ([]fab.Peer, error) {
-	var eventEndpoints []fab.Peer
+	eventEndpoints, err := s.eventEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	s.notifyRefresh(eventEndpoints)
+
+	var peers []fab.Peer
+	for _, eventEndpoint := range eventEndpoints {
+		peers = append(peers, eventEndpoint)
+	}
+	return peers, nil
+}
+
+// Snapshot returns the current event endpoints along with their resolved
+// connection options. No connection is made to the endpoints.
+func (s *discoveryService) Snapshot() ([]EndpointSnapshot, error) {
+	eventEndpoints, err := s.eventEndpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []EndpointSnapshot
+	for _, eventEndpoint := range eventEndpoints {
+		snapshots = append(snapshots, eventEndpoint.Snapshot())
+	}
+	return snapshots, nil
+}
+
+func (s *discoveryService) eventEndpoints() ([]*EventEndpoint, error) {
+	var eventEndpoints []*EventEndpoint
 
 	peers, err := s.DiscoveryService.GetPeers()
 	if err != nil {
 		return nil, err
 	}
 
+	peers, err = s.mergeStaticPeers(peers)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, peer := range peers {
 		peerConfig, err := s.ctx.Config().PeerConfigByURL(peer.URL())
 		if err != nil {
@@ -85,12 +228,132 @@ func (s *discoveryService) GetPeers() ([]fab.Peer, error) {
 			return nil, errors.Errorf("unable to get peer config from [%s]", peer.URL())
 		}
 
-		eventEndpoint, err := FromPeerConfig(s.ctx.Config(), peer, peerConfig)
+		eventEndpoint, err := FromPeerConfig(s.ctx.Config(), peer, peerConfig, s.endpointOpts...)
 		if err != nil {
 			return nil, errors.Wrapf(err, "unable to create event endpoint for [%s]", peer.URL())
 		}
+		if err := s.overrideTLSCert(eventEndpoint, peerConfig); err != nil {
+			return nil, errors.Wrapf(err, "unable to override TLS certificate for [%s]", peer.URL())
+		}
+		if s.certExpiresSoon(eventEndpoint) && !s.certExpiryWarnOnly {
+			continue
+		}
 		eventEndpoints = append(eventEndpoints, eventEndpoint)
 	}
 
 	return eventEndpoints, nil
 }
+
+// notifyRefresh invokes the refresh observer (if any) with the change of the given event endpoints
+// since the previous refresh
+func (s *discoveryService) notifyRefresh(eventEndpoints []*EventEndpoint) {
+	if s.refreshObserver == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	change := PeerSetChange{ChannelID: s.channelID}
+	urls := make(map[string]bool)
+	for _, eventEndpoint := range eventEndpoints {
+		url := eventEndpoint.URL()
+		urls[url] = true
+		change.Peers = append(change.Peers, url)
+		if !s.lastURLs[url] {
+			change.Added = append(change.Added, url)
+		}
+	}
+	for url := range s.lastURLs {
+		if !urls[url] {
+			change.Removed = append(change.Removed, url)
+		}
+	}
+	s.lastURLs = urls
+	s.mutex.Unlock()
+
+	sort.Strings(change.Removed)
+	if len(change.Added) > 0 || len(change.Removed) > 0 {
+		logger.Debugf("event endpoints of channel [%s] changed - added: %v, removed: %v", s.channelID, change.Added, change.Removed)
+	}
+	s.refreshObserver(change)
+}
+
+// mergeStaticPeers appends the configured static peers which aren't already in the given peers
+// and are accepted by the target filter (if any)
+func (s *discoveryService) mergeStaticPeers(peers []fab.Peer) ([]fab.Peer, error) {
+	if len(s.staticPeerURLs) == 0 {
+		return peers, nil
+	}
+
+	urls := make(map[string]bool)
+	for _, peer := range peers {
+		urls[peer.URL()] = true
+	}
+
+	for _, url := range s.staticPeerURLs {
+		peerCfg, err := config.NetworkPeerConfigFromURL(s.ctx.Config(), url)
+		if err != nil {
+			logger.Warnf("skipping static event peer [%s]: %s", url, err)
+			continue
+		}
+		if urls[peerCfg.URL] {
+			continue
+		}
+
+		peer, err := s.ctx.InfraProvider().CreatePeerFromConfig(peerCfg)
+		if err != nil {
+			return nil, errors.WithMessage(err, "creating peer from config failed")
+		}
+		if s.filter != nil && !s.filter.Accept(peer) {
+			logger.Debugf("static event peer [%s] is not accepted by the target filter", peerCfg.URL)
+			urls[peerCfg.URL] = true
+			continue
+		}
+
+		logger.Debugf("adding static event peer [%s]", peerCfg.URL)
+		urls[peerCfg.URL] = true
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
+
+// overrideTLSCert replaces the certificate of the given endpoint with the certificate returned by the TLS
+// certificate resolver (if any). The peer of the endpoint is also replaced by a peer with the same config
+// but the new certificate, so that the endpoint reports the certificate with which its events are delivered.
+func (s *discoveryService) overrideTLSCert(eventEndpoint *EventEndpoint, peerConfig *core.PeerConfig) error {
+	if s.tlsCertResolver == nil {
+		return nil
+	}
+	certificate, ok := s.tlsCertResolver(eventEndpoint.URL())
+	if !ok {
+		return nil
+	}
+
+	p, err := peer.New(s.ctx.Config(),
+		peer.FromPeerConfig(&core.NetworkPeer{PeerConfig: *peerConfig, MSPID: eventEndpoint.MSPID()}),
+		peer.WithURL(eventEndpoint.URL()),
+		peer.WithTLSCert(certificate),
+	)
+	if err != nil {
+		return errors.WithMessage(err, "creating peer failed")
+	}
+
+	logger.Debugf("overriding TLS certificate of peer [%s]", eventEndpoint.URL())
+	eventEndpoint.Peer = p
+	eventEndpoint.Certificate = certificate
+	return nil
+}
+
+// certExpiresSoon returns true (and logs a warning) if the certificate of the given
+// endpoint has expired or expires within the configured window
+func (s *discoveryService) certExpiresSoon(eventEndpoint *EventEndpoint) bool {
+	if s.certExpiryWindow <= 0 || eventEndpoint.Certificate == nil {
+		return false
+	}
+	notAfter := eventEndpoint.Certificate.NotAfter
+	if time.Now().Add(s.certExpiryWindow).Before(notAfter) {
+		return false
+	}
+	logger.Warnf("TLS certificate for peer [%s] expires at %s which is within the expiry window of %s", eventEndpoint.URL(), notAfter, s.certExpiryWindow)
+	return true
+}
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package dispatcher
 
 import (
+	"fmt"
 	"math"
 	"reflect"
 	"regexp"
@@ -237,11 +238,20 @@ func (ed *Dispatcher) handleRegisterCCEvent(e Event) {
 	}
 }
 
+// TxRegistrationExistsError is returned when a TxStatus registration already exists for the transaction
+type TxRegistrationExistsError struct {
+	TxID string
+}
+
+func (e *TxRegistrationExistsError) Error() string {
+	return fmt.Sprintf("registration already exists for TX ID [%s]", e.TxID)
+}
+
 func (ed *Dispatcher) handleRegisterTxStatusEvent(e Event) {
 	event := e.(*RegisterTxStatusEvent)
 
 	if _, exists := ed.txRegistrations[event.Reg.TxID]; exists {
-		event.ErrCh <- errors.Errorf("registration already exists for TX ID [%s]", event.Reg.TxID)
+		event.ErrCh <- &TxRegistrationExistsError{TxID: event.Reg.TxID}
 	} else {
 		ed.txRegistrations[event.Reg.TxID] = event.Reg
 		event.RegCh <- event.Reg
@@ -422,7 +432,7 @@ func (ed *Dispatcher) publishFilteredBlockEvents(fblock *pb.FilteredBlock) {
 	}
 
 	for _, tx := range fblock.FilteredTransactions {
-		ed.publishTxStatusEvents(tx)
+		ed.publishTxStatusEvents(tx, fblock.Number)
 
 		// Only send a chaincode event if the transaction has committed
 		if tx.TxValidationCode == pb.TxValidationCode_VALID {
@@ -439,22 +449,22 @@ func (ed *Dispatcher) publishFilteredBlockEvents(fblock *pb.FilteredBlock) {
 	}
 }
 
-func (ed *Dispatcher) publishTxStatusEvents(tx *pb.FilteredTransaction) {
+func (ed *Dispatcher) publishTxStatusEvents(tx *pb.FilteredTransaction, blockNum uint64) {
 	logger.Debugf("Publishing Tx Status event for TxID [%s]...", tx.Txid)
 	if reg, ok := ed.txRegistrations[tx.Txid]; ok {
 		logger.Debugf("Sending Tx Status event for TxID [%s] to registrant...", tx.Txid)
 
 		if ed.eventConsumerTimeout < 0 {
 			select {
-			case reg.Eventch <- NewTxStatusEvent(tx.Txid, tx.TxValidationCode):
+			case reg.Eventch <- NewTxStatusEventWithBlock(tx.Txid, tx.TxValidationCode, blockNum):
 			default:
 				logger.Warnf("Unable to send to Tx Status event channel.")
 			}
 		} else if ed.eventConsumerTimeout == 0 {
-			reg.Eventch <- NewTxStatusEvent(tx.Txid, tx.TxValidationCode)
+			reg.Eventch <- NewTxStatusEventWithBlock(tx.Txid, tx.TxValidationCode, blockNum)
 		} else {
 			select {
-			case reg.Eventch <- NewTxStatusEvent(tx.Txid, tx.TxValidationCode):
+			case reg.Eventch <- NewTxStatusEventWithBlock(tx.Txid, tx.TxValidationCode, blockNum):
 			case <-time.After(ed.eventConsumerTimeout):
 				logger.Warnf("Timed out sending Tx Status event.")
 			}
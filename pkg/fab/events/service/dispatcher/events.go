@@ -138,6 +138,13 @@ func NewTxStatusEvent(txID string, txValidationCode pb.TxValidationCode) *fab.Tx
 	}
 }
 
+// NewTxStatusEventWithBlock creates a new TxStatusEvent for a transaction in the given block
+func NewTxStatusEventWithBlock(txID string, txValidationCode pb.TxValidationCode, blockNum uint64) *fab.TxStatusEvent {
+	event := NewTxStatusEvent(txID, txValidationCode)
+	event.BlockNumber = blockNum
+	return event
+}
+
 // NewStopEvent creates a new StopEvent
 func NewStopEvent(errch chan<- error) *StopEvent {
 	return &StopEvent{
@@ -22,6 +22,8 @@ type MockChannelCfg struct {
 	MockOrderers    []string
 	MockVersions    *fab.Versions
 	MockMembership  fab.ChannelMembership
+	MockSequence    uint64
+	MockMaxBytes    uint32
 }
 
 // NewMockChannelCfg ...
@@ -54,6 +56,16 @@ func (cfg *MockChannelCfg) Versions() *fab.Versions {
 	return cfg.MockVersions
 }
 
+// Sequence returns the config sequence
+func (cfg *MockChannelCfg) Sequence() uint64 {
+	return cfg.MockSequence
+}
+
+// AbsoluteMaxBytes returns the AbsoluteMaxBytes of the batch size
+func (cfg *MockChannelCfg) AbsoluteMaxBytes() uint32 {
+	return cfg.MockMaxBytes
+}
+
 // MockChannelConfig mocks query channel configuration
 type MockChannelConfig struct {
 	channelID string
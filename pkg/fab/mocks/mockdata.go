@@ -88,10 +88,11 @@ func (b *MockConfigBlockBuilder) Build() *common.Block {
 }
 
 // buildBlockMetadata builds BlockMetadata that contains an array of bytes in the following order:
-// 	0: SIGNATURES
-// 	1: LAST_CONFIG
-// 	2: TRANSACTIONS_FILTER
-// 	3: ORDERER
+//
+//	0: SIGNATURES
+//	1: LAST_CONFIG
+//	2: TRANSACTIONS_FILTER
+//	3: ORDERER
 func (b *MockConfigBlockBuilder) buildBlockMetadata() *common.BlockMetadata {
 	return &common.BlockMetadata{
 		Metadata: [][]byte{
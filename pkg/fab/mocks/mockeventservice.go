@@ -13,13 +13,15 @@ import (
 
 // MockEventService implements a mock event service
 type MockEventService struct {
-	TxStatusRegCh chan *dispatcher.TxStatusReg
+	TxStatusRegCh  chan *dispatcher.TxStatusReg
+	ChaincodeRegCh chan *dispatcher.ChaincodeReg
 }
 
 // NewMockEventService returns a new mock event service
 func NewMockEventService() *MockEventService {
 	return &MockEventService{
-		TxStatusRegCh: make(chan *dispatcher.TxStatusReg, 1),
+		TxStatusRegCh:  make(chan *dispatcher.TxStatusReg, 1),
+		ChaincodeRegCh: make(chan *dispatcher.ChaincodeReg, 1),
 	}
 }
 
@@ -35,7 +37,14 @@ func (m *MockEventService) RegisterFilteredBlockEvent() (fab.Registration, <-cha
 
 // RegisterChaincodeEvent registers for chaincode events.
 func (m *MockEventService) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
-	panic("not implemented")
+	eventCh := make(chan *fab.CCEvent)
+	reg := &dispatcher.ChaincodeReg{
+		Eventch:     eventCh,
+		ChaincodeID: ccID,
+		EventFilter: eventFilter,
+	}
+	m.ChaincodeRegCh <- reg
+	return reg, eventCh, nil
 }
 
 // RegisterTxStatusEvent registers for transaction status events.
@@ -30,6 +30,8 @@ type MockPeer struct {
 	Status               int32
 	ProcessProposalCalls int
 	Endorser             []byte
+	MockMetadata         map[string]string
+	MockBlockHeight      uint64
 }
 
 // NewMockPeer creates basic mock peer
@@ -78,6 +80,20 @@ func (p *MockPeer) SetEnrollmentCertificate(pem *pem.Block) {
 	p.MockCert = pem
 }
 
+// Metadata returns the mock peer's mock metadata
+func (p *MockPeer) Metadata() map[string]string {
+	return p.MockMetadata
+}
+
+// BlockHeight returns the mock peer's block height
+func (p *MockPeer) BlockHeight() uint64 {
+	if p.RWLock != nil {
+		p.RWLock.RLock()
+		defer p.RWLock.RUnlock()
+	}
+	return p.MockBlockHeight
+}
+
 // URL returns the mock peer's mock URL
 func (p *MockPeer) URL() string {
 	return p.MockURL
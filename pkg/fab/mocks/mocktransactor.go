@@ -21,12 +21,12 @@ type MockTransactor struct {
 }
 
 // CreateTransactionHeader creates a Transaction Header based on the current context.
-func (t *MockTransactor) CreateTransactionHeader() (fab.TransactionHeader, error) {
+func (t *MockTransactor) CreateTransactionHeader(opts ...fab.TxnHeaderOpt) (fab.TransactionHeader, error) {
 	return &MockTransactionHeader{}, nil
 }
 
 // SendTransactionProposal sends a TransactionProposal to the target peers.
-func (t *MockTransactor) SendTransactionProposal(proposal *fab.TransactionProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+func (t *MockTransactor) SendTransactionProposal(proposal *fab.TransactionProposal, targets []fab.ProposalProcessor, opts ...fab.SendProposalOpt) ([]*fab.TransactionProposalResponse, error) {
 	response := make([]*fab.TransactionProposalResponse, 1, 1)
 	response[0] = &fab.TransactionProposalResponse{Endorser: "example.com", Status: 99,
 		ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{Payload: []byte("abc")}},
@@ -46,7 +46,7 @@ func (t *MockTransactor) CreateTransaction(request fab.TransactionRequest) (*fab
 }
 
 // SendTransaction send a transaction to the chain’s orderer service (one or more orderer endpoints) for consensus and committing to the ledger.
-func (t *MockTransactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionResponse, error) {
+func (t *MockTransactor) SendTransaction(tx *fab.Transaction, opts ...fab.SendTxnOpt) (*fab.TransactionResponse, error) {
 	response := &fab.TransactionResponse{
 		Orderer: "example.com",
 	}
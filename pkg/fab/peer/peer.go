@@ -36,6 +36,7 @@ type Peer struct {
 	failFast    bool
 	inSecure    bool
 	commManager fab.CommManager
+	metadata    map[string]string
 }
 
 // Option describes a functional parameter for the New constructor
@@ -124,6 +125,15 @@ func WithMSPID(mspID string) Option {
 	}
 }
 
+// WithMetadata is a functional option for the peer.New constructor that configures the peer's metadata labels
+func WithMetadata(metadata map[string]string) Option {
+	return func(p *Peer) error {
+		p.metadata = metadata
+
+		return nil
+	}
+}
+
 // FromPeerConfig is a functional option for the peer.New constructor that configures a new peer
 // from a apiconfig.NetworkPeer struct
 func FromPeerConfig(peerCfg *core.NetworkPeer) Option {
@@ -148,6 +158,7 @@ func FromPeerConfig(peerCfg *core.NetworkPeer) Option {
 		p.mspID = peerCfg.MSPID
 		p.kap = getKeepAliveOptions(peerCfg)
 		p.failFast = getFailFast(peerCfg)
+		p.metadata = peerCfg.Labels
 		return nil
 	}
 }
@@ -213,6 +224,11 @@ func (p *Peer) URL() string {
 	return p.url
 }
 
+// Metadata returns the metadata labels of the peer (the labels of the peer's config)
+func (p *Peer) Metadata() map[string]string {
+	return p.metadata
+}
+
 // ProcessTransactionProposal sends the created proposal to peer for endorsement.
 func (p *Peer) ProcessTransactionProposal(ctx reqContext.Context, proposal fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
 	return p.processor.ProcessTransactionProposal(ctx, proposal)
@@ -241,3 +241,40 @@ func TestNewPeerSecured(t *testing.T) {
 	}
 
 }
+
+// TestPeerMetadata validates that the labels of the peer config are exposed as the peer's metadata
+func TestPeerMetadata(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	config := mocks.DefaultMockConfig(mockCtrl)
+
+	networkPeer := &core.NetworkPeer{
+		PeerConfig: core.PeerConfig{
+			URL:    "grpc://0.0.0.0:1234",
+			Labels: map[string]string{"region": "east"},
+		},
+		MSPID: "Org1MSP",
+	}
+	p, err := New(config, FromPeerConfig(networkPeer))
+	if err != nil {
+		t.Fatalf("Failed to create new peer FromPeerConfig (%v)", err)
+	}
+
+	var apiPeer fab.Peer = p
+	metadata, ok := apiPeer.(fab.PeerMetadata)
+	if !ok {
+		t.Fatalf("Expected peer to implement PeerMetadata")
+	}
+	if metadata.Metadata()["region"] != "east" {
+		t.Fatalf("Expected region label 'east', got %v", metadata.Metadata())
+	}
+
+	p, err = New(config, WithURL("grpc://0.0.0.0:1234"), WithMetadata(map[string]string{"region": "west"}))
+	if err != nil {
+		t.Fatalf("Failed to create new peer WithMetadata (%v)", err)
+	}
+	if p.Metadata()["region"] != "west" {
+		t.Fatalf("Expected region label 'west', got %v", p.Metadata())
+	}
+}
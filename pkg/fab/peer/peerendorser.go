@@ -14,7 +14,9 @@ import (
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // registers the gzip compressor used for compressed proposals
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 	grpcstatus "google.golang.org/grpc/status"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
@@ -30,6 +32,9 @@ const (
 	// GRPC max message size (same as Fabric)
 	maxCallRecvMsgSize = 100 * 1024 * 1024
 	maxCallSendMsgSize = 100 * 1024 * 1024
+
+	// name of the compressor used for compressed proposals
+	gzipCompressor = "gzip"
 )
 
 // peerEndorser enables access to a GRPC-based endorser for running transaction proposal simulations
@@ -132,20 +137,39 @@ func (p *peerEndorser) sendProposal(ctx reqContext.Context, proposal fab.Process
 	if err != nil {
 		rpcStatus, ok := grpcstatus.FromError(err)
 		if ok {
-			return nil, errors.WithMessage(status.NewFromGRPCStatus(rpcStatus), "connection failed")
+			return nil, errors.WithMessage(p.grpcStatus(rpcStatus), "connection failed")
 		}
 		return nil, status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), err.Error(), []interface{}{p.target})
 	}
 	defer p.releaseConn(ctx, conn)
 
+	if len(proposal.Metadata) > 0 {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Join(md, metadata.New(proposal.Metadata)))
+	}
+
+	callOptions := proposal.CallOptions
+	if proposal.Compress {
+		// The proposal is compressed per call so that the cached connection to the endorser is used
+		callOptions = append(append([]grpc.CallOption{}, callOptions...), grpc.UseCompressor(gzipCompressor))
+	}
+
 	endorserClient := pb.NewEndorserClient(conn)
-	resp, err := endorserClient.ProcessProposal(ctx, proposal.SignedProposal)
+	resp, err := endorserClient.ProcessProposal(ctx, proposal.SignedProposal, callOptions...)
 	if err != nil {
 		logger.Errorf("process proposal failed [%s]", err)
 		rpcStatus, ok := grpcstatus.FromError(err)
 		if ok {
-			err = status.NewFromGRPCStatus(rpcStatus)
+			err = p.grpcStatus(rpcStatus)
 		}
 	}
 	return resp, err
 }
+
+// grpcStatus returns the status of the given gRPC error. The target is appended to the details
+// so that the endorser that failed can be identified (for example, to penalize it).
+func (p *peerEndorser) grpcStatus(rpcStatus *grpcstatus.Status) *status.Status {
+	s := status.NewFromGRPCStatus(rpcStatus)
+	s.Details = append(s.Details, p.target)
+	return s
+}
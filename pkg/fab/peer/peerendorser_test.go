@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -20,6 +21,8 @@ import (
 	"google.golang.org/grpc"
 	grpcCodes "google.golang.org/grpc/codes"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
@@ -202,7 +205,126 @@ func TestProcessProposalGoodDial(t *testing.T) {
 	}
 }
 
+// TestProcessProposalCompressed validates that a compressed proposal
+// is gzip-compressed on the wire.
+func TestProcessProposalCompressed(t *testing.T) {
+	payloads := &inPayloadStats{}
+	grpcServer := grpc.NewServer(grpc.RPCDecompressor(grpc.NewGZIPDecompressor()), grpc.StatsHandler(payloads))
+	defer grpcServer.Stop()
+	_, addr := startEndorserServer(t, grpcServer)
+
+	request := mockProcessProposalRequest()
+	request.SignedProposal.ProposalBytes = make([]byte, 10000)
+	request.Compress = true
+	_, err := testProcessProposalRequest(t, reqContext.Background(), "grpc://"+addr, request)
+	if err != nil {
+		t.Fatalf("Process proposal failed (%v)", err)
+	}
+
+	wireLength, length := payloads.last()
+	assert.True(t, wireLength < length/10, "expecting the proposal to be compressed (%d of %d bytes sent)", wireLength, length)
+
+	// The compressed proposal uses the connection of the comm manager
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mockCore.DefaultMockConfig(mockCtrl)
+	config.EXPECT().TimeoutOrDefault(gomock.Any()).Return(time.Second * 1).AnyTimes()
+
+	commManager := &countingCommManager{}
+	endorseReq := getPeerEndorserRequest("grpc://"+addr, nil, "", config, kap, false, true)
+	endorseReq.commManager = commManager
+	endorser, err := newPeerEndorser(endorseReq)
+	if err != nil {
+		t.Fatalf("Peer conn construction error (%v)", err)
+	}
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), normalTimeout)
+	defer cancel()
+	_, err = endorser.ProcessTransactionProposal(ctx, request)
+	if err != nil {
+		t.Fatalf("Process proposal failed (%v)", err)
+	}
+	assert.Equal(t, 1, commManager.dials, "expecting the compressed proposal to use the comm manager")
+	assert.Equal(t, 1, commManager.releases)
+}
+
+// countingCommManager counts the connections dialed and released through it
+type countingCommManager struct {
+	defCommManager
+	dials    int
+	releases int
+}
+
+func (m *countingCommManager) DialContext(ctx reqContext.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	m.dials++
+	return m.defCommManager.DialContext(ctx, target, opts...)
+}
+
+func (m *countingCommManager) ReleaseConn(conn *grpc.ClientConn) {
+	m.releases++
+	m.defCommManager.ReleaseConn(conn)
+}
+
+// TestProcessProposalMetadata validates that the metadata of the proposal
+// is sent along with the metadata of the context.
+func TestProcessProposalMetadata(t *testing.T) {
+	var received metadata.MD
+	interceptor := func(ctx reqContext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		received, _ = metadata.FromIncomingContext(ctx)
+		return handler(ctx, req)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
+	defer grpcServer.Stop()
+	_, addr := startEndorserServer(t, grpcServer)
+
+	request := mockProcessProposalRequest()
+	request.Metadata = map[string]string{"x-tenant": "tenant1"}
+	ctx := metadata.NewOutgoingContext(reqContext.Background(), metadata.Pairs("x-trace", "trace1"))
+	_, err := testProcessProposalRequest(t, ctx, "grpc://"+addr, request)
+	if err != nil {
+		t.Fatalf("Process proposal failed (%v)", err)
+	}
+
+	assert.Equal(t, []string{"tenant1"}, received["x-tenant"])
+	assert.Equal(t, []string{"trace1"}, received["x-trace"])
+}
+
+// inPayloadStats records the lengths of the last payload received by the server
+type inPayloadStats struct {
+	mutex      sync.Mutex
+	wireLength int
+	length     int
+}
+
+func (s *inPayloadStats) TagRPC(ctx reqContext.Context, info *stats.RPCTagInfo) reqContext.Context {
+	return ctx
+}
+
+func (s *inPayloadStats) HandleRPC(ctx reqContext.Context, rs stats.RPCStats) {
+	if in, ok := rs.(*stats.InPayload); ok {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.wireLength, s.length = in.WireLength, in.Length
+	}
+}
+
+func (s *inPayloadStats) TagConn(ctx reqContext.Context, info *stats.ConnTagInfo) reqContext.Context {
+	return ctx
+}
+
+func (s *inPayloadStats) HandleConn(ctx reqContext.Context, cs stats.ConnStats) {
+}
+
+func (s *inPayloadStats) last() (int, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.wireLength, s.length
+}
+
 func testProcessProposal(t *testing.T, url string) (*fab.TransactionProposalResponse, error) {
+	return testProcessProposalRequest(t, reqContext.Background(), url, mockProcessProposalRequest())
+}
+
+func testProcessProposalRequest(t *testing.T, parent reqContext.Context, url string, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	config := mockCore.DefaultMockConfig(mockCtrl)
@@ -213,9 +335,9 @@ func testProcessProposal(t *testing.T, url string) (*fab.TransactionProposalResp
 		t.Fatalf("Peer conn construction error (%v)", err)
 	}
 
-	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), normalTimeout)
+	ctx, cancel := reqContext.WithTimeout(parent, normalTimeout)
 	defer cancel()
-	return conn.ProcessTransactionProposal(ctx, mockProcessProposalRequest())
+	return conn.ProcessTransactionProposal(ctx, request)
 }
 
 func getPeerEndorserRequest(url string, cert *x509.Certificate, serverHostOverride string,
@@ -281,6 +403,8 @@ func TestEndorserRPCError(t *testing.T) {
 	assert.Equal(t, status.GRPCTransportStatus, statusError.Group)
 	assert.Equal(t, testErrorMessage, statusError.Message)
 
+	assert.Equal(t, []interface{}{addr}, statusError.Details, "Expected the target in the details")
+
 	grpcCode := status.ToGRPCStatusCode(statusError.Code)
 	assert.Equal(t, grpcCodes.Unknown, grpcCode)
 }
@@ -53,11 +53,20 @@ func (th *TransactionHeader) ChannelID() string {
 
 // NewHeader computes a TransactionID from the current user context and holds
 // metadata to create transaction proposals.
-func NewHeader(ctx contextApi.Client, channelID string) (*TransactionHeader, error) {
-	// generate a random nonce
-	nonce, err := crypto.GetRandomNonce()
-	if err != nil {
-		return nil, errors.WithMessage(err, "nonce creation failed")
+func NewHeader(ctx contextApi.Client, channelID string, opts ...fab.TxnHeaderOpt) (*TransactionHeader, error) {
+	var options fab.TxnHeaderOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	nonce := options.Nonce
+	if nonce == nil {
+		// generate a random nonce
+		var err error
+		nonce, err = crypto.GetRandomNonce()
+		if err != nil {
+			return nil, errors.WithMessage(err, "nonce creation failed")
+		}
 	}
 
 	creator, err := ctx.Serialize()
@@ -99,15 +108,14 @@ func computeTxnID(nonce, creator []byte, h hash.Hash) (string, error) {
 	return id, nil
 }
 
-// signPayload signs payload
-func signPayload(ctx contextApi.Client, payload *common.Payload) (*fab.SignedEnvelope, error) {
+// signPayload signs payload with the given signer
+func signPayload(signer fab.ProposalSigner, payload *common.Payload) (*fab.SignedEnvelope, error) {
 	payloadBytes, err := proto.Marshal(payload)
 	if err != nil {
 		return nil, errors.WithMessage(err, "marshaling of payload failed")
 	}
 
-	signingMgr := ctx.SigningManager()
-	signature, err := signingMgr.Sign(payloadBytes, ctx.PrivateKey())
+	signature, err := signer(payloadBytes)
 	if err != nil {
 		return nil, errors.WithMessage(err, "signing of payload failed")
 	}
@@ -57,19 +57,37 @@ func CreateChaincodeInvokeProposal(txh fab.TransactionHeader, request fab.Chainc
 	return &tp, nil
 }
 
-// signProposal creates a SignedProposal based on the current context.
-func signProposal(ctx contextApi.Client, proposal *pb.Proposal) (*pb.SignedProposal, error) {
-	proposalBytes, err := proto.Marshal(proposal)
-	if err != nil {
-		return nil, errors.Wrap(err, "mashal proposal failed")
-	}
-
+// contextSigner returns a signer that signs with the signing manager and private key of the given context.
+func contextSigner(ctx contextApi.Client) (fab.ProposalSigner, error) {
 	signingMgr := ctx.SigningManager()
 	if signingMgr == nil {
 		return nil, errors.New("signing manager is nil")
 	}
+	return func(msg []byte) ([]byte, error) {
+		return signingMgr.Sign(msg, ctx.PrivateKey())
+	}, nil
+}
+
+// requestSigner returns the given signer or, if it's nil, the signer of the client context of the request.
+func requestSigner(reqCtx reqContext.Context, signer fab.ProposalSigner) (fab.ProposalSigner, error) {
+	if signer != nil {
+		return signer, nil
+	}
+	ctx, ok := context.RequestClientContext(reqCtx)
+	if !ok {
+		return nil, errors.New("failed get client context from reqContext for signing")
+	}
+	return contextSigner(ctx)
+}
+
+// signProposal creates a SignedProposal using the given signer.
+func signProposal(signer fab.ProposalSigner, proposal *pb.Proposal) (*pb.SignedProposal, error) {
+	proposalBytes, err := proto.Marshal(proposal)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal proposal failed")
+	}
 
-	signature, err := signingMgr.Sign(proposalBytes, ctx.PrivateKey())
+	signature, err := signer(proposalBytes)
 	if err != nil {
 		return nil, errors.WithMessage(err, "sign failed")
 	}
@@ -78,7 +96,7 @@ func signProposal(ctx contextApi.Client, proposal *pb.Proposal) (*pb.SignedPropo
 }
 
 // SendProposal sends a TransactionProposal to ProposalProcessor.
-func SendProposal(reqCtx reqContext.Context, proposal *fab.TransactionProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+func SendProposal(reqCtx reqContext.Context, proposal *fab.TransactionProposal, targets []fab.ProposalProcessor, opts ...fab.SendProposalOpt) ([]*fab.TransactionProposalResponse, error) {
 
 	if proposal == nil {
 		return nil, errors.New("proposal is required")
@@ -88,16 +106,26 @@ func SendProposal(reqCtx reqContext.Context, proposal *fab.TransactionProposal,
 		return nil, errors.New("targets is required")
 	}
 
-	ctx, ok := context.RequestClientContext(reqCtx)
-	if !ok {
-		return nil, errors.New("failed get client context from reqContext for signProposal")
+	var options fab.SendProposalOptions
+	for _, opt := range opts {
+		opt(&options)
 	}
-	signedProposal, err := signProposal(ctx, proposal.Proposal)
+
+	signer, err := requestSigner(reqCtx, options.Signer)
+	if err != nil {
+		return nil, errors.WithMessage(err, "sign proposal failed")
+	}
+	signedProposal, err := signProposal(signer, proposal.Proposal)
 	if err != nil {
 		return nil, errors.WithMessage(err, "sign proposal failed")
 	}
 
-	request := fab.ProcessProposalRequest{SignedProposal: signedProposal}
+	request := fab.ProcessProposalRequest{
+		SignedProposal: signedProposal,
+		Compress:       options.Compress,
+		Metadata:       options.Metadata,
+		CallOptions:    options.CallOptions,
+	}
 
 	var responseMtx sync.Mutex
 	var transactionProposalResponses []*fab.TransactionProposalResponse
@@ -16,6 +16,7 @@ import (
 
 	"time"
 
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	mock_context "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
@@ -49,7 +50,7 @@ func TestNewTransactionProposal(t *testing.T) {
 		t.Fatalf("Create Transaction Proposal Failed: %s", err)
 	}
 
-	signedProposal, err := signProposal(ctx, tp.Proposal)
+	signedProposal, err := signProposal(testSigner(ctx, t), tp.Proposal)
 	if err != nil {
 		t.Fatalf("signProposal failed: %s", err)
 	}
@@ -186,7 +187,7 @@ func TestSendTransactionProposalToProcessors(t *testing.T) {
 	defer mockCtrl.Finish()
 	proc := mock_context.NewMockProposalProcessor(mockCtrl)
 
-	stp, err := signProposal(ctx, &pb.Proposal{})
+	stp, err := signProposal(testSigner(ctx, t), &pb.Proposal{})
 	if err != nil {
 		t.Fatalf("signProposal returned error: %s", err)
 	}
@@ -236,7 +237,7 @@ func TestProposalResponseError(t *testing.T) {
 	proc := mock_context.NewMockProposalProcessor(mockCtrl)
 	proc2 := mock_context.NewMockProposalProcessor(mockCtrl)
 
-	stp, err := signProposal(ctx, &pb.Proposal{})
+	stp, err := signProposal(testSigner(ctx, t), &pb.Proposal{})
 	if err != nil {
 		t.Fatalf("signProposal returned error: %s", err)
 	}
@@ -272,3 +273,12 @@ func setupMassiveTestPeers(numberOfPeers int) []fab.ProposalProcessor {
 
 	return peers
 }
+
+// testSigner returns the signer of the given context
+func testSigner(ctx contextApi.Client, t *testing.T) fab.ProposalSigner {
+	signer, err := contextSigner(ctx)
+	if err != nil {
+		t.Fatalf("contextSigner failed: %s", err)
+	}
+	return signer
+}
@@ -11,12 +11,14 @@ import (
 	"bytes"
 	reqContext "context"
 	"math/rand"
+	"sort"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
-	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
@@ -103,7 +105,7 @@ func New(request fab.TransactionRequest) (*fab.Transaction, error) {
 }
 
 // Send send a transaction to the chain’s orderer service (one or more orderer endpoints) for consensus and committing to the ledger.
-func Send(reqCtx reqContext.Context, tx *fab.Transaction, orderers []fab.Orderer) (*fab.TransactionResponse, error) {
+func Send(reqCtx reqContext.Context, tx *fab.Transaction, orderers []fab.Orderer, opts ...fab.SendTxnOpt) (*fab.TransactionResponse, error) {
 	if orderers == nil || len(orderers) == 0 {
 		return nil, errors.New("orderers is nil")
 	}
@@ -114,46 +116,110 @@ func Send(reqCtx reqContext.Context, tx *fab.Transaction, orderers []fab.Orderer
 		return nil, errors.New("proposal is nil")
 	}
 
-	// the original header
-	hdr, err := protos_utils.GetHeader(tx.Proposal.Proposal.Header)
+	payload, err := transactionPayload(tx)
 	if err != nil {
-		return nil, errors.Wrap(err, "unmarshal proposal header failed")
+		return nil, err
 	}
-	// serialize the tx
-	txBytes, err := protos_utils.GetBytesTransaction(tx.Transaction)
+
+	var options fab.SendTxnOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	transactionResponse, err := broadcastPayload(reqCtx, payload, orderers, options.OrdererComparator, options.Signer)
 	if err != nil {
 		return nil, err
 	}
 
-	// create the payload
-	payload := common.Payload{Header: hdr, Data: txBytes}
+	return transactionResponse, nil
+}
 
-	transactionResponse, err := BroadcastPayload(reqCtx, &payload, orderers)
+// CreateEnvelope creates the transaction with the given proposal and responses and returns the marshaled
+// common.Envelope of the transaction, signed by the client. This is the envelope that is broadcast to the orderer,
+// so it can be submitted by any Fabric tool (for example, the peer CLI) or SDK. The client must be the creator
+// of the proposal since the envelope is validated against the creator in the header of the proposal.
+func CreateEnvelope(ctx contextApi.Client, request fab.TransactionRequest) ([]byte, error) {
+	if request.Proposal == nil || request.Proposal.Proposal == nil {
+		return nil, errors.New("proposal is nil")
+	}
+
+	tx, err := New(request)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := transactionPayload(tx)
 	if err != nil {
 		return nil, err
 	}
 
-	return transactionResponse, nil
+	creator, err := ctx.Serialize()
+	if err != nil {
+		return nil, errors.WithMessage(err, "identity serialization failed")
+	}
+	signatureHeader, err := protos_utils.GetSignatureHeader(payload.Header.SignatureHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal signature header failed")
+	}
+	if !bytes.Equal(creator, signatureHeader.Creator) {
+		return nil, errors.New("the transaction must be signed by the creator of the proposal")
+	}
+
+	signer, err := contextSigner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := signPayload(signer, payload)
+	if err != nil {
+		return nil, err
+	}
+	envelopeBytes, err := proto.Marshal(&common.Envelope{Payload: envelope.Payload, Signature: envelope.Signature})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling of envelope failed")
+	}
+	return envelopeBytes, nil
+}
+
+// transactionPayload returns the payload of the given transaction with the header of its proposal
+func transactionPayload(tx *fab.Transaction) (*common.Payload, error) {
+	// the original header
+	hdr, err := protos_utils.GetHeader(tx.Proposal.Proposal.Header)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal proposal header failed")
+	}
+	// serialize the tx
+	txBytes, err := protos_utils.GetBytesTransaction(tx.Transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.Payload{Header: hdr, Data: txBytes}, nil
 }
 
 // BroadcastPayload will send the given payload to some orderer, picking random endpoints
 // until all are exhausted
 func BroadcastPayload(reqCtx reqContext.Context, payload *common.Payload, orderers []fab.Orderer) (*fab.TransactionResponse, error) {
+	return broadcastPayload(reqCtx, payload, orderers, nil, nil)
+}
+
+// broadcastPayload will send the given payload to some orderer, trying the preferred
+// orderers (according to the comparator) first and the others in random order. The payload
+// is signed by the given signer or, if nil, by the client context of the request.
+func broadcastPayload(reqCtx reqContext.Context, payload *common.Payload, orderers []fab.Orderer, comparator fab.OrdererComparator, signer fab.ProposalSigner) (*fab.TransactionResponse, error) {
 	// Check if orderers are defined
 	if len(orderers) == 0 {
 		return nil, errors.New("orderers not set")
 	}
 
-	ctx, ok := context.RequestClientContext(reqCtx)
-	if !ok {
-		return nil, errors.New("failed get client context from reqContext for signPayload")
+	signer, err := requestSigner(reqCtx, signer)
+	if err != nil {
+		return nil, errors.WithMessage(err, "signing of payload failed")
 	}
-	envelope, err := signPayload(ctx, payload)
+	envelope, err := signPayload(signer, payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return broadcastEnvelope(reqCtx, envelope, orderers)
+	return broadcastEnvelopeInOrder(reqCtx, envelope, orderOrderers(orderers, comparator))
 }
 
 // broadcastEnvelope will send the given envelope to some orderer, picking random endpoints
@@ -164,16 +230,31 @@ func broadcastEnvelope(reqCtx reqContext.Context, envelope *fab.SignedEnvelope,
 		return nil, errors.New("orderers not set")
 	}
 
-	// Copy aside the ordering service endpoints
+	return broadcastEnvelopeInOrder(reqCtx, envelope, orderOrderers(orderers, nil))
+}
+
+// orderOrderers returns the orderers in random order, or sorted by the
+// comparator (if any) with the orderers that compare equal in random order
+func orderOrderers(orderers []fab.Orderer, comparator fab.OrdererComparator) []fab.Orderer {
 	randOrderers := []fab.Orderer{}
-	for _, o := range orderers {
-		randOrderers = append(randOrderers, o)
+	for _, i := range rand.Perm(len(orderers)) {
+		randOrderers = append(randOrderers, orderers[i])
 	}
 
-	// Iterate them in a random order and try broadcasting 1 by 1
+	if comparator != nil {
+		sort.SliceStable(randOrderers, func(i, j int) bool {
+			return comparator(randOrderers[i], randOrderers[j])
+		})
+	}
+	return randOrderers
+}
+
+// broadcastEnvelopeInOrder tries broadcasting the envelope to the orderers 1 by 1
+// in the given order until it succeeds
+func broadcastEnvelopeInOrder(reqCtx reqContext.Context, envelope *fab.SignedEnvelope, orderers []fab.Orderer) (*fab.TransactionResponse, error) {
 	var errResp error
-	for _, i := range rand.Perm(len(randOrderers)) {
-		resp, err := sendBroadcast(reqCtx, envelope, randOrderers[i])
+	for _, orderer := range orderers {
+		resp, err := sendBroadcast(reqCtx, envelope, orderer)
 		if err != nil {
 			errResp = err
 		} else {
@@ -201,11 +282,11 @@ func SendPayload(reqCtx reqContext.Context, payload *common.Payload, orderers []
 		return nil, errors.New("orderers not set")
 	}
 
-	ctx, ok := context.RequestClientContext(reqCtx)
-	if !ok {
-		return nil, errors.New("failed get client context from reqContext for signPayload")
+	signer, err := requestSigner(reqCtx, nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, "signing of payload failed")
 	}
-	envelope, err := signPayload(ctx, payload)
+	envelope, err := signPayload(signer, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -15,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
@@ -217,6 +218,44 @@ func TestBroadcastEnvelope(t *testing.T) {
 	}
 }
 
+func TestBroadcastEnvelopeWithOrdererComparator(t *testing.T) {
+	user := mspmocks.NewMockSigningIdentity("test", "1234")
+	ctx := mocks.NewMockContext(user)
+
+	orderer1 := mocks.NewMockOrderer("orderer1", nil)
+	orderer2 := mocks.NewMockOrderer("orderer2", nil)
+	orderer3 := mocks.NewMockOrderer("orderer3", nil)
+	orderers := []fab.Orderer{orderer1, orderer2, orderer3}
+
+	preferOrderer2 := func(o1, o2 fab.Orderer) bool {
+		return o1.URL() == "orderer2" && o2.URL() != "orderer2"
+	}
+
+	sigEnvelope := &fab.SignedEnvelope{
+		Signature: []byte(""),
+		Payload:   []byte(""),
+	}
+
+	reqCtx, cancel := context.NewRequest(ctx, context.WithTimeout(10*time.Second))
+	defer cancel()
+
+	for i := 0; i < 10; i++ {
+		res, err := broadcastEnvelopeInOrder(reqCtx, sigEnvelope, orderOrderers(orderers, preferOrderer2))
+		if err != nil {
+			t.Fatalf("Test Broadcast Envelope Failed, cause %s", err)
+		}
+		assert.Equal(t, "orderer2", res.Orderer, "expecting the preferred orderer to be used")
+	}
+
+	// The preferred orderer is unavailable so one of the others should be used
+	orderer2.EnqueueSendBroadcastError(errors.New("Service Unavailable"))
+	res, err := broadcastEnvelopeInOrder(reqCtx, sigEnvelope, orderOrderers(orderers, preferOrderer2))
+	if err != nil {
+		t.Fatalf("Test Broadcast Envelope Failed, cause %s", err)
+	}
+	assert.NotEqual(t, "orderer2", res.Orderer)
+}
+
 func TestSendTransaction(t *testing.T) {
 	//Setup channel
 	user := mspmocks.NewMockSigningIdentity("test", "1234")
@@ -291,6 +330,111 @@ func TestSendTransaction(t *testing.T) {
 	}
 }
 
+func TestSendTransactionWithSigner(t *testing.T) {
+	user := mspmocks.NewMockSigningIdentity("test", "1234")
+	ctx := mocks.NewMockContext(user)
+
+	reqCtx, cancel := context.NewRequest(ctx, context.WithTimeout(10*time.Second))
+	defer cancel()
+
+	broadcasts := make(chan *fab.SignedEnvelope, 1)
+	orderer := mocks.NewMockOrderer("", broadcasts)
+	txn := fab.Transaction{
+		Proposal: &fab.TransactionProposal{
+			Proposal: &pb.Proposal{Header: []byte(""), Payload: []byte(""), Extension: []byte("")},
+		},
+		Transaction: &pb.Transaction{},
+	}
+
+	var signedPayload []byte
+	signer := func(payload []byte) ([]byte, error) {
+		signedPayload = payload
+		return []byte("external-signature"), nil
+	}
+	_, err := Send(reqCtx, &txn, []fab.Orderer{orderer}, fab.WithTransactionSigner(signer))
+	if err != nil {
+		t.Fatalf("Send failed: %s", err)
+	}
+	envelope := <-broadcasts
+	assert.Equal(t, []byte("external-signature"), envelope.Signature, "expecting the envelope to be signed by the external signer")
+	assert.Equal(t, envelope.Payload, signedPayload)
+
+	failingSigner := func(payload []byte) ([]byte, error) {
+		return nil, errors.New("HSM unavailable")
+	}
+	_, err = Send(reqCtx, &txn, []fab.Orderer{orderer}, fab.WithTransactionSigner(failingSigner))
+	if err == nil || !strings.Contains(err.Error(), "HSM unavailable") {
+		t.Fatal("Expected signer error, Received error:", err)
+	}
+}
+
+func TestCreateEnvelope(t *testing.T) {
+	user := mspmocks.NewMockSigningIdentity("test", "1234")
+	ctx := mocks.NewMockContext(user)
+
+	_, err := CreateEnvelope(ctx, fab.TransactionRequest{})
+	assert.NotNil(t, err, "expected error for missing proposal")
+
+	txh, err := NewHeader(ctx, testChannel)
+	if err != nil {
+		t.Fatalf("create transaction ID failed: %s", err)
+	}
+	proposal, err := CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{ChaincodeID: "cc", Fcn: "invoke", Args: [][]byte{[]byte("a")}})
+	if err != nil {
+		t.Fatalf("Create Transaction Proposal Failed: %s", err)
+	}
+	request := fab.TransactionRequest{
+		Proposal: proposal,
+		ProposalResponses: []*fab.TransactionProposalResponse{{
+			Endorser: "http://peer1.com",
+			ProposalResponse: &pb.ProposalResponse{
+				Response:    &pb.Response{Status: 200, Payload: []byte("A")},
+				Payload:     []byte("payload"),
+				Endorsement: &pb.Endorsement{Endorser: []byte("peer1"), Signature: []byte("signature")},
+			},
+		}},
+	}
+
+	envelopeBytes, err := CreateEnvelope(ctx, request)
+	assert.Nil(t, err)
+
+	envelope := &common.Envelope{}
+	assert.Nil(t, proto.Unmarshal(envelopeBytes, envelope))
+	assert.NotEmpty(t, envelope.Signature)
+	payload := &common.Payload{}
+	assert.Nil(t, proto.Unmarshal(envelope.Payload, payload))
+	assert.Equal(t, proposal.Proposal.Header, mustMarshal(payload.Header, t))
+	tx := &pb.Transaction{}
+	assert.Nil(t, proto.Unmarshal(payload.Data, tx))
+	assert.Equal(t, 1, len(tx.Actions))
+	actionPayload := &pb.ChaincodeActionPayload{}
+	assert.Nil(t, proto.Unmarshal(tx.Actions[0].Payload, actionPayload))
+	assert.Equal(t, []byte("payload"), actionPayload.Action.ProposalResponsePayload)
+	assert.Equal(t, []byte("signature"), actionPayload.Action.Endorsements[0].Signature)
+
+	// The envelope must be signed by the creator of the proposal
+	other := mocks.NewMockContext(&otherSigningIdentity{MockSigningIdentity: mspmocks.NewMockSigningIdentity("other", "5678")})
+	_, err = CreateEnvelope(other, request)
+	assert.NotNil(t, err, "expected error for a client that isn't the creator of the proposal")
+}
+
+// otherSigningIdentity is an identity that serializes differently from the mock identity
+type otherSigningIdentity struct {
+	*mspmocks.MockSigningIdentity
+}
+
+func (m *otherSigningIdentity) Serialize() ([]byte, error) {
+	return []byte("other"), nil
+}
+
+func mustMarshal(msg proto.Message, t *testing.T) []byte {
+	bytes, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal failed: %s", err)
+	}
+	return bytes
+}
+
 func TestBuildChannelHeader(t *testing.T) {
 	user := mspmocks.NewMockSigningIdentity("test", "1234")
 	ctx := mocks.NewMockContext(user)
@@ -311,13 +455,32 @@ func TestBuildChannelHeader(t *testing.T) {
 
 }
 
+func TestNewHeaderWithNonce(t *testing.T) {
+	user := mspmocks.NewMockSigningIdentity("test", "1234")
+	ctx := mocks.NewMockContext(user)
+
+	nonce := []byte("nonce")
+	txh1, err := NewHeader(ctx, "test", fab.WithNonce(nonce))
+	assert.Nil(t, err, "NewHeader failed")
+	assert.Equal(t, nonce, txh1.Nonce())
+
+	txh2, err := NewHeader(ctx, "test", fab.WithNonce(nonce))
+	assert.Nil(t, err, "NewHeader failed")
+	assert.Equal(t, txh1.TransactionID(), txh2.TransactionID(), "expecting the same transaction ID for the same nonce and creator")
+
+	txh3, err := NewHeader(ctx, "test")
+	assert.Nil(t, err, "NewHeader failed")
+	assert.NotEqual(t, nonce, txh3.Nonce())
+	assert.NotEqual(t, txh1.TransactionID(), txh3.TransactionID())
+}
+
 func TestSignPayload(t *testing.T) {
 	user := mspmocks.NewMockSigningIdentity("test", "1234")
 	ctx := mocks.NewMockContext(user)
 
 	payload := common.Payload{}
 
-	signedEnv, err := signPayload(ctx, &payload)
+	signedEnv, err := signPayload(testSigner(ctx, t), &payload)
 
 	if err != nil || signedEnv == nil {
 		t.Fatal("Test Sign Payload Failed")
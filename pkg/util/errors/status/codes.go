@@ -41,18 +41,84 @@ const (
 
 	// MultipleErrors multiple errors occurred
 	MultipleErrors Code = 7
+
+	// InvalidPayload is returned when a response payload fails validation by the SDK
+	InvalidPayload Code = 8
+
+	// PayloadTooLarge is returned when a response payload exceeds the size limit of the SDK
+	PayloadTooLarge Code = 9
+
+	// ChaincodeVersionMismatch is returned when the endorsers ran different versions of the chaincode
+	ChaincodeVersionMismatch Code = 10
+
+	// OrdererRejected is returned when the ordering service rejects a transaction (as opposed to
+	// the peers invalidating it, which is reported with the transaction validation code)
+	OrdererRejected Code = 11
+
+	// EndorserRevoked is returned when the identity of an endorser is revoked by a CRL
+	EndorserRevoked Code = 12
+
+	// RetryBudgetExhausted is returned when the retries of an invoke exceed its retry budget
+	RetryBudgetExhausted Code = 13
+
+	// EmptyPayload is returned when a successful endorsement carries an empty payload although a payload is required
+	EmptyPayload Code = 14
+
+	// VetoedBeforeSubmit is returned when a transaction is vetoed after endorsement, before being submitted
+	// to the ordering service
+	VetoedBeforeSubmit Code = 15
+
+	// ConcurrencyLimitExceeded is returned when the maximum number of invokes in the endorsement phase is reached
+	ConcurrencyLimitExceeded Code = 16
+
+	// NoCollectionEndorsers is returned when none of the peers that are members of the private data
+	// collections written by the chaincode is available to endorse
+	NoCollectionEndorsers Code = 17
+
+	// TransactionTooLarge is returned when the estimated size of the transaction envelope exceeds the size limit
+	// of the SDK
+	TransactionTooLarge Code = 18
+
+	// StatusDisagreement is returned when the endorsers are required to agree on the response status but
+	// returned different statuses
+	StatusDisagreement Code = 19
+
+	// StaleEndorsement is returned when the proposal of the endorsements is older than the maximum endorsement age
+	StaleEndorsement Code = 20
+
+	// ConfirmationMismatch is returned when the response of the confirmation peer disagrees with the endorsed result
+	ConfirmationMismatch Code = 21
+
+	// ConfigSequenceChanged is returned when the channel config sequence changed between the selection of the
+	// endorsers and the submission of the transaction
+	ConfigSequenceChanged Code = 22
 )
 
 // CodeName maps the codes in this packages to human-readable strings
 var CodeName = map[int32]string{
-	0: "OK",
-	1: "UNKNOWN",
-	2: "CONNECTION_FAILED",
-	3: "ENDORSEMENT_MISMATCH",
-	4: "EMPTY_CERT",
-	5: "TIMEOUT",
-	6: "NO_PEERS_FOUND",
-	7: "MULTIPLE_ERRORS",
+	0:  "OK",
+	1:  "UNKNOWN",
+	2:  "CONNECTION_FAILED",
+	3:  "ENDORSEMENT_MISMATCH",
+	4:  "EMPTY_CERT",
+	5:  "TIMEOUT",
+	6:  "NO_PEERS_FOUND",
+	7:  "MULTIPLE_ERRORS",
+	8:  "INVALID_PAYLOAD",
+	9:  "PAYLOAD_TOO_LARGE",
+	10: "CHAINCODE_VERSION_MISMATCH",
+	11: "ORDERER_REJECTED",
+	12: "ENDORSER_REVOKED",
+	13: "RETRY_BUDGET_EXHAUSTED",
+	14: "EMPTY_PAYLOAD",
+	15: "VETOED_BEFORE_SUBMIT",
+	16: "CONCURRENCY_LIMIT_EXCEEDED",
+	17: "NO_COLLECTION_ENDORSERS",
+	18: "TRANSACTION_TOO_LARGE",
+	19: "STATUS_DISAGREEMENT",
+	20: "STALE_ENDORSEMENT",
+	21: "CONFIRMATION_MISMATCH",
+	22: "CONFIG_SEQUENCE_CHANGED",
 }
 
 // ToInt32 cast to int32
@@ -109,6 +109,11 @@ func FromError(err error) (s *Status, ok bool) {
 		return s, true
 	}
 	if m, ok := unwrappedErr.(multi.Errors); ok {
+		if len(m) == 1 {
+			if s, ok := FromError(m[0]); ok {
+				return s, true
+			}
+		}
 		return New(ClientStatus, MultipleErrors.ToInt32(), m.Error(), nil), true
 	}
 
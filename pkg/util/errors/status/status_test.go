@@ -74,6 +74,12 @@ func TestFromError(t *testing.T) {
 	assert.Equal(t, ClientStatus, s.Group)
 	assert.EqualValues(t, MultipleErrors.ToInt32(), s.Code)
 	assert.Equal(t, errs.Error(), s.Message)
+
+	// The status of a single wrapped error is preserved
+	endorserStatus := New(EndorserServerStatus, 500, "Test", nil)
+	s, ok = FromError(errors.WithMessage(multi.Errors{endorserStatus}, "wrapped"))
+	assert.True(t, ok)
+	assert.Equal(t, endorserStatus, s)
 }
 
 func TestStatusToError(t *testing.T) {